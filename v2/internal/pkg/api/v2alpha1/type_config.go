@@ -25,6 +25,9 @@ type ImageSetConfigurationSpec struct {
 	Mirror Mirror `json:"mirror"`
 	// ArchiveSize is the size of the segmented archive in GB
 	ArchiveSize int64 `json:"archiveSize,omitempty"`
+	// ClusterResources defines the configuration for the generation
+	// of the resources under cluster-resources.
+	ClusterResources ClusterResources `json:"clusterResources,omitempty"`
 }
 
 // DeleteImageSetConfiguration object kind.
@@ -63,6 +66,10 @@ type Mirror struct {
 	// Samples defines the configuration for Sample content types.
 	// This is currently not implemented.
 	Samples []SampleImages `json:"samples,omitempty"`
+	// ImagePolicies defines the signature verification policies
+	// from which ClusterImagePolicy (or ImagePolicy) resources are
+	// generated for the mirrored content.
+	ImagePolicies []ImagePolicy `json:"imagePolicies,omitempty"`
 }
 
 // Delete defines the configuration for content types within the imageset.
@@ -79,8 +86,31 @@ type Delete struct {
 	// Samples defines the configuration for Sample content types.
 	// This is currently not implemented.
 	Samples []SampleImages `json:"samples,omitempty"`
+	// Age selects, among the images recorded by the previous mirror runs,
+	// the ones not mirrored for a while. They are deleted along with the content above.
+	Age *AgePolicy `json:"age,omitempty"`
 }
 
+// AgePolicy selects the images to delete by the date they were last mirrored.
+type AgePolicy struct {
+	// MaxAgeDays is the number of days after which an image not mirrored again is deleted.
+	MaxAgeDays int `json:"maxAgeDays"`
+	// Types restricts the policy to some of the AgePolicyTypes: ocpRelease, generic or helmImage.
+	// All the AgePolicyTypes are considered when empty.
+	Types []ImageType `json:"types,omitempty"`
+	// KeepLast is the number of images last mirrored to each repository that are never deleted,
+	// whatever their age. It is counted per destination repository, by the date the images were
+	// last mirrored: the release channels of the images are not recorded.
+	KeepLast int `json:"keepLast,omitempty"`
+}
+
+// AgePolicyTypes are the image types an AgePolicy can delete.
+// The release component images are not selected: they are not recorded with their release, so
+// they are left in place when the release image is deleted by age. The operator images are not
+// selected either: their catalogs would still reference them. Delete them with the platform and
+// operators sections.
+var AgePolicyTypes = []ImageType{TypeOCPRelease, TypeGeneric, TypeHelmImage}
+
 // Platform defines the configuration for OpenShift and OKD platform types.
 type Platform struct {
 	// Graph defines whether Cincinnati graph data will
@@ -172,17 +202,90 @@ type Operator struct {
 	// the catalog will be publish with the provided tag in the Catalog
 	// field or a tag calculated from the partial digest.
 	TargetTag string `json:"targetTag,omitempty"`
+	// MergeTargetCatalog combines the filtered content of this catalog with the one of the
+	// other catalogs with MergeTargetCatalog and the same TargetCatalog and TargetTag, into a
+	// single rebuilt catalog image. The catalogs cannot have packages in common.
+	MergeTargetCatalog bool `json:"mergeTargetCatalog,omitempty"`
 	// Full defines whether all packages within the catalog
 	// or specified IncludeConfig will be mirrored or just channel heads.
 	Full bool `json:"full,omitempty"`
 	// SkipDependencies will not include dependencies
 	// of bundles included in the diff if true.
+	// Otherwise, the olm.package.required and olm.gvk.required dependencies of the
+	// filtered bundles are resolved in the catalog, and the bundles satisfying them are mirrored too.
 	SkipDependencies bool `json:"skipDependencies,omitempty"`
+	// ResolveDependenciesAcrossCatalogs looks for the dependencies not found in this catalog
+	// in the other catalogs of the ImageSetConfiguration setting it too, whose packages are
+	// extended with the bundles satisfying them. Disabled by default.
+	ResolveDependenciesAcrossCatalogs bool `json:"resolveDependenciesAcrossCatalogs,omitempty"`
+	// BundleSelector selects the bundles of all packages based on their
+	// properties and CSV metadata. It can be replaced at the package level.
+	BundleSelector *BundleSelector `json:"bundleSelector,omitempty"`
+	// ExcludePackages are packages that are never mirrored, even when
+	// the full catalog is mirrored.
+	ExcludePackages []string `json:"excludePackages,omitempty"`
+	// PlatformCompatibility excludes the bundles that cannot be installed
+	// on any of the targeted OpenShift versions.
+	PlatformCompatibility *PlatformCompatibility `json:"platformCompatibility,omitempty"`
+	// DeprecatedContent defines how the packages, channels and bundles marked as
+	// deprecated in the catalog's olm.deprecations are handled: preserve (default), warn or skip.
+	DeprecatedContent DeprecatedContentMode `json:"deprecatedContent,omitempty"`
+	// AdditionalBundles are operator bundles, from local bundle directories or bundle images,
+	// added to the filtered content of the catalog before it is rebuilt.
+	AdditionalBundles []AdditionalBundle `json:"additionalBundles,omitempty"`
 	// path on disk for a template to use to complete catalogSource custom resource
 	// generated by oc-mirror
 	TargetCatalogSourceTemplate string `json:"targetCatalogSourceTemplate,omitempty"`
 }
 
+// AdditionalBundle is an operator bundle added to a rebuilt catalog, in the channels
+// declared by the annotations of its metadata.
+type AdditionalBundle struct {
+	// Image is the reference of the bundle image, mirrored with the catalog.
+	// It must already exist: oc-mirror does not build nor push bundle images.
+	Image string `json:"image"`
+	// Path is a local bundle directory, containing the manifests and metadata
+	// directories of the bundle. When set, the bundle is rendered from this directory
+	// instead of Image, which must be the bundle image already built from it.
+	// Otherwise, the bundle is rendered from Image.
+	Path string `json:"path,omitempty"`
+}
+
+// DeprecatedContentMode defines how the deprecated content of a catalog is handled
+type DeprecatedContentMode string
+
+const (
+	// DeprecatedContentPreserve mirrors the deprecated content, and keeps its deprecation
+	// metadata in the rebuilt catalog, so that OLM still warns the users
+	DeprecatedContentPreserve DeprecatedContentMode = "preserve"
+	// DeprecatedContentWarn preserves the deprecated content, and reports it
+	DeprecatedContentWarn DeprecatedContentMode = "warn"
+	// DeprecatedContentSkip does not mirror the deprecated packages, channels and bundles
+	DeprecatedContentSkip DeprecatedContentMode = "skip"
+)
+
+// IsValid returns true for the known modes, or when the mode is not set
+func (m DeprecatedContentMode) IsValid() bool {
+	switch m {
+	case "", DeprecatedContentPreserve, DeprecatedContentWarn, DeprecatedContentSkip:
+		return true
+	default:
+		return false
+	}
+}
+
+// PlatformCompatibility defines the range of OpenShift versions the operators are mirrored for.
+// Bundles whose olm.maxOpenShiftVersion is lower than MinOpenShiftVersion, or whose
+// minKubeVersion is higher than the Kubernetes version of MaxOpenShiftVersion, are excluded.
+// When both versions are empty, the range covered by the platform channels
+// of the ImageSetConfiguration is used.
+type PlatformCompatibility struct {
+	// MinOpenShiftVersion is the oldest OpenShift version targeted.
+	MinOpenShiftVersion string `json:"minOpenShiftVersion,omitempty"`
+	// MaxOpenShiftVersion is the newest OpenShift version targeted.
+	MaxOpenShiftVersion string `json:"maxOpenShiftVersion,omitempty"`
+}
+
 // GetUniqueName determines the catalog name that will
 // be tracked in the metadata and built. This depends on what fields
 // are set between Catalog, TargetName, and TargetTag.
@@ -277,3 +380,93 @@ type Image struct {
 type SampleImages struct {
 	Image `json:",inline"`
 }
+
+// ImagePolicy defines a signature verification policy for the mirrored
+// release and operator images.
+type ImagePolicy struct {
+	// Name of the generated ClusterImagePolicy or ImagePolicy resources.
+	Name string `json:"name"`
+	// Namespace, when set, generates namespaced ImagePolicy resources
+	// instead of ClusterImagePolicy resources.
+	Namespace string `json:"namespace,omitempty"`
+	// Scopes restricts the policy to the mirrored images whose original
+	// reference starts with one of these repository prefixes
+	// (e.g. quay.io/openshift-release-dev).
+	// When empty, all mirrored release and operator images are in scope.
+	Scopes []string `json:"scopes,omitempty"`
+	// PublicKeyFile is the path on disk to the PEM encoded public key
+	// the images are signed with.
+	PublicKeyFile string `json:"publicKeyFile,omitempty"`
+	// FulcioCAFile is the path on disk to the PEM encoded Fulcio CA
+	// certificate, for keyless (sigstore) signatures.
+	FulcioCAFile string `json:"fulcioCAFile,omitempty"`
+	// RekorKeyFile is the path on disk to the PEM encoded Rekor public key.
+	// It is mandatory when FulcioCAFile is set.
+	RekorKeyFile string `json:"rekorKeyFile,omitempty"`
+	// OIDCIssuer is the OIDC issuer of the Fulcio certificates.
+	OIDCIssuer string `json:"oidcIssuer,omitempty"`
+	// SignedEmail is the email of the signer in the Fulcio certificates.
+	SignedEmail string `json:"signedEmail,omitempty"`
+}
+
+// ClusterResources defines the configuration for the generation
+// of the resources under cluster-resources.
+type ClusterResources struct {
+	// MirrorScope defines how the IDMS/ITMS entries are aggregated.
+	MirrorScope MirrorScope `json:"mirrorScope,omitempty"`
+	// Kustomize, when set, emits cluster-resources as a kustomization.
+	Kustomize *Kustomize `json:"kustomize,omitempty"`
+}
+
+// Kustomize defines the kustomization.yaml generated in cluster-resources,
+// so that GitOps tooling can sync the generated resources directly.
+type Kustomize struct {
+	// NamePrefix is prepended to the names of all resources.
+	NamePrefix string `json:"namePrefix,omitempty"`
+	// Labels are added to the metadata of all resources.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ScopeAggregation is the level up to which the sources of
+// IDMS/ITMS entries are aggregated.
+type ScopeAggregation string
+
+const (
+	// RepositoryScope generates an entry per mirrored repository.
+	RepositoryScope ScopeAggregation = "repository"
+	// NamespaceScope generates an entry per namespace of the mirrored repositories. This is the default.
+	NamespaceScope ScopeAggregation = "namespace"
+	// RegistryScope generates an entry per registry of the mirrored repositories.
+	RegistryScope ScopeAggregation = "registry"
+)
+
+// MirrorScope defines how the IDMS/ITMS entries are aggregated.
+// Aggregation only happens when it is safe: all repositories under
+// the aggregated source are mirrored under the same destination prefix.
+// Otherwise, oc-mirror falls back to a narrower scope.
+type MirrorScope struct {
+	// Aggregation is the widest scope allowed for IDMS/ITMS sources.
+	// One of repository, namespace (default) or registry.
+	Aggregation ScopeAggregation `json:"aggregation,omitempty"`
+	// Overrides sets a different aggregation for the sources
+	// under a given prefix. The longest matching prefix wins.
+	Overrides []MirrorScopeOverride `json:"overrides,omitempty"`
+}
+
+// MirrorScopeOverride sets the aggregation for the sources under a prefix.
+type MirrorScopeOverride struct {
+	// Source is the prefix (registry, namespace or repository) of the original images.
+	Source string `json:"source"`
+	// Aggregation is the widest scope allowed for the IDMS/ITMS sources under Source.
+	Aggregation ScopeAggregation `json:"aggregation"`
+}
+
+// IsValid returns true when the aggregation is one of the supported values (or empty).
+func (a ScopeAggregation) IsValid() bool {
+	switch a {
+	case "", RepositoryScope, NamespaceScope, RegistryScope:
+		return true
+	default:
+		return false
+	}
+}
@@ -25,6 +25,16 @@ type IncludePackage struct {
 
 	// All channels containing these bundles are parsed for an upgrade graph.
 	IncludeBundle `json:",inline"`
+
+	// BundleSelector selects the bundles of this package based on their
+	// properties and CSV metadata. It replaces the catalog's BundleSelector.
+	BundleSelector *BundleSelector `json:"bundleSelector,omitempty" yaml:"bundleSelector,omitempty"`
+
+	// ExcludeChannels are channels of this package that are never mirrored.
+	// Bundles only reachable from these channels are excluded as well.
+	ExcludeChannels []string `json:"excludeChannels,omitempty" yaml:"excludeChannels,omitempty"`
+	// ExcludeBundles are bundles of this package that are never mirrored.
+	ExcludeBundles []string `json:"excludeBundles,omitempty" yaml:"excludeBundles,omitempty"`
 }
 
 // IncludeChannel contains a name (required) and versions (optional)
@@ -49,6 +59,24 @@ type IncludeBundle struct {
 	// MinBundle string `json:"minBundle,omitempty" yaml:"minBundle,omitempty"`
 }
 
+// BundleSelector selects bundles based on their properties and CSV metadata.
+// A bundle is selected only if it matches all the criteria set.
+type BundleSelector struct {
+	// OpenShiftVersion excludes the bundles that cannot be installed on this
+	// OpenShift version, because their olm.maxOpenShiftVersion is lower.
+	// Bundles without olm.maxOpenShiftVersion are kept.
+	OpenShiftVersion string `json:"openShiftVersion,omitempty" yaml:"openShiftVersion,omitempty"`
+	// Annotations the CSV must have, with the same values.
+	// For example features.operators.openshift.io/disconnected: "true".
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	// Architectures the CSV must support, through its operatorframework.io/arch.<arch> labels.
+	// CSVs without any such label only support amd64.
+	Architectures []string `json:"architectures,omitempty" yaml:"architectures,omitempty"`
+	// ExcludeDeprecated excludes the packages, channels and bundles marked as deprecated in olm.deprecations,
+	// as DeprecatedContent skip does for the whole catalog.
+	ExcludeDeprecated bool `json:"excludeDeprecated,omitempty" yaml:"excludeDeprecated,omitempty"`
+}
+
 // Encode IncludeConfig in an efficient, opaque format.
 func (ic *IncludeConfig) Encode(w io.Writer) error {
 	enc := gob.NewEncoder(w)
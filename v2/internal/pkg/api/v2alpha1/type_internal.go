@@ -247,6 +247,11 @@ type DeleteImageList struct {
 	Kind       string       `json:"kind"`
 	APIVersion string       `json:"apiVersion"`
 	Items      []DeleteItem `json:"items"`
+	// Protected are the images of the DeleteImageSetConfiguration that are not deleted,
+	// because the kept image sets still reference them
+	Protected []DeleteItem `json:"protected,omitempty"`
+	// Catalogs are the operator catalogs of the destination registry the deleted operators are removed from
+	Catalogs []DeleteCatalog `json:"catalogs,omitempty"`
 }
 
 type DeleteItem struct {
@@ -255,6 +260,43 @@ type DeleteItem struct {
 	Type           ImageType `json:"type"`
 }
 
+// DeleteCatalog is an operator catalog of the destination registry containing deleted operators.
+// The catalog is replaced by the catalog rebuilt without them, or deleted when no operator remains in it.
+type DeleteCatalog struct {
+	ImageName      string `json:"imageName"`
+	ImageReference string `json:"imageReference"`
+	// ReplacementTag is the tag of the catalog rebuilt without the deleted operators in the local cache
+	ReplacementTag string `json:"replacementTag,omitempty"`
+	// CacheTags are the tags of the catalogs rebuilt in the local cache, deleted along with the catalog
+	CacheTags []string `json:"cacheTags,omitempty"`
+}
+
+// MirroredImageList records the images mirrored to a registry, with the date they were mirrored
+type MirroredImageList struct {
+	Kind       string          `json:"kind"`
+	APIVersion string          `json:"apiVersion"`
+	Items      []MirroredImage `json:"items"`
+}
+
+type MirroredImage struct {
+	ImageName      string    `json:"imageName"`
+	ImageReference string    `json:"imageReference"`
+	Type           ImageType `json:"type"`
+	FirstMirrored  time.Time `json:"firstMirrored"`
+	LastMirrored   time.Time `json:"lastMirrored"`
+}
+
+// MirrorReceipt records the images a diskToMirror workflow pushed to a registry, with the digests of their
+// manifests and blobs: the mirrorToDisk workflow leaves these digests out of the next archive
+type MirrorReceipt struct {
+	Kind        string    `json:"kind"`
+	APIVersion  string    `json:"apiVersion"`
+	Destination string    `json:"destination"`
+	Updated     time.Time `json:"updated"`
+	Images      []string  `json:"images"`
+	Digests     []string  `json:"digests"`
+}
+
 type CatalogFilterResult struct {
 	OperatorFilter     Operator
 	FilteredConfigPath string
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	digest "github.com/opencontainers/go-digest"
@@ -25,6 +26,13 @@ type MirrorArchive struct {
 	cacheDir     string
 	history      history.History
 	blobGatherer BlobsGatherer
+	// receipt is the receipt of a diskToMirror workflow, listing the blobs to leave out instead of the history
+	receipt string
+	// deliveredSources list the blobs the destination already has, left out of the archive along with the history
+	deliveredSources []string
+	// ignoreHistory leaves out the blobs of deliveredSources only, and not the blobs of the previous archives
+	ignoreHistory bool
+	delivered     deliveredBlobs
 }
 
 // NewMirrorArchive creates a new MirrorArchive instance with strictAdder:
@@ -54,13 +62,17 @@ func NewMirrorArchive(opts *mirror.CopyOptions, destination, iscPath, workingDir
 		return &MirrorArchive{}, err
 	}
 	ma := MirrorArchive{
-		destination:  destination,
-		history:      history,
-		blobGatherer: bg,
-		workingDir:   workingDir,
-		cacheDir:     cacheDir,
-		iscPath:      iscPath,
-		adder:        a,
+		destination:      destination,
+		history:          history,
+		blobGatherer:     bg,
+		workingDir:       workingDir,
+		cacheDir:         cacheDir,
+		iscPath:          iscPath,
+		adder:            a,
+		receipt:          opts.Global.Receipt,
+		deliveredSources: opts.Global.DeliveredBlobs,
+		ignoreHistory:    opts.Global.IgnoreHistory,
+		delivered:        newDeliveredBlobs(logg, opts),
 	}
 	return &ma, nil
 }
@@ -89,12 +101,16 @@ func NewPermissiveMirrorArchive(opts *mirror.CopyOptions, destination, iscPath,
 	}
 
 	ma := MirrorArchive{
-		destination:  destination,
-		history:      history,
-		blobGatherer: bg,
-		workingDir:   workingDir,
-		cacheDir:     cacheDir,
-		iscPath:      iscPath,
+		destination:      destination,
+		history:          history,
+		blobGatherer:     bg,
+		workingDir:       workingDir,
+		cacheDir:         cacheDir,
+		iscPath:          iscPath,
+		receipt:          opts.Global.Receipt,
+		deliveredSources: opts.Global.DeliveredBlobs,
+		ignoreHistory:    opts.Global.IgnoreHistory,
+		delivered:        newDeliveredBlobs(logg, opts),
 
 		adder: a,
 	}
@@ -127,45 +143,99 @@ func (o *MirrorArchive) BuildArchive(ctx context.Context, collectedImages []v2al
 		return fmt.Errorf("unable to add image set configuration to the archive : %w", err)
 	}
 	// 4 - Add blobs
-	blobsInHistory, err := o.history.Read()
-	if err != nil && !errors.Is(err, &history.EmptyHistoryError{}) {
-		return fmt.Errorf("unable to read history metadata from working-dir : %w", err)
+	blobsInHistory, err := o.blobsDelivered(ctx)
+	if err != nil {
+		return err
 	}
-	// ignoring the error otherwise: continuing with an empty map in blobsInHistory
 
-	addedBlobs, err := o.addImagesDiff(ctx, collectedImages, blobsInHistory)
+	addedBlobs, addedByImage, err := o.addImagesDiff(ctx, collectedImages, blobsInHistory)
 	if err != nil {
 		return fmt.Errorf("unable to add image blobs to the archive : %w", err)
 	}
-	// 5 - update history file with addedBlobs
+	// 5 - update history file with addedBlobs, and record the images which added them
 	_, err = o.history.Append(addedBlobs)
 	if err != nil {
 		return fmt.Errorf("unable to update history metadata: %w", err)
 	}
+	err = o.history.RecordImages(addedByImage)
+	if err != nil {
+		return fmt.Errorf("unable to update history metadata: %w", err)
+	}
 
 	return nil
 }
 
-func (o *MirrorArchive) addImagesDiff(ctx context.Context, collectedImages []v2alpha1.CopyImageSchema, historyBlobs map[string]struct{}) (map[string]struct{}, error) {
+// blobsDelivered returns the blobs to leave out of the archive: the blobs listed by the receipt of the
+// diskToMirror workflow when one is used, the blobs of the previous archives otherwise, unless ignoreHistory is set.
+// The blobs the delivered sources list are added to them.
+func (o *MirrorArchive) blobsDelivered(ctx context.Context) (map[string]struct{}, error) {
+	blobs, err := o.blobsInHistory()
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range o.deliveredSources {
+		delivered, err := o.delivered.From(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the blobs delivered according to %s : %w", source, err)
+		}
+		for blob := range delivered {
+			blobs[blob] = struct{}{}
+		}
+	}
+	return blobs, nil
+}
+
+// blobsInHistory returns the blobs listed by the receipt when one is used, the blobs of the previous archives otherwise
+func (o *MirrorArchive) blobsInHistory() (map[string]struct{}, error) {
+	if o.receipt != "" {
+		receipt, err := ReadReceipt(o.receipt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the receipt : %w", err)
+		}
+		return toSet(receipt.Digests), nil
+	}
+	if o.ignoreHistory {
+		return make(map[string]struct{}), nil
+	}
+	blobsInHistory, err := o.history.Read()
+	if err != nil && !errors.Is(err, &history.EmptyHistoryError{}) {
+		return nil, fmt.Errorf("unable to read history metadata from working-dir : %w", err)
+	}
+	if blobsInHistory == nil {
+		blobsInHistory = make(map[string]struct{})
+	}
+	// ignoring the error otherwise: continuing with an empty map in blobsInHistory
+	return blobsInHistory, nil
+}
+
+// addImagesDiff adds the blobs of the images which are not in history to the archive, and returns them,
+// along with the blobs added by each image
+func (o *MirrorArchive) addImagesDiff(ctx context.Context, collectedImages []v2alpha1.CopyImageSchema, historyBlobs map[string]struct{}) (map[string]struct{}, map[string][]string, error) {
 	allAddedBlobs := make(map[string]struct{})
+	addedByImage := make(map[string][]string)
 	for _, img := range collectedImages {
 		imgBlobs, err := o.blobGatherer.GatherBlobs(ctx, img.Destination)
 		if err != nil && !errors.As(err, &SignatureBlobGathererError{}) {
-			return nil, fmt.Errorf("unable to find blobs corresponding to %s: %w", img.Destination, err)
+			return nil, nil, fmt.Errorf("unable to find blobs corresponding to %s: %w", img.Destination, err)
 		}
 
 		addedBlobs, err := o.addBlobsDiff(imgBlobs, historyBlobs, allAddedBlobs)
 		if err != nil {
-			return nil, fmt.Errorf("unable to add blobs corresponding to %s: %w", img.Destination, err)
+			return nil, nil, fmt.Errorf("unable to add blobs corresponding to %s: %w", img.Destination, err)
 		}
 
+		name := img.Origin
+		if name == "" {
+			name = img.Source
+		}
 		for hash, value := range addedBlobs {
 			allAddedBlobs[hash] = value
+			addedByImage[name] = append(addedByImage[name], hash)
 		}
-
+		sort.Strings(addedByImage[name])
 	}
 
-	return allAddedBlobs, nil
+	return allAddedBlobs, addedByImage, nil
 }
 
 func (o *MirrorArchive) addBlobsDiff(collectedBlobs, historyBlobs map[string]struct{}, alreadyAddedBlobs map[string]struct{}) (map[string]struct{}, error) {
@@ -408,6 +408,10 @@ func (m mockHistory) Read() (map[string]struct{}, error) {
 	return historyMap, nil
 }
 
+func (m mockHistory) RecordImages(images map[string][]string) error {
+	return nil
+}
+
 func (m mockHistory) Append(inputMap map[string]struct{}) (map[string]struct{}, error) {
 	historyMap := map[string]struct{}{
 		"sha256:2e39d55595ea56337b5b788e96e6afdec3db09d2759d903cbe120468187c4644": {},
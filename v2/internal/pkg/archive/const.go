@@ -11,4 +11,6 @@ const (
 	segMultiplier         int64 = 1024 * 1024 * 1024
 	defaultSegSize        int64 = 500
 	archiveFileNameFormat       = "%s_%06d.tar"
+	// receiptFile lists the manifests and blobs pushed by the diskToMirror workflow, in its working-dir
+	receiptFile = "mirror-receipt.yaml"
 )
@@ -0,0 +1,212 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	digest "github.com/opencontainers/go-digest"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/consts"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/imagebuilder"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+// deliveredBlobs finds the manifests and blobs already present in the destination registry, to leave them out
+// of the archive. The sources are:
+// * docker://<registry>[/<namespace>] : a walk of the _catalog of the registry, or of a read-only replica of it,
+// limited to the repositories under namespace when set
+// * a directory of archives, or an archive : the blobs of previous archives
+// * any other file : a blob list exported from the registry, with one digest per line
+type deliveredBlobs struct {
+	log        clog.PluggableLoggerInterface
+	nameOpts   []name.Option
+	remoteOpts []remote.Option
+}
+
+// newDeliveredBlobs reaches the registries with the credentials and TLS settings of the destination
+func newDeliveredBlobs(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) deliveredBlobs {
+	if opts.SrcImage == nil || opts.DestImage == nil {
+		return deliveredBlobs{log: log}
+	}
+	builder := imagebuilder.NewBuilder(log, *opts)
+	return deliveredBlobs{log: log, nameOpts: builder.NameOpts, remoteOpts: builder.RemoteOpts}
+}
+
+// From returns the digests of the manifests and blobs present according to source
+func (o deliveredBlobs) From(ctx context.Context, source string) (map[string]struct{}, error) {
+	if strings.HasPrefix(source, consts.DockerProtocol) {
+		return o.fromRegistry(ctx, strings.TrimPrefix(source, consts.DockerProtocol))
+	}
+	path := strings.TrimPrefix(source, consts.FileProtocol)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		archives, err := filepath.Glob(filepath.Join(path, archiveFilePrefix+"_*.tar"))
+		if err != nil {
+			return nil, fmt.Errorf("error getting glob matches %w", err)
+		}
+		if len(archives) == 0 {
+			return nil, fmt.Errorf("no archive found in %s", path)
+		}
+		return fromArchives(archives)
+	}
+	if strings.HasSuffix(path, ".tar") {
+		return fromArchives([]string{path})
+	}
+	return fromBlobList(path)
+}
+
+// fromRegistry returns the digests of the manifests and blobs of the tags of the repositories of the registry,
+// under the namespace when location has a path. The tags which can not be read are left out.
+func (o deliveredBlobs) fromRegistry(ctx context.Context, location string) (map[string]struct{}, error) {
+	host, namespace, _ := strings.Cut(strings.TrimSuffix(location, "/"), "/")
+	reg, err := name.NewRegistry(host, o.nameOpts...)
+	if err != nil {
+		return nil, err
+	}
+	remoteOpts := append(append([]remote.Option{}, o.remoteOpts...), remote.WithContext(ctx))
+	repositories, err := remote.Catalog(ctx, reg, remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the repositories of %s: %w", host, err)
+	}
+
+	blobs := make(map[string]struct{})
+	walked := 0
+	for _, repository := range repositories {
+		if namespace != "" && repository != namespace && !strings.HasPrefix(repository, namespace+"/") {
+			continue
+		}
+		repo, err := name.NewRepository(host+"/"+repository, o.nameOpts...)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := remote.List(repo, remoteOpts...)
+		if err != nil {
+			o.log.Warn("unable to list the tags of %s, its blobs are not left out of the archive: %v", repo, err)
+			continue
+		}
+		for _, tag := range tags {
+			if err := o.addManifestBlobs(repo.Tag(tag), remoteOpts, blobs); err != nil {
+				o.log.Warn("unable to read %s:%s, its blobs are not left out of the archive: %v", repo, tag, err)
+			}
+		}
+		walked++
+	}
+	o.log.Debug("%d repositories of %s walked: %d manifests and blobs found", walked, location, len(blobs))
+	return blobs, nil
+}
+
+// addManifestBlobs adds the digest of the manifest of ref, and of the manifests and blobs it references, to blobs
+func (o deliveredBlobs) addManifestBlobs(ref name.Reference, remoteOpts []remote.Option, blobs map[string]struct{}) error {
+	desc, err := remote.Get(ref, remoteOpts...)
+	if err != nil {
+		return err
+	}
+	blobs[desc.Digest.String()] = struct{}{}
+	if desc.MediaType.IsIndex() {
+		index, err := v1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+		if err != nil {
+			return err
+		}
+		for _, m := range index.Manifests {
+			if _, ok := blobs[m.Digest.String()]; ok {
+				continue
+			}
+			if err := o.addManifestBlobs(ref.Context().Digest(m.Digest.String()), remoteOpts, blobs); err != nil {
+				// the manifests of the platforms which were not mirrored are missing
+				o.log.Debug("manifest %s of %s skipped: %v", m.Digest, ref, err)
+			}
+		}
+		return nil
+	}
+	manifest, err := v1.ParseManifest(bytes.NewReader(desc.Manifest))
+	if err != nil {
+		return err
+	}
+	blobs[manifest.Config.Digest.String()] = struct{}{}
+	for _, layer := range manifest.Layers {
+		blobs[layer.Digest.String()] = struct{}{}
+	}
+	return nil
+}
+
+// fromArchives returns the digests of the blobs of the archives
+func fromArchives(archives []string) (map[string]struct{}, error) {
+	blobs := make(map[string]struct{})
+	for _, path := range archives {
+		if err := addArchiveBlobs(path, blobs); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
+
+// addArchiveBlobs adds the digests of the blobs of the archive, stored as docker/registry/v2/blobs/<algorithm>/<xx>/<encoded>/data
+func addArchiveBlobs(path string, blobs map[string]struct{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening a file %w", err)
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read the archive %s: %w", path, err)
+		}
+		blobPath, ok := strings.CutPrefix(header.Name, cacheBlobsDir+"/")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(blobPath, "/")
+		if len(parts) != 4 || parts[3] != "data" {
+			continue
+		}
+		blobs[parts[0]+":"+parts[2]] = struct{}{}
+	}
+}
+
+// fromBlobList returns the digests of the blob list, skipping the empty lines and the # comments
+func fromBlobList(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening a file %w", err)
+	}
+	defer file.Close()
+
+	blobs := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		d, err := digest.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest %q at line %d of %s: %w", text, line, path, err)
+		}
+		blobs[d.String()] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error non-EOF found %w", err)
+	}
+	return blobs, nil
+}
@@ -0,0 +1,168 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+func TestDeliveredBlobsFrom(t *testing.T) {
+	delivered := deliveredBlobs{log: clog.New("trace"), nameOpts: []name.Option{name.Insecure}}
+
+	t.Run("Testing From - registry : should return the manifests and blobs of the repositories under the namespace", func(t *testing.T) {
+		s := httptest.NewServer(registry.New())
+		defer s.Close()
+		u, err := url.Parse(s.URL)
+		assert.NoError(t, err)
+
+		img, err := random.Image(1024, 2)
+		assert.NoError(t, err)
+		ref, err := name.ParseReference(u.Host+"/mirror/ubi9/ubi:latest", name.Insecure)
+		assert.NoError(t, err)
+		assert.NoError(t, remote.Write(ref, img))
+
+		index, err := random.Index(1024, 1, 2)
+		assert.NoError(t, err)
+		indexRef, err := name.ParseReference(u.Host+"/mirror/ubi8/ubi:latest", name.Insecure)
+		assert.NoError(t, err)
+		assert.NoError(t, remote.WriteIndex(indexRef, index))
+
+		other, err := random.Image(1024, 1)
+		assert.NoError(t, err)
+		otherRef, err := name.ParseReference(u.Host+"/other/ubi:latest", name.Insecure)
+		assert.NoError(t, err)
+		assert.NoError(t, remote.Write(otherRef, other))
+
+		expected := make(map[string]struct{})
+		addImageDigests(t, img, expected)
+		indexDigest, err := index.Digest()
+		assert.NoError(t, err)
+		expected[indexDigest.String()] = struct{}{}
+		indexManifest, err := index.IndexManifest()
+		assert.NoError(t, err)
+		for _, m := range indexManifest.Manifests {
+			child, err := index.Image(m.Digest)
+			assert.NoError(t, err)
+			addImageDigests(t, child, expected)
+		}
+
+		blobs, err := delivered.From(context.Background(), "docker://"+u.Host+"/mirror")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, blobs)
+
+		blobs, err = delivered.From(context.Background(), "docker://"+u.Host)
+		assert.NoError(t, err)
+		addImageDigests(t, other, expected)
+		assert.Equal(t, expected, blobs)
+	})
+
+	t.Run("Testing From - archives : should return the blobs of the archives", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTar(t, filepath.Join(dir, "mirror_000001.tar"), []string{
+			"docker/registry/v2/repositories/ubi9/ubi/_manifests/tags/latest/current/link",
+			"docker/registry/v2/blobs/sha256/aa/" + sha("a") + "/data",
+			"working-dir/.history/.history-2024-01-01T00:00:00Z",
+		})
+		writeTar(t, filepath.Join(dir, "mirror_000002.tar"), []string{
+			"docker/registry/v2/blobs/sha256/bb/" + sha("b") + "/data",
+		})
+
+		blobs, err := delivered.From(context.Background(), "file://"+dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:" + sha("a"): {}, "sha256:" + sha("b"): {}}, blobs)
+
+		blobs, err = delivered.From(context.Background(), filepath.Join(dir, "mirror_000002.tar"))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:" + sha("b"): {}}, blobs)
+
+		_, err = delivered.From(context.Background(), t.TempDir())
+		assert.ErrorContains(t, err, "no archive found")
+	})
+
+	t.Run("Testing From - blob list : should return the digests of the list", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blobs.txt")
+		assert.NoError(t, os.WriteFile(path, []byte("# exported from the registry\nsha256:"+sha("a")+"\n\n  sha256:"+sha("b")+"  \n"), 0600))
+		blobs, err := delivered.From(context.Background(), path)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:" + sha("a"): {}, "sha256:" + sha("b"): {}}, blobs)
+
+		assert.NoError(t, os.WriteFile(path, []byte("sha256:"+sha("a")+"\nnot-a-digest\n"), 0600))
+		_, err = delivered.From(context.Background(), path)
+		assert.ErrorContains(t, err, `invalid digest "not-a-digest" at line 2`)
+	})
+}
+
+func TestBlobsDeliveredSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blobs.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("sha256:"+sha("a")+"\n"), 0600))
+	delivered := deliveredBlobs{log: clog.New("trace")}
+
+	t.Run("Testing blobsDelivered - delivered blobs : should add them to the history", func(t *testing.T) {
+		ma := MirrorArchive{history: mockHistory{}, deliveredSources: []string{path}, delivered: delivered}
+		blobs, err := ma.blobsDelivered(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 6)
+		assert.Contains(t, blobs, "sha256:"+sha("a"))
+	})
+
+	t.Run("Testing blobsDelivered - ignore history : should return the delivered blobs only", func(t *testing.T) {
+		ma := MirrorArchive{history: mockHistory{}, deliveredSources: []string{path}, ignoreHistory: true, delivered: delivered}
+		blobs, err := ma.blobsDelivered(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:" + sha("a"): {}}, blobs)
+	})
+
+	t.Run("Testing blobsDelivered - missing source : should fail", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.txt")
+		ma := MirrorArchive{history: mockHistory{}, deliveredSources: []string{missing}, delivered: delivered}
+		_, err := ma.blobsDelivered(context.Background())
+		assert.ErrorContains(t, err, "unable to find the blobs delivered according to "+missing)
+	})
+}
+
+// addImageDigests adds the digests of the manifest, config and layers of img to blobs
+func addImageDigests(t *testing.T, img v1.Image, blobs map[string]struct{}) {
+	d, err := img.Digest()
+	assert.NoError(t, err)
+	blobs[d.String()] = struct{}{}
+	manifest, err := img.Manifest()
+	assert.NoError(t, err)
+	blobs[manifest.Config.Digest.String()] = struct{}{}
+	for _, layer := range manifest.Layers {
+		blobs[layer.Digest.String()] = struct{}{}
+	}
+}
+
+// writeTar writes an archive with an empty file for each name
+func writeTar(t *testing.T, path string, names []string) {
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	tw := tar.NewWriter(file)
+	for _, n := range names {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: n, Mode: 0600, Typeflag: tar.TypeReg}))
+	}
+	assert.NoError(t, tw.Close())
+}
+
+// sha returns a fake sha256 encoded digest repeating s
+func sha(s string) string {
+	out := ""
+	for len(out) < 64 {
+		out += s
+	}
+	return out[:64]
+}
@@ -0,0 +1,92 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+// WriteReceipt records, in the receipt of workingDir, the images pushed to destination with the digests of their
+// manifests and blobs, gathered from the local cache, and returns the path of the receipt.
+// The receipt keeps the content of the previous runs pushed to the same destination.
+// The images whose blobs can not be gathered are left out: the next archive includes them again.
+func WriteReceipt(ctx context.Context, log clog.PluggableLoggerInterface, gatherer BlobsGatherer, workingDir, destination string, pushed []v2alpha1.CopyImageSchema, now time.Time) (string, error) {
+	receiptPath := filepath.Join(workingDir, receiptFile)
+	receipt, err := ReadReceipt(receiptPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if receipt.Destination != destination {
+		if receipt.Destination != "" {
+			log.Warn("the receipt %s was written for %s: starting a new receipt for %s", receiptPath, receipt.Destination, destination)
+		}
+		receipt = v2alpha1.MirrorReceipt{Destination: destination}
+	}
+
+	images := toSet(receipt.Images)
+	digests := toSet(receipt.Digests)
+	for _, img := range pushed {
+		blobs, err := gatherer.GatherBlobs(ctx, img.Source)
+		if err != nil && !errors.As(err, &SignatureBlobGathererError{}) {
+			log.Warn("%s left out of the receipt: unable to find its blobs: %v", img.Destination, err)
+			continue
+		}
+		images[img.Destination] = struct{}{}
+		for blob := range blobs {
+			digests[blob] = struct{}{}
+		}
+	}
+
+	receipt.Kind = "MirrorReceipt"
+	receipt.APIVersion = "mirror.openshift.io/v2alpha1"
+	receipt.Updated = now
+	receipt.Images = sortedKeys(images)
+	receipt.Digests = sortedKeys(digests)
+	data, err := yaml.Marshal(receipt)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling the receipt: %w", err)
+	}
+	if err := os.WriteFile(receiptPath, data, 0644); err != nil { // nolint:gosec // G306: no sensitive data
+		return "", fmt.Errorf("error writing the receipt: %w", err)
+	}
+	return receiptPath, nil
+}
+
+// ReadReceipt returns the receipt written by a diskToMirror workflow at path
+func ReadReceipt(path string) (v2alpha1.MirrorReceipt, error) {
+	var receipt v2alpha1.MirrorReceipt
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return receipt, fmt.Errorf("error reading the receipt: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &receipt); err != nil {
+		return receipt, fmt.Errorf("error parsing the receipt %s: %w", path, err)
+	}
+	return receipt, nil
+}
+
+func toSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
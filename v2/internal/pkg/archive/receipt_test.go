@@ -0,0 +1,96 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+// mapBlobGatherer returns the blobs of the images of its map, and fails for the others
+type mapBlobGatherer map[string][]string
+
+func (m mapBlobGatherer) GatherBlobs(ctx context.Context, imgRef string) (map[string]struct{}, error) {
+	digests, ok := m[imgRef]
+	if !ok {
+		return nil, errors.New("manifest unknown")
+	}
+	return toSet(digests), nil
+}
+
+func TestWriteReceipt(t *testing.T) {
+	log := clog.New("trace")
+	gatherer := mapBlobGatherer{
+		"docker://localhost:55000/ubi8/ubi:latest": {"sha256:m1", "sha256:l1", "sha256:l2"},
+		"docker://localhost:55000/ubi9/ubi:latest": {"sha256:m2", "sha256:l2", "sha256:l3"},
+	}
+	pushed := func(name string) v2alpha1.CopyImageSchema {
+		return v2alpha1.CopyImageSchema{Source: "docker://localhost:55000/" + name + ":latest", Destination: "docker://mirror.example.com/" + name + ":latest"}
+	}
+	updated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Testing WriteReceipt : should record the images pushed with their digests, along with the previous ones", func(t *testing.T) {
+		workingDir := t.TempDir()
+		_, err := WriteReceipt(context.Background(), log, gatherer, workingDir, "docker://mirror.example.com", []v2alpha1.CopyImageSchema{pushed("ubi8/ubi"), pushed("missing")}, updated)
+		assert.NoError(t, err)
+		path, err := WriteReceipt(context.Background(), log, gatherer, workingDir, "docker://mirror.example.com", []v2alpha1.CopyImageSchema{pushed("ubi9/ubi")}, updated)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(workingDir, receiptFile), path)
+
+		receipt, err := ReadReceipt(path)
+		assert.NoError(t, err)
+		assert.Equal(t, v2alpha1.MirrorReceipt{
+			Kind:        "MirrorReceipt",
+			APIVersion:  "mirror.openshift.io/v2alpha1",
+			Destination: "docker://mirror.example.com",
+			Updated:     updated,
+			Images:      []string{"docker://mirror.example.com/ubi8/ubi:latest", "docker://mirror.example.com/ubi9/ubi:latest"},
+			Digests:     []string{"sha256:l1", "sha256:l2", "sha256:l3", "sha256:m1", "sha256:m2"},
+		}, receipt)
+	})
+
+	t.Run("Testing WriteReceipt - another destination : should start a new receipt", func(t *testing.T) {
+		workingDir := t.TempDir()
+		_, err := WriteReceipt(context.Background(), log, gatherer, workingDir, "docker://mirror.example.com", []v2alpha1.CopyImageSchema{pushed("ubi8/ubi")}, updated)
+		assert.NoError(t, err)
+		path, err := WriteReceipt(context.Background(), log, gatherer, workingDir, "docker://other.example.com", []v2alpha1.CopyImageSchema{pushed("ubi9/ubi")}, updated)
+		assert.NoError(t, err)
+
+		receipt, err := ReadReceipt(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "docker://other.example.com", receipt.Destination)
+		assert.Equal(t, []string{"sha256:l2", "sha256:l3", "sha256:m2"}, receipt.Digests)
+	})
+}
+
+func TestBlobsDelivered(t *testing.T) {
+	t.Run("Testing blobsDelivered - receipt : should return the digests of the receipt instead of the history", func(t *testing.T) {
+		workingDir := t.TempDir()
+		gatherer := mapBlobGatherer{"docker://localhost:55000/ubi8/ubi:latest": {"sha256:m1", "sha256:l1"}}
+		path, err := WriteReceipt(context.Background(), clog.New("trace"), gatherer, workingDir, "docker://mirror.example.com",
+			[]v2alpha1.CopyImageSchema{{Source: "docker://localhost:55000/ubi8/ubi:latest", Destination: "docker://mirror.example.com/ubi8/ubi:latest"}}, time.Now())
+		assert.NoError(t, err)
+
+		ma := MirrorArchive{history: mockHistory{}, receipt: path}
+		blobs, err := ma.blobsDelivered(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:m1": {}, "sha256:l1": {}}, blobs)
+
+		ma.receipt = ""
+		blobs, err = ma.blobsDelivered(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 5)
+	})
+
+	t.Run("Testing blobsDelivered - missing receipt : should fail", func(t *testing.T) {
+		ma := MirrorArchive{history: mockHistory{}, receipt: filepath.Join(t.TempDir(), receiptFile)}
+		_, err := ma.blobsDelivered(context.Background())
+		assert.ErrorContains(t, err, "unable to read the receipt")
+	})
+}
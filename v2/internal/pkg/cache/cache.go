@@ -0,0 +1,328 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/manifestlist"
+	"github.com/distribution/distribution/v3/manifest/schema2"
+	"github.com/distribution/distribution/v3/registry/storage"
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/distribution/distribution/v3/registry/storage/driver/filesystem"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+// manifestMediaTypes are the media types of the references which are manifests themselves
+var manifestMediaTypes = map[string]bool{
+	ocispec.MediaTypeImageManifest:     true,
+	ocispec.MediaTypeImageIndex:        true,
+	schema2.MediaTypeManifest:          true,
+	manifestlist.MediaTypeManifestList: true,
+}
+
+// Tag is a tag of a repository of the cache
+type Tag struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+	// Size is the size of the manifests and blobs of the tag
+	Size int64 `json:"size"`
+}
+
+// Repository is a repository of the cache, with its tags
+type Repository struct {
+	Name string `json:"name"`
+	Tags []Tag  `json:"tags"`
+	// Size is the size of the manifests and blobs of the tags, the ones shared by the tags counted once
+	Size int64 `json:"size"`
+	// UniqueSize is the size of the manifests and blobs referenced by this repository only:
+	// the size freed by removing it, once garbage collected
+	UniqueSize int64 `json:"uniqueSize"`
+}
+
+// Usage is the disk usage of the cache
+type Usage struct {
+	Repositories []Repository `json:"repositories"`
+	// TotalSize is the sum of the sizes of the repositories, as if they did not share any blob
+	TotalSize int64 `json:"totalSize"`
+	// UniqueSize is the size of the manifests and blobs referenced by the repositories, each counted once
+	UniqueSize int64 `json:"uniqueSize"`
+	// StorageSize is the size of all the blobs stored, referenced or not
+	StorageSize int64 `json:"storageSize"`
+	Blobs       int   `json:"blobs"`
+	// ReclaimableSize is the size of the blobs no repository references, removed by the garbage collection
+	// along with the untagged manifests
+	ReclaimableSize  int64 `json:"reclaimableSize"`
+	ReclaimableBlobs int   `json:"reclaimableBlobs"`
+}
+
+// GarbageCollectReport is the outcome of a garbage collection of the cache
+type GarbageCollectReport struct {
+	// Blobs and Size are the number and the size of the blobs removed. For a dry run, they are an
+	// estimate: the blobs no tag references, without the untagged manifests and their blobs.
+	Blobs int
+	Size  int64
+}
+
+// Cache inspects and cleans up the storage of the local cache registry.
+// oc-mirror must not be running against the same cache meanwhile.
+type Cache struct {
+	Log           clog.PluggableLoggerInterface
+	RootDirectory string
+	driver        storagedriver.StorageDriver
+	registry      distribution.Namespace
+}
+
+// New returns the cache stored under rootDirectory
+func New(ctx context.Context, log clog.PluggableLoggerInterface, rootDirectory string) (*Cache, error) {
+	driver := filesystem.New(filesystem.DriverParameters{RootDirectory: rootDirectory, MaxThreads: 100})
+	reg, err := storage.NewRegistry(ctx, driver)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the cache %s: %w", rootDirectory, err)
+	}
+	return &Cache{Log: log, RootDirectory: rootDirectory, driver: driver, registry: reg}, nil
+}
+
+// RepositoryNames returns the names of the repositories of the cache, sorted
+func (o Cache) RepositoryNames(ctx context.Context) ([]string, error) {
+	enumerator, ok := o.registry.(distribution.RepositoryEnumerator)
+	if !ok {
+		return nil, fmt.Errorf("the cache storage can not enumerate its repositories")
+	}
+	var names []string
+	err := enumerator.Enumerate(ctx, func(name string) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil && !isPathNotFound(err) {
+		return nil, fmt.Errorf("unable to list the repositories of the cache: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Repositories returns the repositories of the cache, with the sizes of their tags
+func (o Cache) Repositories(ctx context.Context) ([]Repository, error) {
+	repos, _, err := o.repositories(ctx)
+	return repos, err
+}
+
+// Usage returns the disk usage of the cache, by repository and overall
+func (o Cache) Usage(ctx context.Context) (Usage, error) {
+	repos, referenced, err := o.repositories(ctx)
+	if err != nil {
+		return Usage{}, err
+	}
+	usage := Usage{Repositories: repos}
+	for _, repo := range repos {
+		usage.TotalSize += repo.Size
+	}
+	for _, size := range referenced {
+		usage.UniqueSize += size
+	}
+	err = o.registry.Blobs().Enumerate(ctx, func(dgst digest.Digest) error {
+		size := o.blobSize(ctx, dgst)
+		usage.Blobs++
+		usage.StorageSize += size
+		if _, ok := referenced[dgst]; !ok {
+			usage.ReclaimableBlobs++
+			usage.ReclaimableSize += size
+		}
+		return nil
+	})
+	if err != nil && !isPathNotFound(err) {
+		return Usage{}, fmt.Errorf("unable to list the blobs of the cache: %w", err)
+	}
+	return usage, nil
+}
+
+// Remove removes the repositories of the cache whose name matches one of the patterns (see path.Match),
+// and returns their names. When dryRun is set, the repositories are only returned.
+// The blobs of the removed repositories are only freed by the next garbage collection.
+func (o Cache) Remove(ctx context.Context, patterns []string, dryRun bool) ([]string, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		}
+	}
+	names, err := o.RepositoryNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remover, ok := o.registry.(distribution.RepositoryRemover)
+	if !ok {
+		return nil, fmt.Errorf("the cache storage can not remove its repositories")
+	}
+	var removed []string
+	for _, name := range names {
+		if !matchesAny(name, patterns) {
+			continue
+		}
+		if !dryRun {
+			named, err := reference.WithName(name)
+			if err != nil {
+				return removed, err
+			}
+			if err := remover.Remove(ctx, named); err != nil {
+				return removed, fmt.Errorf("unable to remove the repository %s from the cache: %w", name, err)
+			}
+			o.Log.Debug("repository %s removed from the cache", name)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// GarbageCollect removes the blobs and the untagged manifests no tag references, and reports
+// the blobs removed, measured by comparing the blobs stored before and after.
+// When dryRun is set, nothing is removed and the report is an estimate.
+func (o Cache) GarbageCollect(ctx context.Context, dryRun bool) (GarbageCollectReport, error) {
+	before, err := o.Usage(ctx)
+	if err != nil {
+		return GarbageCollectReport{}, err
+	}
+	if dryRun || before.Blobs == 0 {
+		return GarbageCollectReport{Blobs: before.ReclaimableBlobs, Size: before.ReclaimableSize}, nil
+	}
+	opts := storage.GCOpts{
+		DryRun:         false,
+		RemoveUntagged: true,
+	}
+	if err := storage.MarkAndSweep(ctx, o.driver, o.registry, opts); err != nil {
+		return GarbageCollectReport{}, fmt.Errorf("unable to garbage collect the cache: %w", err)
+	}
+	after, err := o.Usage(ctx)
+	if err != nil {
+		return GarbageCollectReport{}, err
+	}
+	return GarbageCollectReport{Blobs: before.Blobs - after.Blobs, Size: before.StorageSize - after.StorageSize}, nil
+}
+
+// repositories returns the repositories of the cache, and the size of each manifest and blob they reference
+func (o Cache) repositories(ctx context.Context) ([]Repository, map[digest.Digest]int64, error) {
+	names, err := o.RepositoryNames(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	repos := make([]Repository, 0, len(names))
+	blobsByRepo := make([]map[digest.Digest]int64, 0, len(names))
+	referencedBy := map[digest.Digest]int{}
+	referenced := map[digest.Digest]int64{}
+	for _, name := range names {
+		repo, blobs, err := o.repository(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for dgst, size := range blobs {
+			referencedBy[dgst]++
+			referenced[dgst] = size
+		}
+		repos = append(repos, repo)
+		blobsByRepo = append(blobsByRepo, blobs)
+	}
+	for i, blobs := range blobsByRepo {
+		for dgst, size := range blobs {
+			if referencedBy[dgst] == 1 {
+				repos[i].UniqueSize += size
+			}
+		}
+	}
+	return repos, referenced, nil
+}
+
+// repository returns the repository name, with its tags, and the size of each manifest and blob it references
+func (o Cache) repository(ctx context.Context, name string) (Repository, map[digest.Digest]int64, error) {
+	named, err := reference.WithName(name)
+	if err != nil {
+		return Repository{}, nil, err
+	}
+	repo, err := o.registry.Repository(ctx, named)
+	if err != nil {
+		return Repository{}, nil, fmt.Errorf("unable to open the repository %s of the cache: %w", name, err)
+	}
+	manifests, err := repo.Manifests(ctx)
+	if err != nil {
+		return Repository{}, nil, fmt.Errorf("unable to open the repository %s of the cache: %w", name, err)
+	}
+	tagService := repo.Tags(ctx)
+	tags, err := tagService.All(ctx)
+	if err != nil {
+		var unknown distribution.ErrRepositoryUnknown
+		if !errors.As(err, &unknown) {
+			return Repository{}, nil, fmt.Errorf("unable to list the tags of the repository %s of the cache: %w", name, err)
+		}
+	}
+
+	result := Repository{Name: name, Tags: []Tag{}}
+	blobs := map[digest.Digest]int64{}
+	for _, tag := range tags {
+		desc, err := tagService.Get(ctx, tag)
+		if err != nil {
+			// the tag was removed meanwhile
+			o.Log.Debug("tag %s:%s of the cache skipped: %v", name, tag, err)
+			continue
+		}
+		// the tags only hold the digest: the manifest is read whatever its media type
+		tagBlobs := map[digest.Digest]int64{}
+		o.addReferences(ctx, manifests, distribution.Descriptor{Digest: desc.Digest, MediaType: ocispec.MediaTypeImageManifest}, tagBlobs)
+		size := int64(0)
+		for dgst, blobSize := range tagBlobs {
+			size += blobSize
+			blobs[dgst] = blobSize
+		}
+		result.Tags = append(result.Tags, Tag{Name: tag, Digest: desc.Digest.String(), Size: size})
+	}
+	for _, size := range blobs {
+		result.Size += size
+	}
+	return result, blobs, nil
+}
+
+// addReferences adds desc to blobs with its size and, when it is a manifest, the manifests and blobs it references
+func (o Cache) addReferences(ctx context.Context, manifests distribution.ManifestService, desc distribution.Descriptor, blobs map[digest.Digest]int64) {
+	if _, ok := blobs[desc.Digest]; ok {
+		return
+	}
+	blobs[desc.Digest] = o.blobSize(ctx, desc.Digest)
+	if !manifestMediaTypes[desc.MediaType] {
+		return
+	}
+	m, err := manifests.Get(ctx, desc.Digest)
+	if err != nil {
+		// the manifests of the platforms which were not mirrored are missing from the indexes
+		return
+	}
+	for _, ref := range m.References() {
+		o.addReferences(ctx, manifests, ref, blobs)
+	}
+}
+
+// blobSize returns the size of the blob dgst, or 0 when it is missing
+func (o Cache) blobSize(ctx context.Context, dgst digest.Digest) int64 {
+	desc, err := o.registry.BlobStatter().Stat(ctx, dgst)
+	if err != nil {
+		return 0
+	}
+	return desc.Size
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathNotFound(err error) bool {
+	var notFound storagedriver.PathNotFoundError
+	return errors.As(err, &notFound)
+}
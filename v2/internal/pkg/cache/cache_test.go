@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/distribution/distribution/v3"
+	"github.com/distribution/distribution/v3/manifest/ocischema"
+	"github.com/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+// pushImage pushes an image made of config and layers to the repository name of the cache, and returns its size
+func pushImage(t *testing.T, c *Cache, name, tag string, config []byte, layers ...[]byte) int64 {
+	ctx := context.Background()
+	named, err := reference.WithName(name)
+	assert.NoError(t, err)
+	repo, err := c.registry.Repository(ctx, named)
+	assert.NoError(t, err)
+	blobs := repo.Blobs(ctx)
+
+	configDesc, err := blobs.Put(ctx, ocispec.MediaTypeImageConfig, config)
+	assert.NoError(t, err)
+	size := configDesc.Size
+	builder := ocischema.NewManifestBuilder(blobs, config, nil)
+	for _, layer := range layers {
+		desc, err := blobs.Put(ctx, ocispec.MediaTypeImageLayerGzip, layer)
+		assert.NoError(t, err)
+		desc.MediaType = ocispec.MediaTypeImageLayerGzip
+		assert.NoError(t, builder.AppendReference(desc))
+		size += desc.Size
+	}
+	m, err := builder.Build(ctx)
+	assert.NoError(t, err)
+	manifests, err := repo.Manifests(ctx)
+	assert.NoError(t, err)
+	dgst, err := manifests.Put(ctx, m)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Tags(ctx).Tag(ctx, tag, distribution.Descriptor{Digest: dgst}))
+	_, payload, err := m.Payload()
+	assert.NoError(t, err)
+	return size + int64(len(payload))
+}
+
+// newTestCache returns a cache with the repositories ubi8/ubi and ubi9/ubi, sharing a layer,
+// and with a blob no manifest references
+func newTestCache(t *testing.T) (*Cache, int64, int64) {
+	c, err := New(context.Background(), clog.New("trace"), t.TempDir())
+	assert.NoError(t, err)
+	shared := []byte("shared layer")
+	ubi8Size := pushImage(t, c, "ubi8/ubi", "latest", []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers"},"config":{"Labels":{"v":"8"}}}`), shared, []byte("ubi8 layer"))
+	ubi9Size := pushImage(t, c, "ubi9/ubi", "latest", []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers"},"config":{"Labels":{"v":"9"}}}`), shared, []byte("ubi9 layer"))
+
+	named, err := reference.WithName("ubi8/ubi")
+	assert.NoError(t, err)
+	repo, err := c.registry.Repository(context.Background(), named)
+	assert.NoError(t, err)
+	_, err = repo.Blobs(context.Background()).Put(context.Background(), ocispec.MediaTypeImageLayerGzip, []byte("dangling"))
+	assert.NoError(t, err)
+	return c, ubi8Size, ubi9Size
+}
+
+func TestRepositories(t *testing.T) {
+	t.Run("Testing Repositories : should return the tags of the repositories with their sizes", func(t *testing.T) {
+		c, ubi8Size, ubi9Size := newTestCache(t)
+		repos, err := c.Repositories(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, repos, 2)
+		assert.Equal(t, "ubi8/ubi", repos[0].Name)
+		assert.Equal(t, "ubi9/ubi", repos[1].Name)
+		assert.Len(t, repos[0].Tags, 1)
+		assert.Equal(t, "latest", repos[0].Tags[0].Name)
+		assert.Equal(t, ubi8Size, repos[0].Tags[0].Size)
+		assert.Equal(t, ubi8Size, repos[0].Size)
+		assert.Equal(t, ubi9Size, repos[1].Size)
+		sharedSize := int64(len("shared layer"))
+		assert.Equal(t, ubi8Size-sharedSize, repos[0].UniqueSize)
+		assert.Equal(t, ubi9Size-sharedSize, repos[1].UniqueSize)
+	})
+
+	t.Run("Testing Repositories - empty cache : should return no repository", func(t *testing.T) {
+		c, err := New(context.Background(), clog.New("trace"), t.TempDir())
+		assert.NoError(t, err)
+		repos, err := c.Repositories(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, repos)
+	})
+}
+
+func TestUsage(t *testing.T) {
+	t.Run("Testing Usage : should return the total, unique and reclaimable sizes", func(t *testing.T) {
+		c, ubi8Size, ubi9Size := newTestCache(t)
+		usage, err := c.Usage(context.Background())
+		assert.NoError(t, err)
+		sharedSize := int64(len("shared layer"))
+		assert.Equal(t, ubi8Size+ubi9Size, usage.TotalSize)
+		assert.Equal(t, ubi8Size+ubi9Size-sharedSize, usage.UniqueSize)
+		assert.Equal(t, usage.UniqueSize+int64(len("dangling")), usage.StorageSize)
+		// 2 manifests, 2 configs, 3 layers and the dangling blob
+		assert.Equal(t, 8, usage.Blobs)
+		assert.Equal(t, 1, usage.ReclaimableBlobs)
+		assert.Equal(t, int64(len("dangling")), usage.ReclaimableSize)
+	})
+
+	t.Run("Testing Usage - empty cache : should return no usage", func(t *testing.T) {
+		c, err := New(context.Background(), clog.New("trace"), t.TempDir())
+		assert.NoError(t, err)
+		usage, err := c.Usage(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, usage.Blobs)
+		assert.Equal(t, int64(0), usage.StorageSize)
+	})
+}
+
+func TestRemove(t *testing.T) {
+	t.Run("Testing Remove - dry run : should only return the matching repositories", func(t *testing.T) {
+		c, _, _ := newTestCache(t)
+		removed, err := c.Remove(context.Background(), []string{"ubi8/*"}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ubi8/ubi"}, removed)
+		names, err := c.RepositoryNames(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ubi8/ubi", "ubi9/ubi"}, names)
+	})
+
+	t.Run("Testing Remove : should remove the matching repositories", func(t *testing.T) {
+		c, _, ubi9Size := newTestCache(t)
+		removed, err := c.Remove(context.Background(), []string{"ubi8/*", "nomatch"}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ubi8/ubi"}, removed)
+		usage, err := c.Usage(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, usage.Repositories, 1)
+		assert.Equal(t, ubi9Size, usage.UniqueSize)
+		// the blobs of ubi8/ubi are only freed by the garbage collection
+		assert.Equal(t, 4, usage.ReclaimableBlobs)
+	})
+
+	t.Run("Testing Remove - invalid pattern : should fail", func(t *testing.T) {
+		c, _, _ := newTestCache(t)
+		_, err := c.Remove(context.Background(), []string{"ubi8/["}, false)
+		assert.ErrorContains(t, err, `invalid repository pattern "ubi8/["`)
+	})
+}
+
+func TestGarbageCollect(t *testing.T) {
+	t.Run("Testing GarbageCollect - dry run : should report the reclaimable blobs and keep them", func(t *testing.T) {
+		c, _, _ := newTestCache(t)
+		report, err := c.GarbageCollect(context.Background(), true)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Blobs)
+		usage, err := c.Usage(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 8, usage.Blobs)
+	})
+
+	t.Run("Testing GarbageCollect : should remove the blobs of the removed repositories", func(t *testing.T) {
+		c, _, ubi9Size := newTestCache(t)
+		_, err := c.Remove(context.Background(), []string{"ubi8/ubi"}, false)
+		assert.NoError(t, err)
+		before, err := c.Usage(context.Background())
+		assert.NoError(t, err)
+		report, err := c.GarbageCollect(context.Background(), false)
+		assert.NoError(t, err)
+		usage, err := c.Usage(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, usage.ReclaimableBlobs)
+		assert.Equal(t, ubi9Size, usage.StorageSize)
+		assert.Equal(t, before.Blobs-usage.Blobs, report.Blobs)
+		assert.Equal(t, before.StorageSize-ubi9Size, report.Size)
+	})
+}
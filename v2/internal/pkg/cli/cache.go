@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/cache"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+type CacheSchema struct {
+	Log    clog.PluggableLoggerInterface
+	Opts   *mirror.CopyOptions
+	Output string
+	DryRun bool
+	cache  *cache.Cache
+}
+
+// NewCacheCommand - setup the 'cache' sub command and its 'ls', 'du', 'gc' and 'rm' sub commands
+func NewCacheCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and clean up the local cache of oc-mirror (under --cache-dir)",
+	}
+	cmd.AddCommand(newCacheListCommand(log, opts))
+	cmd.AddCommand(newCacheUsageCommand(log, opts))
+	cmd.AddCommand(newCacheGarbageCollectCommand(log, opts))
+	cmd.AddCommand(newCacheRemoveCommand(log, opts))
+	return cmd
+}
+
+// newCacheListCommand - setup the 'cache ls' sub command
+func newCacheListCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &CacheSchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the repositories and tags of the cache, with their sizes",
+		Example: templates.Examples(`
+			# List the repositories and tags of the cache
+			oc-mirror cache ls --v2
+
+			# List the repositories and tags of the cache of another directory, in JSON
+			oc-mirror cache ls --cache-dir /mnt/cache --output json --v2
+		`),
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(cmd.Context(), false); err != nil {
+				return err
+			}
+			repos, err := ex.cache.Repositories(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if ex.Output == outputJSON {
+				return writeJSON(cmd.OutOrStdout(), repos)
+			}
+			return writeRepositoriesTable(cmd.OutOrStdout(), repos)
+		},
+	}
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or a table when not set")
+	return cmd
+}
+
+// newCacheUsageCommand - setup the 'cache du' sub command
+func newCacheUsageCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &CacheSchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "du",
+		Short: "Show the disk usage of the cache: by repository, overall, and reclaimable by a garbage collection",
+		Example: templates.Examples(`
+			# Show the disk usage of the cache
+			oc-mirror cache du --v2
+		`),
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(cmd.Context(), false); err != nil {
+				return err
+			}
+			usage, err := ex.cache.Usage(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if ex.Output == outputJSON {
+				return writeJSON(cmd.OutOrStdout(), usage)
+			}
+			return writeUsageTable(cmd.OutOrStdout(), usage)
+		},
+	}
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or a table when not set")
+	return cmd
+}
+
+// newCacheGarbageCollectCommand - setup the 'cache gc' sub command
+func newCacheGarbageCollectCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &CacheSchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove the blobs and untagged manifests of the cache no tag references",
+		Example: templates.Examples(`
+			# Show what the garbage collection of the cache would remove
+			oc-mirror cache gc --dry-run --v2
+
+			# Garbage collect the cache
+			oc-mirror cache gc --v2
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(cmd.Context(), !ex.DryRun); err != nil {
+				return err
+			}
+			ex.Log.Info(emoji.Gear+" garbage collecting the cache %s", ex.cache.RootDirectory)
+			report, err := ex.cache.GarbageCollect(cmd.Context(), ex.DryRun)
+			if err != nil {
+				return err
+			}
+			if ex.DryRun {
+				ex.Log.Info("dry run: about %d blobs (%s) would be removed, not counting the untagged manifests", report.Blobs, units.HumanSize(float64(report.Size)))
+				return nil
+			}
+			ex.Log.Info("%d blobs (%s) removed", report.Blobs, units.HumanSize(float64(report.Size)))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&ex.DryRun, "dry-run", false, "Only report what would be removed")
+	return cmd
+}
+
+// newCacheRemoveCommand - setup the 'cache rm' sub command
+func newCacheRemoveCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &CacheSchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "rm <pattern>...",
+		Short: "Remove the repositories of the cache matching the patterns (e.g. openshift/*)",
+		Long: templates.LongDesc(`
+			Remove the repositories of the cache whose name matches one of the patterns, with the syntax of
+			shell file name patterns: * does not match the / of the repository names.
+			The blobs of the removed repositories are freed by the next 'oc-mirror cache gc'.
+		`),
+		Example: templates.Examples(`
+			# Show the repositories of the cache a pattern matches
+			oc-mirror cache rm 'redhat/*-operator-index' --dry-run --v2
+
+			# Remove the release images from the cache, and free their blobs
+			oc-mirror cache rm 'openshift/release*' --v2
+			oc-mirror cache gc --v2
+		`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(cmd.Context(), !ex.DryRun); err != nil {
+				return err
+			}
+			removed, err := ex.cache.Remove(cmd.Context(), args, ex.DryRun)
+			for _, name := range removed {
+				if ex.DryRun {
+					ex.Log.Info("dry run: %s would be removed", name)
+				} else {
+					ex.Log.Info("%s removed", name)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				ex.Log.Warn(emoji.Warning+" no repository of the cache matches %v", args)
+				return nil
+			}
+			if !ex.DryRun {
+				ex.Log.Info("%d repositories removed: run oc-mirror cache gc to free their blobs", len(removed))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&ex.DryRun, "dry-run", false, "Only show the repositories which would be removed")
+	return cmd
+}
+
+// Validate - cobra validation. The cache must not be in use by oc-mirror when it is modified.
+func (o CacheSchema) Validate(modifies bool) error {
+	if o.Output != "" && o.Output != outputJSON {
+		return fmt.Errorf("invalid --output %q: only json is supported", o.Output)
+	}
+	if modifies {
+		listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", o.Opts.Global.Port))
+		if err != nil {
+			return fmt.Errorf("port %d is in use, oc-mirror may be running against the cache: stop it before modifying the cache", o.Opts.Global.Port)
+		}
+		listener.Close()
+	}
+	return nil
+}
+
+// complete validates the flags, and opens the cache of the cache directory
+func (o *CacheSchema) complete(ctx context.Context, modifies bool) error {
+	if err := o.Validate(modifies); err != nil {
+		return err
+	}
+	o.Log.Level(o.Opts.Global.LogLevel)
+	c, err := cache.New(ctx, o.Log, filepath.Join(o.Opts.Global.CacheDir, cacheRelativePath))
+	if err != nil {
+		return err
+	}
+	o.cache = c
+	return nil
+}
+
+func writeJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeRepositoriesTable writes the tags of the repositories
+func writeRepositoriesTable(out io.Writer, repos []cache.Repository) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAG\tDIGEST\tSIZE")
+	for _, repo := range repos {
+		for _, tag := range repo.Tags {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", repo.Name, tag.Name, tag.Digest, units.HumanSize(float64(tag.Size)))
+		}
+	}
+	return w.Flush()
+}
+
+// writeUsageTable writes the usage of the repositories, then of the cache
+func writeUsageTable(out io.Writer, usage cache.Usage) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAGS\tSIZE\tUNIQUE")
+	for _, repo := range usage.Repositories {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", repo.Name, len(repo.Tags), units.HumanSize(float64(repo.Size)), units.HumanSize(float64(repo.UniqueSize)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "total: %s, unique: %s\n", units.HumanSize(float64(usage.TotalSize)), units.HumanSize(float64(usage.UniqueSize)))
+	fmt.Fprintf(out, "stored: %s in %d blobs\n", units.HumanSize(float64(usage.StorageSize)), usage.Blobs)
+	fmt.Fprintf(out, "reclaimable by oc-mirror cache gc: %s in %d blobs\n", units.HumanSize(float64(usage.ReclaimableSize)), usage.ReclaimableBlobs)
+	return nil
+}
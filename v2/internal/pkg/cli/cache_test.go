@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/cache"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+func TestCacheValidate(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	busyPort := uint16(listener.Addr().(*net.TCPAddr).Port)
+	opts := &mirror.CopyOptions{Global: &mirror.GlobalOptions{Port: busyPort}}
+
+	t.Run("Testing CacheSchema.Validate - json output : should pass", func(t *testing.T) {
+		assert.NoError(t, CacheSchema{Log: clog.New("error"), Opts: opts, Output: "json"}.Validate(false))
+	})
+
+	t.Run("Testing CacheSchema.Validate - yaml output : should fail", func(t *testing.T) {
+		err := CacheSchema{Log: clog.New("error"), Opts: opts, Output: "yaml"}.Validate(false)
+		assert.EqualError(t, err, `invalid --output "yaml": only json is supported`)
+	})
+
+	t.Run("Testing CacheSchema.Validate - cache modified while the port is in use : should fail", func(t *testing.T) {
+		err := CacheSchema{Log: clog.New("error"), Opts: opts}.Validate(true)
+		assert.ErrorContains(t, err, "oc-mirror may be running against the cache")
+	})
+}
+
+func TestCacheWriteTables(t *testing.T) {
+	repos := []cache.Repository{
+		{Name: "ubi8/ubi", Tags: []cache.Tag{{Name: "latest", Digest: "sha256:abc", Size: 2048}}, Size: 2048, UniqueSize: 1024},
+	}
+
+	t.Run("Testing writeRepositoriesTable : should write the tags of the repositories", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, writeRepositoriesTable(&out, repos))
+		assert.Equal(t, "REPOSITORY  TAG     DIGEST      SIZE\nubi8/ubi    latest  sha256:abc  2.048kB\n", out.String())
+	})
+
+	t.Run("Testing writeUsageTable : should write the usage of the repositories and of the cache", func(t *testing.T) {
+		var out bytes.Buffer
+		usage := cache.Usage{Repositories: repos, TotalSize: 2048, UniqueSize: 2048, StorageSize: 3072, Blobs: 4, ReclaimableSize: 1024, ReclaimableBlobs: 1}
+		assert.NoError(t, writeUsageTable(&out, usage))
+		assert.Equal(t, "REPOSITORY  TAGS  SIZE     UNIQUE\n"+
+			"ubi8/ubi    1     2.048kB  1.024kB\n"+
+			"\n"+
+			"total: 2.048kB, unique: 2.048kB\n"+
+			"stored: 3.072kB in 4 blobs\n"+
+			"reclaimable by oc-mirror cache gc: 1.024kB in 1 blobs\n", out.String())
+	})
+}
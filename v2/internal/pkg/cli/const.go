@@ -17,6 +17,7 @@ const (
 	cincinnatiGraphDataDir        string = "cincinnati-graph-data"
 	operatorImageExtractDir       string = "hold-operator"
 	operatorCatalogsDir           string = "operator-catalogs"
+	catalogConfigDir              string = "catalog-config"
 	signaturesDir                 string = "signatures"
 	registryLogFilename           string = "registry.log"
 	startMessage                  string = "starting local storage on localhost:%v"
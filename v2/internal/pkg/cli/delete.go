@@ -2,11 +2,14 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,6 +18,7 @@ import (
 	"github.com/distribution/distribution/v3/registry/storage/driver/factory"
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/filesystem"
 	"github.com/google/uuid"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 
 	"github.com/spf13/cobra"
 
@@ -26,6 +30,9 @@ import (
 	"github.com/openshift/oc-mirror/v2/internal/pkg/delete"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/helm"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/history"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/imagebuilder"
 	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
@@ -42,6 +49,30 @@ const (
 type DeleteSchema struct {
 	ExecutorSchema
 	V1Tags bool
+	// KeepConfigs are the ImageSetConfigurations whose images are never deleted
+	KeepConfigs []string
+	// PreviousConfig is the ImageSetConfiguration previously mirrored: the images it selected
+	// that the current ImageSetConfiguration no longer selects are deleted
+	PreviousConfig string
+	keep           []keptConfig
+	// age is the policy selecting the recorded mirrored images to delete by age
+	age *v2alpha1.AgePolicy
+	// GCHook is the kind of garbage collection run on the destination registry once the images are deleted
+	GCHook                string
+	GCHookEndpoint        string
+	GCHookCredentialsFile string
+	garbageCollector      delete.GarbageCollector
+	// InUseKubeconfigs and InUseResources are the clusters, and the resources exported from clusters,
+	// whose images in use are not deleted
+	InUseKubeconfigs []string
+	InUseResources   []string
+	inUse            *delete.InUseImages
+}
+
+// keptConfig is an ImageSetConfiguration whose images are not deleted
+type keptConfig struct {
+	path   string
+	config v2alpha1.ImageSetConfiguration
 }
 
 // NewDeleteCommand - setup all the relevant support structs
@@ -96,6 +127,13 @@ func NewDeleteCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOption
 	cmd.Flags().BoolVar(&opts.Global.ForceCacheDelete, "force-cache-delete", false, "Used to force delete  the local cache manifests and blobs")
 	cmd.Flags().BoolVar(&opts.Global.DeleteGenerate, "generate", false, "Used to generate the delete yaml for the list of manifests and blobs , used in the step to actually delete from local cache and remote registry")
 	cmd.Flags().BoolVar(&ex.V1Tags, "delete-v1-images", false, "Used during the migration, along with --generate, in order to target images previously mirrored with oc-mirror v1")
+	cmd.Flags().StringVar(&ex.GCHook, "gc-hook", "", "Garbage collection run on the destination registry once the images are deleted: quay, harbor, distribution or webhook")
+	cmd.Flags().StringVar(&ex.GCHookEndpoint, "gc-hook-endpoint", "", "URL of the registry (quay, harbor) or of the webhook, or root directory of the storage of a distribution registry, used by --gc-hook")
+	cmd.Flags().StringVar(&ex.GCHookCredentialsFile, "gc-hook-credentials-file", "", "File containing the token (quay, webhook) or the user:password (harbor) used by --gc-hook")
+	cmd.Flags().StringVar(&ex.PreviousConfig, "previous-config", "", "Used along with --generate: ImageSetConfiguration previously mirrored. The images it selected that are no longer selected by the ImageSetConfiguration of --config are deleted")
+	cmd.Flags().StringSliceVar(&ex.KeepConfigs, "keep-config", nil, "Used along with --generate: ImageSetConfiguration whose images are still needed, and must not be deleted (can be repeated)")
+	cmd.Flags().StringSliceVar(&ex.InUseKubeconfigs, "in-use-kubeconfig", nil, "Kubeconfig of a cluster using the destination registry: the images in use by the cluster are not deleted (can be repeated)")
+	cmd.Flags().StringSliceVar(&ex.InUseResources, "in-use-resources", nil, "File of ClusterVersion, Pod and ClusterServiceVersion resources exported from a cluster using the destination registry: the images they reference are not deleted (can be repeated)")
 
 	// hide flags
 	HideFlags(cmd)
@@ -120,6 +158,21 @@ func (o DeleteSchema) ValidateDelete(args []string) error {
 	if o.V1Tags && !o.Opts.Global.DeleteGenerate {
 		return fmt.Errorf("the --delete-v1-images flag can only be used alongside the --generate flag")
 	}
+	if len(o.KeepConfigs) > 0 && !o.Opts.Global.DeleteGenerate {
+		return fmt.Errorf("the --keep-config flag can only be used alongside the --generate flag")
+	}
+	if len(o.PreviousConfig) > 0 && !o.Opts.Global.DeleteGenerate {
+		return fmt.Errorf("the --previous-config flag can only be used alongside the --generate flag")
+	}
+	if len(o.GCHook) > 0 && o.Opts.Global.DeleteGenerate {
+		return fmt.Errorf("the --gc-hook flag can not be used alongside the --generate flag")
+	}
+	if len(o.GCHook) == 0 && (len(o.GCHookEndpoint) > 0 || len(o.GCHookCredentialsFile) > 0) {
+		return fmt.Errorf("the --gc-hook-endpoint and --gc-hook-credentials-file flags can only be used alongside the --gc-hook flag")
+	}
+	if (len(o.InUseKubeconfigs) > 0 || len(o.InUseResources) > 0) && o.Opts.Global.DeleteGenerate {
+		return fmt.Errorf("the --in-use-kubeconfig and --in-use-resources flags can not be used alongside the --generate flag")
+	}
 	if len(args) < 1 {
 		return fmt.Errorf("the destination registry is missing in the command arguments")
 	}
@@ -146,7 +199,11 @@ func (o *DeleteSchema) CompleteDelete(args []string) error {
 	}
 	o.Opts.Destination = args[0]
 	o.Opts.Global.DeleteDestination = args[0]
-	if o.Opts.Global.DeleteGenerate {
+	if o.Opts.Global.DeleteGenerate && len(o.PreviousConfig) > 0 {
+		if err := o.completePrune(); err != nil {
+			return err
+		}
+	} else if o.Opts.Global.DeleteGenerate {
 		o.Log.Debug("delete imagesetconfig file %s ", o.Opts.Global.ConfigPath)
 		// read and validate the DeleteImageSetConfiguration
 		cfg, err := config.ReadConfig(o.Opts.Global.ConfigPath, v2alpha1.DeleteImageSetConfigurationKind)
@@ -171,6 +228,17 @@ func (o *DeleteSchema) CompleteDelete(args []string) error {
 			},
 		}
 		o.Config = isc
+		o.age = converted.Delete.Age
+	}
+	if o.Opts.Global.DeleteGenerate {
+		for _, keepConfig := range o.KeepConfigs {
+			o.Log.Debug("kept imagesetconfig file %s ", keepConfig)
+			cfg, err := config.ReadConfig(keepConfig, v2alpha1.ImageSetConfigurationKind)
+			if err != nil {
+				return fmt.Errorf("kept imagesetconfig %s: %w", keepConfig, err)
+			}
+			o.keep = append(o.keep, keptConfig{path: keepConfig, config: cfg.(v2alpha1.ImageSetConfiguration)})
+		}
 		// TODO ALEX check if we can remove the line below for delete when working on CLID-348
 		o.Opts.RemoveSignatures = true
 		// nolint: errcheck
@@ -233,27 +301,86 @@ func (o *DeleteSchema) CompleteDelete(args []string) error {
 		return err
 	}
 
-	client, _ := release.NewOCPClient(uuid.New(), o.Log)
-	signature := release.NewSignatureClient(o.Log, o.Config, *o.Opts)
-	cn := release.NewCincinnati(o.Log, o.Manifest, &o.Config, *o.Opts, client, false, signature)
-	o.Release = release.New(o.Log, o.LogsDir, o.Config, *o.Opts, o.Mirror, o.Manifest, cn, o.ImageBuilder)
+	o.setupCollectors(&o.ExecutorSchema)
+	o.CatalogBuilder = imagebuilder.NewGCRCatalogBuilder(o.Log, *o.Opts)
 	o.Batch = batch.New(batch.ChannelConcurrentWorker, o.Log, o.LogsDir, o.Mirror, o.Opts.ParallelImages)
-	o.Operator = operator.NewWithFilter(o.Log, o.LogsDir, o.Config, *o.Opts, o.Mirror, o.Manifest)
-
-	o.AdditionalImages = additional.New(o.Log, o.Config, *o.Opts, o.Mirror, o.Manifest)
-	o.HelmCollector = helm.New(o.Log, o.Config, *o.Opts, nil, nil, &http.Client{Timeout: time.Duration(5) * time.Second})
-	if o.V1Tags {
-		o.Operator = operator.WithV1Tags(o.Operator)
-		o.AdditionalImages = additional.WithV1Tags(o.AdditionalImages)
-		o.HelmCollector = helm.WithV1Tags(o.HelmCollector)
-	}
 	// instantiate delete module
 	bg := archive.NewImageBlobGatherer(o.Opts)
 	o.Delete = delete.New(o.Log, *o.Opts, o.Batch, bg, o.Config, o.Manifest, o.LocalStorageDisk)
 
+	if len(o.GCHook) > 0 {
+		if err := o.setupGarbageCollector(); err != nil {
+			return err
+		}
+	}
+	if len(o.InUseKubeconfigs) > 0 || len(o.InUseResources) > 0 {
+		inUse, err := delete.NewInUseImages(o.Log, o.InUseKubeconfigs, o.InUseResources)
+		if err != nil {
+			return err
+		}
+		o.inUse = inUse
+	}
+
 	return nil
 }
 
+// setupGarbageCollector sets up the garbage collector run on the destination registry after the delete
+func (o *DeleteSchema) setupGarbageCollector() error {
+	credentials := ""
+	if len(o.GCHookCredentialsFile) > 0 {
+		data, err := os.ReadFile(o.GCHookCredentialsFile)
+		if err != nil {
+			return fmt.Errorf("gc hook credentials: %w", err)
+		}
+		credentials = strings.TrimSpace(string(data))
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// nolint: gosec
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: !o.Opts.DestImage.TlsVerify}
+	client := &http.Client{Transport: transport, Timeout: time.Duration(60) * time.Second}
+	gc, err := delete.NewGarbageCollector(o.Log, o.GCHook, o.GCHookEndpoint, credentials, client)
+	if err != nil {
+		return err
+	}
+	o.garbageCollector = gc
+	return nil
+}
+
+// completePrune reads the previous ImageSetConfiguration, whose images are the ones to delete, and the
+// current ImageSetConfiguration, whose images are kept
+func (o *DeleteSchema) completePrune() error {
+	o.Log.Debug("previous imagesetconfig file %s ", o.PreviousConfig)
+	previous, err := config.ReadConfig(o.PreviousConfig, v2alpha1.ImageSetConfigurationKind)
+	if err != nil {
+		return fmt.Errorf("previous imagesetconfig %s: %w", o.PreviousConfig, err)
+	}
+	o.Log.Debug("current imagesetconfig file %s ", o.Opts.Global.ConfigPath)
+	current, err := config.ReadConfig(o.Opts.Global.ConfigPath, v2alpha1.ImageSetConfigurationKind)
+	if err != nil {
+		return fmt.Errorf("current imagesetconfig %s: %w", o.Opts.Global.ConfigPath, err)
+	}
+	o.Config = previous.(v2alpha1.ImageSetConfiguration)
+	o.keep = append(o.keep, keptConfig{path: o.Opts.Global.ConfigPath, config: current.(v2alpha1.ImageSetConfiguration)})
+	return nil
+}
+
+// setupCollectors sets up the collectors of ex for its configuration
+func (o *DeleteSchema) setupCollectors(ex *ExecutorSchema) {
+	client, _ := release.NewOCPClient(uuid.New(), o.Log)
+	signature := release.NewSignatureClient(o.Log, ex.Config, *o.Opts)
+	cn := release.NewCincinnati(o.Log, o.Manifest, &ex.Config, *o.Opts, client, false, signature)
+	ex.Release = release.New(o.Log, o.LogsDir, ex.Config, *o.Opts, o.Mirror, o.Manifest, cn, o.ImageBuilder)
+	ex.Operator = operator.NewWithFilter(o.Log, o.LogsDir, ex.Config, *o.Opts, o.Mirror, o.Manifest)
+
+	ex.AdditionalImages = additional.New(o.Log, ex.Config, *o.Opts, o.Mirror, o.Manifest)
+	ex.HelmCollector = helm.New(o.Log, ex.Config, *o.Opts, nil, nil, &http.Client{Timeout: time.Duration(5) * time.Second})
+	if o.V1Tags {
+		ex.Operator = operator.WithV1Tags(ex.Operator)
+		ex.AdditionalImages = additional.WithV1Tags(ex.AdditionalImages)
+		ex.HelmCollector = helm.WithV1Tags(ex.HelmCollector)
+	}
+}
+
 // RunDelete - cobra run
 func (o *DeleteSchema) RunDelete(cmd *cobra.Command) error {
 	startTime := time.Now()
@@ -267,7 +394,7 @@ func (o *DeleteSchema) RunDelete(cmd *cobra.Command) error {
 	if o.Opts.Global.DeleteGenerate {
 		err = o.generateDeleteFile(cmd.Context())
 	} else {
-		err = o.deleteImages()
+		err = o.deleteImages(cmd.Context())
 	}
 	if err != nil {
 		return err
@@ -291,12 +418,36 @@ func (o *DeleteSchema) RunDelete(cmd *cobra.Command) error {
 func (o *DeleteSchema) generateDeleteFile(ctx context.Context) error {
 	collectorSchema, collectErr := o.CollectAll(ctx)
 
+	// the images of the kept image sets must all be known, otherwise some of them could be deleted
+	kept, err := o.collectKeptImages(ctx)
+	if err != nil {
+		return errors.Join(collectErr, err)
+	}
+
 	// It could be the case that collection finishes with errors (e.g. some
 	// images in the ISC cannot be found anymore). As long as images were
 	// collected, we want to generate a delete file so those images can be deleted
+	images, catalogImages := splitCatalogImages(collectorSchema.AllImages)
+	if len(o.PreviousConfig) > 0 {
+		images = noLongerSelected(images, kept)
+	}
+	if o.age != nil {
+		images, err = o.withImagesByAge(images)
+		if err != nil {
+			return errors.Join(collectErr, err)
+		}
+	}
+	images, err = o.withGraphImages(images)
+	if err != nil {
+		return errors.Join(collectErr, err)
+	}
+	catalogs, err := o.deletedCatalogs(ctx, catalogImages, collectorSchema.CatalogToFBCMap, kept)
+	if err != nil {
+		return errors.Join(collectErr, err)
+	}
 	var writeErr error
-	if len(collectorSchema.AllImages) > 0 {
-		writeErr = o.Delete.WriteDeleteMetaData(collectorSchema.AllImages)
+	if len(images) > 0 || len(catalogs) > 0 {
+		writeErr = o.Delete.WriteDeleteMetaData(images, kept, catalogs)
 		if collectErr != nil && writeErr == nil {
 			o.Log.Warn("image discovery finished with errors: the delete file might not be complete")
 		}
@@ -307,20 +458,281 @@ func (o *DeleteSchema) generateDeleteFile(ctx context.Context) error {
 	return errors.Join(collectErr, writeErr)
 }
 
-func (o *DeleteSchema) deleteImages() error {
+// collectKeptImages returns the images of the kept image sets, as they were mirrored to the destination
+func (o *DeleteSchema) collectKeptImages(ctx context.Context) ([]v2alpha1.CopyImageSchema, error) {
+	var kept []v2alpha1.CopyImageSchema
+	for _, k := range o.keep {
+		o.Log.Info(emoji.SleuthOrSpy+"  collecting the images of the kept imagesetconfig %s...", k.path)
+		ex := o.ExecutorSchema
+		ex.Config = k.config
+		o.setupCollectors(&ex)
+		collectorSchema, err := ex.CollectAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to collect all the images of the kept imagesetconfig %s, no image deleted: %w", k.path, err)
+		}
+		kept = append(kept, collectorSchema.AllImages...)
+	}
+	return kept, nil
+}
+
+// withImagesByAge adds to images the images recorded as mirrored to the destination that the age policy selects
+func (o *DeleteSchema) withImagesByAge(images []v2alpha1.CopyImageSchema) ([]v2alpha1.CopyImageSchema, error) {
+	records, err := history.ReadMirroredImages(o.Opts.Global.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		o.Log.Warn("no mirrored image recorded in %s: the age policy selects no image", o.Opts.Global.WorkingDir)
+		return images, nil
+	}
+	for _, img := range delete.ImagesByAge(records, *o.age, o.Opts.Global.DeleteDestination, time.Now().UTC()) {
+		if !slices.ContainsFunc(images, func(i v2alpha1.CopyImageSchema) bool { return i.Destination == img.Destination }) {
+			o.Log.Debug("%s is selected by the age policy", img.Destination)
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+// withGraphImages adds to images the graph images recorded as mirrored to the destination, once images
+// delete all the releases recorded as mirrored to the destination
+func (o *DeleteSchema) withGraphImages(images []v2alpha1.CopyImageSchema) ([]v2alpha1.CopyImageSchema, error) {
+	records, err := history.ReadMirroredImages(o.Opts.Global.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range delete.GraphImages(records, images, o.Opts.Global.DeleteDestination) {
+		o.Log.Debug("%s is deleted along with the last releases", img.Destination)
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// splitCatalogImages splits images between the catalog images and the other images
+func splitCatalogImages(images []v2alpha1.CopyImageSchema) ([]v2alpha1.CopyImageSchema, []v2alpha1.CopyImageSchema) {
+	var others, catalogs []v2alpha1.CopyImageSchema
+	for _, img := range images {
+		if img.Type == v2alpha1.TypeOperatorCatalog {
+			catalogs = append(catalogs, img)
+		} else {
+			others = append(others, img)
+		}
+	}
+	return others, catalogs
+}
+
+// deletedCatalogs returns the catalogs of the destination the deleted operators are removed from.
+// The content of each catalog of the destination is the filtered catalog of the working-dir rebuilt with the same
+// digest: the catalog is deleted when all its bundles are deleted, otherwise it is rebuilt in the local cache without
+// the deleted bundles, to replace the catalog of the destination.
+func (o *DeleteSchema) deletedCatalogs(ctx context.Context, catalogImages []v2alpha1.CopyImageSchema, fbcs map[string]v2alpha1.CatalogFilterResult, kept []v2alpha1.CopyImageSchema) ([]v2alpha1.DeleteCatalog, error) {
+	var catalogs []v2alpha1.DeleteCatalog
+	for _, ctlg := range catalogImages {
+		if slices.ContainsFunc(kept, func(k v2alpha1.CopyImageSchema) bool { return k.Destination == ctlg.Destination }) {
+			o.Log.Info("catalog %s is still mirrored by a kept image set: not updated", ctlg.Destination)
+			continue
+		}
+		originSpec, err := image.ParseRef(ctlg.Origin)
+		if err != nil {
+			return nil, err
+		}
+		result, ok := fbcs[originSpec.ReferenceWithTransport]
+		if !ok || result.DeclConfig == nil {
+			o.Log.Warn("the operators deleted from catalog %s are unknown: not updated", ctlg.Destination)
+			continue
+		}
+		// no filtered catalog when the whole catalog is deleted
+		if len(result.FilteredConfigPath) == 0 {
+			catalogs = append(catalogs, v2alpha1.DeleteCatalog{ImageName: ctlg.Origin, ImageReference: ctlg.Destination})
+			continue
+		}
+		catalog, err := o.deletedCatalog(ctx, ctlg, result)
+		if err != nil {
+			return nil, err
+		}
+		if catalog != nil {
+			catalogs = append(catalogs, *catalog)
+		}
+	}
+	return catalogs, nil
+}
+
+// deletedCatalog returns the update of the catalog ctlg of the destination without the bundles of result,
+// or nil when the catalog is left as is
+func (o *DeleteSchema) deletedCatalog(ctx context.Context, ctlg v2alpha1.CopyImageSchema, result v2alpha1.CatalogFilterResult) (*v2alpha1.DeleteCatalog, error) {
+	filteredCatalogsDir := filepath.Dir(filepath.Dir(result.FilteredConfigPath))
+	rebuilt, err := delete.RebuiltCatalogs(filteredCatalogsDir)
+	if err != nil {
+		return nil, err
+	}
+	destCtx, err := o.Opts.DestImage.NewSystemContext()
+	if err != nil {
+		return nil, err
+	}
+	destDigest, err := o.Manifest.GetDigest(ctx, destCtx, ctlg.Destination)
+	if err != nil {
+		o.Log.Warn("unable to read catalog %s of the destination, not updated: %v", ctlg.Destination, err)
+		return nil, nil
+	}
+	currentTag := ""
+	for tag, d := range rebuilt {
+		if d == strings.TrimPrefix(destDigest, "sha256:") {
+			currentTag = tag
+		}
+	}
+	if len(currentTag) == 0 {
+		o.Log.Warn("the content of catalog %s was not found in the working-dir: not updated", ctlg.Destination)
+		return nil, nil
+	}
+	current, err := declcfg.LoadFS(ctx, os.DirFS(filepath.Join(filteredCatalogsDir, currentTag, catalogConfigDir)))
+	if err != nil {
+		return nil, fmt.Errorf("catalog %s: %w", ctlg.Destination, err)
+	}
+
+	remaining := delete.RemainingCatalog(*current, *result.DeclConfig)
+	if len(remaining.Bundles) == 0 {
+		cacheTags := slices.Sorted(maps.Keys(rebuilt))
+		return &v2alpha1.DeleteCatalog{ImageName: ctlg.Origin, ImageReference: ctlg.Destination, CacheTags: cacheTags}, nil
+	}
+	if len(remaining.Bundles) == len(current.Bundles) {
+		o.Log.Debug("no operator deleted from catalog %s", ctlg.Destination)
+		return nil, nil
+	}
+
+	replacementTag := delete.RemainingCatalogTag(remaining)
+	if _, ok := rebuilt[replacementTag]; !ok {
+		o.Log.Info(emoji.RepeatSingleButton+" rebuilding catalog %s without the deleted operators", ctlg.Destination)
+		configPath := filepath.Join(filteredCatalogsDir, replacementTag, catalogConfigDir)
+		if err := os.MkdirAll(configPath, 0755); err != nil {
+			return nil, err
+		}
+		if err := declcfg.WriteFS(remaining, configPath, declcfg.WriteJSON, ".json"); err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", ctlg.Destination, err)
+		}
+		cacheSpec, err := image.ParseRef(ctlg.Source)
+		if err != nil {
+			return nil, err
+		}
+		copyImage := v2alpha1.CopyImageSchema{
+			Origin:      ctlg.Origin,
+			Source:      dockerProtocol + cacheSpec.Name + ":" + replacementTag,
+			Destination: ctlg.Destination,
+			Type:        v2alpha1.TypeOperatorCatalog,
+		}
+		if err := o.CatalogBuilder.RebuildCatalog(ctx, copyImage, configPath); err != nil {
+			return nil, fmt.Errorf("unable to rebuild catalog %s without the deleted operators: %w", ctlg.Destination, err)
+		}
+	}
+	return &v2alpha1.DeleteCatalog{ImageName: ctlg.Origin, ImageReference: ctlg.Destination, ReplacementTag: replacementTag}, nil
+}
+
+// noLongerSelected returns the previously mirrored images that are not mirrored to the same destination
+// by the current image sets. The other ones are still mirrored, and are not even reported as protected.
+func noLongerSelected(previous, current []v2alpha1.CopyImageSchema) []v2alpha1.CopyImageSchema {
+	currentDestinations := map[string]bool{}
+	for _, img := range current {
+		currentDestinations[img.Destination] = true
+	}
+	var pruned []v2alpha1.CopyImageSchema
+	for _, img := range previous {
+		if !currentDestinations[img.Destination] {
+			pruned = append(pruned, img)
+		}
+	}
+	return pruned
+}
+
+func (o *DeleteSchema) deleteImages(ctx context.Context) error {
 	deleteList, err := o.Delete.ReadDeleteMetaData()
 	if err != nil {
 		return err
 	}
+	if o.inUse != nil {
+		if deleteList, err = o.withoutImagesInUse(ctx, deleteList); err != nil {
+			return err
+		}
+	}
 
 	if err := o.Delete.DeleteRegistryImages(deleteList); err != nil {
 		return err
 	}
+	// the age policy and the graph image must not select the deleted images again
+	deleted := make([]string, 0, len(deleteList.Items))
+	for _, item := range deleteList.Items {
+		deleted = append(deleted, item.ImageReference)
+	}
+	if err := history.ForgetMirroredImages(o.Opts.Global.WorkingDir, deleted); err != nil {
+		o.Log.Warn("the images were deleted, but their records in %s were not removed: %v", o.Opts.Global.WorkingDir, err)
+	}
 
-	o.Log.Info(emoji.Memo + " Remember to execute a garbage collect (or similar) on your remote repository")
+	if o.garbageCollector == nil {
+		o.Log.Info(emoji.Memo + " Remember to execute a garbage collect (or similar) on your remote repository")
+		return nil
+	}
+	o.Log.Info(emoji.Gear+" running the %s garbage collection on the remote repository...", o.GCHook)
+	if err := o.garbageCollector.GarbageCollect(ctx, deleteList.Items); err != nil {
+		return fmt.Errorf("the images were deleted, but the garbage collection failed: %w", err)
+	}
 	return nil
 }
 
+// withoutImagesInUse removes from deleteList the images in use by the clusters.
+// The digest of the images only referenced by tag is read from the destination registry.
+func (o *DeleteSchema) withoutImagesInUse(ctx context.Context, deleteList v2alpha1.DeleteImageList) (v2alpha1.DeleteImageList, error) {
+	o.Log.Info(emoji.LeftPointingMagnifyingGlass + " Looking for the images in use by the clusters...")
+	inUse, err := o.inUse.Digests(ctx)
+	if err != nil {
+		return deleteList, fmt.Errorf("unable to find the images in use, no image deleted: %w", err)
+	}
+	destCtx, err := o.Opts.DestImage.NewSystemContext()
+	if err != nil {
+		return deleteList, err
+	}
+	isInUse := func(item v2alpha1.DeleteItem) (bool, error) {
+		d := delete.ItemDigest(item)
+		if len(d) == 0 {
+			imgDigest, err := o.Manifest.GetDigest(ctx, destCtx, item.ImageReference)
+			if err != nil {
+				return false, fmt.Errorf("unable to read the digest of %s to check whether it is in use, no image deleted: %w", item.ImageReference, err)
+			}
+			d = "sha256:" + strings.TrimPrefix(imgDigest, "sha256:")
+		}
+		if inUse[d] {
+			o.Log.Warn("%s is in use by a cluster: not deleted", item.ImageReference)
+			return true, nil
+		}
+		return false, nil
+	}
+
+	items := make([]v2alpha1.DeleteItem, 0, len(deleteList.Items))
+	for _, item := range deleteList.Items {
+		used, err := isInUse(item)
+		if err != nil {
+			return deleteList, err
+		}
+		if !used {
+			items = append(items, item)
+		}
+	}
+	// the catalogs replaced without the deleted operators are still available
+	catalogs := make([]v2alpha1.DeleteCatalog, 0, len(deleteList.Catalogs))
+	for _, ctlg := range deleteList.Catalogs {
+		if len(ctlg.ReplacementTag) == 0 {
+			used, err := isInUse(v2alpha1.DeleteItem{ImageName: ctlg.ImageName, ImageReference: ctlg.ImageReference, Type: v2alpha1.TypeOperatorCatalog})
+			if err != nil {
+				return deleteList, err
+			}
+			if used {
+				continue
+			}
+		}
+		catalogs = append(catalogs, ctlg)
+	}
+	deleteList.Items = items
+	deleteList.Catalogs = catalogs
+	return deleteList, nil
+}
+
 // startLocalRegistryGarbageCollect
 func (o *DeleteSchema) startLocalRegistryGarbageCollect() error {
 	ctx := context.Background()
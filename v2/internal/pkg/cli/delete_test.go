@@ -2,15 +2,25 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/containers/image/v5/types"
 	"github.com/distribution/distribution/v3/registry"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/common"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/config"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/delete"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/history"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/imagebuilder"
 	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/otiai10/copy"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -97,6 +107,38 @@ func TestExecutorValidateDelete(t *testing.T) {
 		err = ex.ValidateDelete([]string{"docker://test"})
 		assert.Equal(t, "file not found ../../nothing", err.Error())
 
+		// check when kept imagesetconfigs are set without --generate
+		opts.Global.DeleteYaml = common.TestFolder + "delete/delete-images.yaml"
+		ex.KeepConfigs = []string{common.TestFolder + "isc.yaml"}
+		err = ex.ValidateDelete([]string{"docker://test"})
+		assert.Equal(t, "the --keep-config flag can only be used alongside the --generate flag", err.Error())
+
+		// check when the previous imagesetconfig is set without --generate
+		ex.KeepConfigs = nil
+		ex.PreviousConfig = common.TestFolder + "isc.yaml"
+		err = ex.ValidateDelete([]string{"docker://test"})
+		assert.Equal(t, "the --previous-config flag can only be used alongside the --generate flag", err.Error())
+
+		// check when the garbage collection hook is set with --generate
+		ex.PreviousConfig = ""
+		ex.GCHook = "harbor"
+		opts.Global.DeleteGenerate = true
+		err = ex.ValidateDelete([]string{"docker://test"})
+		assert.Equal(t, "the --gc-hook flag can not be used alongside the --generate flag", err.Error())
+
+		// check when the garbage collection hook settings are set without the hook
+		ex.GCHook = ""
+		ex.GCHookEndpoint = "https://harbor.example.com"
+		opts.Global.DeleteGenerate = false
+		err = ex.ValidateDelete([]string{"docker://test"})
+		assert.Equal(t, "the --gc-hook-endpoint and --gc-hook-credentials-file flags can only be used alongside the --gc-hook flag", err.Error())
+
+		// check when the clusters in use are set with --generate
+		ex.GCHookEndpoint = ""
+		ex.InUseResources = []string{"cluster.yaml"}
+		opts.Global.DeleteGenerate = true
+		err = ex.ValidateDelete([]string{"docker://test"})
+		assert.Equal(t, "the --in-use-kubeconfig and --in-use-resources flags can not be used alongside the --generate flag", err.Error())
 	})
 }
 
@@ -357,3 +399,356 @@ func TestExecutorRunDelete(t *testing.T) {
 		}
 	})
 }
+
+// TestNoLongerSelected
+func TestNoLongerSelected(t *testing.T) {
+	t.Run("Testing noLongerSelected - images dropped from the imagesetconfig : should only return them", func(t *testing.T) {
+		kept := v2alpha1.CopyImageSchema{Destination: "docker://myregistry/openshift/release:4.16.1-x86_64"}
+		dropped := v2alpha1.CopyImageSchema{Destination: "docker://myregistry/openshift/release:4.15.12-x86_64"}
+		current := []v2alpha1.CopyImageSchema{kept, {Destination: "docker://myregistry/openshift/release:4.16.2-x86_64"}}
+		assert.Equal(t, []v2alpha1.CopyImageSchema{dropped}, noLongerSelected([]v2alpha1.CopyImageSchema{kept, dropped}, current))
+	})
+
+	t.Run("Testing noLongerSelected - unchanged imagesetconfig : should return no image", func(t *testing.T) {
+		images := []v2alpha1.CopyImageSchema{{Destination: "docker://myregistry/ubi8/ubi:latest"}}
+		assert.Empty(t, noLongerSelected(images, images))
+	})
+}
+
+// TestWithImagesByAge
+func TestWithImagesByAge(t *testing.T) {
+	old := v2alpha1.CopyImageSchema{
+		Origin:      "quay.io/openshift-release-dev/ocp-release:4.14.1-x86_64",
+		Destination: "docker://myregistry/openshift/release-images:4.14.1-x86_64",
+		Type:        v2alpha1.TypeOCPRelease,
+	}
+	recent := v2alpha1.CopyImageSchema{
+		Origin:      "quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64",
+		Destination: "docker://myregistry/openshift/release-images:4.16.1-x86_64",
+		Type:        v2alpha1.TypeOCPRelease,
+	}
+	ubi := v2alpha1.CopyImageSchema{
+		Origin:      "registry.redhat.io/ubi8/ubi:latest",
+		Destination: "docker://myregistry/ubi8/ubi:latest",
+		Type:        v2alpha1.TypeGeneric,
+	}
+	newSchema := func(workingDir string) *DeleteSchema {
+		return &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:  clog.New("trace"),
+				Opts: &mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: workingDir, DeleteDestination: "docker://myregistry"}},
+			},
+			age: &v2alpha1.AgePolicy{MaxAgeDays: 180},
+		}
+	}
+
+	t.Run("Testing withImagesByAge - recorded images : should add the old images once", func(t *testing.T) {
+		workingDir := t.TempDir()
+		assert.NoError(t, history.RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{old, ubi}, time.Now().UTC().AddDate(0, 0, -200)))
+		assert.NoError(t, history.RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{recent}, time.Now().UTC()))
+		images, err := newSchema(workingDir).withImagesByAge([]v2alpha1.CopyImageSchema{ubi})
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{ubi, old}, images)
+	})
+
+	t.Run("Testing withImagesByAge - nothing recorded : should leave the images as is", func(t *testing.T) {
+		images, err := newSchema(t.TempDir()).withImagesByAge([]v2alpha1.CopyImageSchema{ubi})
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{ubi}, images)
+	})
+}
+
+// mockDigestManifest returns the digest of the catalogs of the destination
+type mockDigestManifest struct {
+	manifest.ManifestInterface
+	digests map[string]string
+}
+
+func (o mockDigestManifest) GetDigest(ctx context.Context, sourceCtx *types.SystemContext, imgRef string) (string, error) {
+	d, ok := o.digests[imgRef]
+	if !ok {
+		return "", fmt.Errorf("manifest unknown")
+	}
+	return d, nil
+}
+
+// mockCatalogBuilder records the rebuilt catalogs
+type mockCatalogBuilder struct {
+	imagebuilder.CatalogBuilderInterface
+	rebuilt []string
+}
+
+func (o *mockCatalogBuilder) RebuildCatalog(ctx context.Context, catalogCopyRef v2alpha1.CopyImageSchema, configPath string) error {
+	o.rebuilt = append(o.rebuilt, catalogCopyRef.Source)
+	return os.WriteFile(filepath.Join(filepath.Dir(configPath), "digest"), []byte("rebuilt"), 0644)
+}
+
+// TestDeletedCatalogs
+func TestDeletedCatalogs(t *testing.T) {
+	const (
+		currentTag = "4d3a1c0e9f5b7e2d6c8a0b1f3e5d7c9a"
+		filterTag  = "9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b"
+	)
+	origin := "docker://registry.redhat.io/redhat/redhat-operator-index:v4.16"
+	catalogImage := v2alpha1.CopyImageSchema{
+		Origin:      origin,
+		Source:      "docker://localhost:55000/redhat/redhat-operator-index:" + filterTag,
+		Destination: "docker://myregistry/redhat/redhat-operator-index:v4.16",
+		Type:        v2alpha1.TypeOperatorCatalog,
+	}
+	current := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "op1", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Name: "stable", Package: "op1", Entries: []declcfg.ChannelEntry{{Name: "op1.v1"}, {Name: "op1.v2", Replaces: "op1.v1"}}}},
+		Bundles: []declcfg.Bundle{
+			{Schema: declcfg.SchemaBundle, Name: "op1.v1", Package: "op1", Image: "registry.redhat.io/op1/bundle:v1"},
+			{Schema: declcfg.SchemaBundle, Name: "op1.v2", Package: "op1", Image: "registry.redhat.io/op1/bundle:v2"},
+		},
+	}
+	// the working-dir holds the catalog rebuilt for the destination, and the catalog filtered for the delete
+	newSchema := func(t *testing.T, deleted []declcfg.Bundle) (*DeleteSchema, *mockCatalogBuilder, map[string]v2alpha1.CatalogFilterResult) {
+		filteredCatalogsDir := filepath.Join(t.TempDir(), "filtered-catalogs")
+		assert.NoError(t, os.MkdirAll(filepath.Join(filteredCatalogsDir, currentTag, catalogConfigDir), 0755))
+		assert.NoError(t, declcfg.WriteFS(current, filepath.Join(filteredCatalogsDir, currentTag, catalogConfigDir), declcfg.WriteJSON, ".json"))
+		assert.NoError(t, os.WriteFile(filepath.Join(filteredCatalogsDir, currentTag, "digest"), []byte("abcdef"), 0644))
+		builder := &mockCatalogBuilder{}
+		_, sharedOpts := mirror.SharedImageFlags()
+		_, deprecatedTLSVerifyOpt := mirror.DeprecatedTLSVerifyFlags()
+		global := &mirror.GlobalOptions{DeleteDestination: "docker://myregistry"}
+		_, destOpts := mirror.ImageDestFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "dest-", "dcreds")
+		o := &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:            clog.New("trace"),
+				Opts:           &mirror.CopyOptions{Global: global, DestImage: destOpts},
+				Manifest:       mockDigestManifest{digests: map[string]string{catalogImage.Destination: "sha256:abcdef"}},
+				CatalogBuilder: builder,
+			},
+		}
+		fbcs := map[string]v2alpha1.CatalogFilterResult{
+			origin: {
+				FilteredConfigPath: filepath.Join(filteredCatalogsDir, filterTag, catalogConfigDir),
+				DeclConfig:         &declcfg.DeclarativeConfig{Bundles: deleted},
+			},
+		}
+		return o, builder, fbcs
+	}
+
+	t.Run("Testing deletedCatalogs - some operators deleted : should rebuild the catalog without them", func(t *testing.T) {
+		o, builder, fbcs := newSchema(t, current.Bundles[:1])
+		catalogs, err := o.deletedCatalogs(context.Background(), []v2alpha1.CopyImageSchema{catalogImage}, fbcs, nil)
+		assert.NoError(t, err)
+		assert.Len(t, catalogs, 1)
+		assert.Len(t, catalogs[0].ReplacementTag, 32)
+		assert.Equal(t, []string{"docker://localhost:55000/redhat/redhat-operator-index:" + catalogs[0].ReplacementTag}, builder.rebuilt)
+
+		remaining, err := declcfg.LoadFS(context.Background(), os.DirFS(filepath.Join(filepath.Dir(filepath.Dir(fbcs[origin].FilteredConfigPath)), catalogs[0].ReplacementTag, catalogConfigDir)))
+		assert.NoError(t, err)
+		assert.Len(t, remaining.Bundles, 1)
+		assert.Equal(t, "op1.v2", remaining.Bundles[0].Name)
+
+		// deleting the same operators again uses the catalog already rebuilt
+		again, err := o.deletedCatalogs(context.Background(), []v2alpha1.CopyImageSchema{catalogImage}, fbcs, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, catalogs, again)
+		assert.Len(t, builder.rebuilt, 1)
+	})
+
+	t.Run("Testing deletedCatalogs - all operators deleted : should delete the catalog and its rebuilt tags", func(t *testing.T) {
+		o, builder, fbcs := newSchema(t, current.Bundles)
+		catalogs, err := o.deletedCatalogs(context.Background(), []v2alpha1.CopyImageSchema{catalogImage}, fbcs, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.DeleteCatalog{{ImageName: origin, ImageReference: catalogImage.Destination, CacheTags: []string{currentTag}}}, catalogs)
+		assert.Empty(t, builder.rebuilt)
+	})
+
+	t.Run("Testing deletedCatalogs - catalog of a kept image set : should not update it", func(t *testing.T) {
+		o, _, fbcs := newSchema(t, current.Bundles)
+		catalogs, err := o.deletedCatalogs(context.Background(), []v2alpha1.CopyImageSchema{catalogImage}, fbcs, []v2alpha1.CopyImageSchema{catalogImage})
+		assert.NoError(t, err)
+		assert.Empty(t, catalogs)
+	})
+
+	t.Run("Testing deletedCatalogs - unknown content : should not update the catalog", func(t *testing.T) {
+		o, _, fbcs := newSchema(t, current.Bundles)
+		o.Manifest = mockDigestManifest{digests: map[string]string{catalogImage.Destination: "sha256:012345"}}
+		catalogs, err := o.deletedCatalogs(context.Background(), []v2alpha1.CopyImageSchema{catalogImage}, fbcs, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, catalogs)
+	})
+}
+
+// TestWithGraphImages
+func TestWithGraphImages(t *testing.T) {
+	release := v2alpha1.CopyImageSchema{
+		Origin:      "quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64",
+		Destination: "docker://myregistry/openshift/release-images:4.16.1-x86_64",
+		Type:        v2alpha1.TypeOCPRelease,
+	}
+	graph := v2alpha1.CopyImageSchema{
+		Origin:      "docker://localhost:55000/openshift/graph-image:latest",
+		Destination: "docker://myregistry/openshift/graph-image:latest",
+		Type:        v2alpha1.TypeCincinnatiGraph,
+	}
+
+	t.Run("Testing withGraphImages - last release deleted : should delete the graph image", func(t *testing.T) {
+		workingDir := t.TempDir()
+		assert.NoError(t, history.RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{release, graph}, time.Now().UTC()))
+		o := &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:  clog.New("trace"),
+				Opts: &mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: workingDir, DeleteDestination: "docker://myregistry"}},
+			},
+		}
+		images, err := o.withGraphImages([]v2alpha1.CopyImageSchema{release})
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{release, graph}, images)
+	})
+}
+
+// TestWithoutImagesInUse
+func TestWithoutImagesInUse(t *testing.T) {
+	inUseDigest := "sha256:" + strings.Repeat("1", 64)
+	resources := `apiVersion: config.openshift.io/v1
+kind: ClusterVersion
+metadata:
+  name: version
+status:
+  desired:
+    image: quay.io/openshift-release-dev/ocp-release@` + inUseDigest + `
+`
+	release := v2alpha1.DeleteItem{
+		ImageName:      "quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64",
+		ImageReference: "docker://myregistry/openshift/release-images:4.16.1-x86_64",
+		Type:           v2alpha1.TypeOCPRelease,
+	}
+	oldRelease := v2alpha1.DeleteItem{
+		ImageName:      "quay.io/openshift-release-dev/ocp-release:4.15.1-x86_64",
+		ImageReference: "docker://myregistry/openshift/release-images:4.15.1-x86_64",
+		Type:           v2alpha1.TypeOCPRelease,
+	}
+	catalog := v2alpha1.DeleteCatalog{
+		ImageName:      "docker://registry.redhat.io/redhat/redhat-operator-index:v4.16",
+		ImageReference: "docker://myregistry/redhat/redhat-operator-index:v4.16",
+	}
+
+	t.Run("Testing withoutImagesInUse - image in use : should not delete it", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cluster.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(resources), 0644))
+		_, sharedOpts := mirror.SharedImageFlags()
+		_, deprecatedTLSVerifyOpt := mirror.DeprecatedTLSVerifyFlags()
+		global := &mirror.GlobalOptions{}
+		_, destOpts := mirror.ImageDestFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "dest-", "dcreds")
+		o := &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:  clog.New("trace"),
+				Opts: &mirror.CopyOptions{Global: global, DestImage: destOpts},
+				Manifest: mockDigestManifest{digests: map[string]string{
+					release.ImageReference:    strings.TrimPrefix(inUseDigest, "sha256:"),
+					oldRelease.ImageReference: strings.Repeat("2", 64),
+					catalog.ImageReference:    inUseDigest,
+				}},
+			},
+			inUse: &delete.InUseImages{Log: clog.New("trace"), Files: []string{path}},
+		}
+		deleteList, err := o.withoutImagesInUse(context.Background(), v2alpha1.DeleteImageList{
+			Items:    []v2alpha1.DeleteItem{release, oldRelease},
+			Catalogs: []v2alpha1.DeleteCatalog{catalog},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.DeleteItem{oldRelease}, deleteList.Items)
+		assert.Empty(t, deleteList.Catalogs)
+	})
+
+	t.Run("Testing withoutImagesInUse - cluster not readable : should fail", func(t *testing.T) {
+		o := &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{Log: clog.New("trace")},
+			inUse:          &delete.InUseImages{Log: clog.New("trace"), Files: []string{filepath.Join(t.TempDir(), "missing.yaml")}},
+		}
+		_, err := o.withoutImagesInUse(context.Background(), v2alpha1.DeleteImageList{Items: []v2alpha1.DeleteItem{release}})
+		assert.ErrorContains(t, err, "unable to find the images in use, no image deleted")
+	})
+
+	t.Run("Testing withoutImagesInUse - digest not readable : should fail", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cluster.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(resources), 0644))
+		_, sharedOpts := mirror.SharedImageFlags()
+		_, deprecatedTLSVerifyOpt := mirror.DeprecatedTLSVerifyFlags()
+		global := &mirror.GlobalOptions{}
+		_, destOpts := mirror.ImageDestFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "dest-", "dcreds")
+		o := &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:      clog.New("trace"),
+				Opts:     &mirror.CopyOptions{Global: global, DestImage: destOpts},
+				Manifest: mockDigestManifest{digests: map[string]string{oldRelease.ImageReference: strings.Repeat("2", 64)}},
+			},
+			inUse: &delete.InUseImages{Log: clog.New("trace"), Files: []string{path}},
+		}
+		_, err := o.withoutImagesInUse(context.Background(), v2alpha1.DeleteImageList{Items: []v2alpha1.DeleteItem{oldRelease, release}})
+		assert.ErrorContains(t, err, "unable to read the digest of "+release.ImageReference+" to check whether it is in use, no image deleted")
+	})
+}
+
+type mockGarbageCollector struct {
+	called bool
+	fail   bool
+}
+
+func (o *mockGarbageCollector) GarbageCollect(ctx context.Context, deleted []v2alpha1.DeleteItem) error {
+	o.called = true
+	if o.fail {
+		return fmt.Errorf("forced error")
+	}
+	return nil
+}
+
+// TestDeleteImagesGarbageCollect
+func TestDeleteImagesGarbageCollect(t *testing.T) {
+	newSchema := func(gc *mockGarbageCollector) *DeleteSchema {
+		return &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:    clog.New("trace"),
+				Opts:   &mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: t.TempDir()}},
+				Delete: MockDelete{},
+			},
+			GCHook:           "harbor",
+			garbageCollector: gc,
+		}
+	}
+
+	t.Run("Testing deleteImages - gc hook : should run the garbage collection after the delete", func(t *testing.T) {
+		gc := &mockGarbageCollector{}
+		assert.NoError(t, newSchema(gc).deleteImages(context.Background()))
+		assert.True(t, gc.called)
+	})
+
+	t.Run("Testing deleteImages - gc hook failing : should fail", func(t *testing.T) {
+		gc := &mockGarbageCollector{fail: true}
+		err := newSchema(gc).deleteImages(context.Background())
+		assert.EqualError(t, err, "the images were deleted, but the garbage collection failed: forced error")
+	})
+}
+
+// TestDeleteImagesForgetRecords
+func TestDeleteImagesForgetRecords(t *testing.T) {
+	t.Run("Testing deleteImages - deleted images : should remove their mirrored records", func(t *testing.T) {
+		workingDir := t.TempDir()
+		old := v2alpha1.CopyImageSchema{Origin: "quay.io/ubi8/ubi:8.8", Destination: "docker://myregistry/ubi8/ubi:8.8", Type: v2alpha1.TypeGeneric}
+		last := v2alpha1.CopyImageSchema{Origin: "quay.io/ubi8/ubi:8.9", Destination: "docker://myregistry/ubi8/ubi:8.9", Type: v2alpha1.TypeGeneric}
+		assert.NoError(t, history.RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{old, last}, time.Now()))
+
+		o := &DeleteSchema{
+			ExecutorSchema: ExecutorSchema{
+				Log:  clog.New("trace"),
+				Opts: &mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: workingDir}},
+				Delete: MockDelete{deleteList: v2alpha1.DeleteImageList{
+					Items: []v2alpha1.DeleteItem{{ImageName: old.Origin, ImageReference: old.Destination, Type: v2alpha1.TypeGeneric}},
+				}},
+			},
+		}
+		assert.NoError(t, o.deleteImages(context.Background()))
+
+		records, err := history.ReadMirroredImages(workingDir)
+		assert.NoError(t, err)
+		assert.Len(t, records, 1)
+		assert.Equal(t, last.Destination, records[0].ImageReference)
+	})
+}
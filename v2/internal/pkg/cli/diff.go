@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/config"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+type DiffSchema struct {
+	Log        clog.PluggableLoggerInterface
+	Opts       *mirror.CopyOptions
+	OldCatalog string
+	NewCatalog string
+	Packages   []string
+	Output     string
+}
+
+// NewDiffCommand - setup the 'diff' sub command
+func NewDiffCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &DiffSchema{
+		Log:  log,
+		Opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diff <old catalog> <new catalog>",
+		Short: "Report the packages, channels, bundles, upgrade edges and related images changed between two versions of a catalog",
+		Example: templates.Examples(`
+			# Compare two versions of a catalog
+			oc-mirror diff registry.redhat.io/redhat/redhat-operator-index:v4.15 registry.redhat.io/redhat/redhat-operator-index:v4.16 --v2
+
+			# Compare a version pulled by a previous run of the workspace with the current one, for the packages of the imageset configuration, in JSON
+			oc-mirror diff registry.redhat.io/redhat/redhat-operator-index@sha256:<digest> registry.redhat.io/redhat/redhat-operator-index:v4.16 -c ./isc.yaml --workspace file:///home/<user>/oc-mirror/mirror1 --output json --v2
+		`),
+		Args:              cobra.ExactArgs(2),
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.Validate(args); err != nil {
+				return err
+			}
+			if err := ex.Complete(args); err != nil {
+				return err
+			}
+			return ex.Run(cmd)
+		},
+	}
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or a list of changes when not set")
+	return cmd
+}
+
+// Validate - cobra validation
+func (o DiffSchema) Validate(args []string) error {
+	if len(args) != 2 || args[0] == "" || args[1] == "" {
+		return fmt.Errorf("the old and the new catalog are mandatory")
+	}
+	if o.Output != "" && o.Output != outputJSON {
+		return fmt.Errorf("invalid --output %q: only json is supported", o.Output)
+	}
+	if len(o.Opts.Global.WorkingDir) > 0 && !strings.HasPrefix(o.Opts.Global.WorkingDir, fileProtocol) {
+		return fmt.Errorf("when --workspace is used, it must have file:// prefix")
+	}
+	return nil
+}
+
+// Complete - cobra complete
+func (o *DiffSchema) Complete(args []string) error {
+	o.Log.Level(o.Opts.Global.LogLevel)
+	o.OldCatalog, o.NewCatalog = args[0], args[1]
+	if len(o.Opts.Global.ConfigPath) > 0 {
+		cfg, err := config.ReadConfig(o.Opts.Global.ConfigPath, v2alpha1.ImageSetConfigurationKind)
+		if err != nil {
+			return err
+		}
+		o.Packages, err = iscPackages(cfg.(v2alpha1.ImageSetConfiguration), o.NewCatalog)
+		if err != nil {
+			return err
+		}
+	}
+	return completeCatalogWorkingDir(o.Opts)
+}
+
+// Run - compares the catalogs
+func (o *DiffSchema) Run(cmd *cobra.Command) error {
+	diff, err := operator.DiffCatalogs(cmd.Context(), o.Log, *o.Opts, mirror.New(mirror.NewMirrorCopy(), nil), manifest.New(o.Log), o.OldCatalog, o.NewCatalog, o.Packages)
+	if err != nil {
+		return err
+	}
+	if o.Output == outputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	if diff.IsEmpty() {
+		fmt.Fprintln(cmd.OutOrStdout(), "no change")
+		return nil
+	}
+	writeCatalogDiff(cmd.OutOrStdout(), diff)
+	return nil
+}
+
+// iscPackages returns the packages of the Operator entries of cfg for the repository of catalog,
+// or nil when one of them mirrors the whole catalog
+func iscPackages(cfg v2alpha1.ImageSetConfiguration, catalog string) ([]string, error) {
+	catalogSpec, err := image.ParseRef(catalog)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	packages := []string{}
+	for _, op := range cfg.Mirror.Operators {
+		opSpec, err := image.ParseRef(op.Catalog)
+		if err != nil {
+			return nil, err
+		}
+		if opSpec.Name != catalogSpec.Name {
+			continue
+		}
+		found = true
+		if len(op.Packages) == 0 {
+			return nil, nil
+		}
+		for _, pkg := range op.Packages {
+			packages = append(packages, pkg.Name)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no operator catalog %s in the imageset configuration", catalogSpec.Name)
+	}
+	return packages, nil
+}
+
+// writeCatalogDiff writes a line for each change of diff
+func writeCatalogDiff(out io.Writer, diff operator.CatalogDiff) {
+	writeDiffLines(out, "+ package", diff.AddedPackages)
+	writeDiffLines(out, "- package", diff.RemovedPackages)
+	writeDiffLines(out, "+ channel", diff.AddedChannels)
+	writeDiffLines(out, "- channel", diff.RemovedChannels)
+	writeDiffLines(out, "+ bundle", diff.AddedBundles)
+	writeDiffLines(out, "- bundle", diff.RemovedBundles)
+	writeDiffLines(out, "+ edge", diff.AddedEdges)
+	writeDiffLines(out, "- edge", diff.RemovedEdges)
+	writeDiffLines(out, "+ image", diff.AddedRelatedImages)
+	writeDiffLines(out, "- image", diff.RemovedRelatedImages)
+}
+
+func writeDiffLines(out io.Writer, prefix string, items []string) {
+	for _, item := range items {
+		fmt.Fprintf(out, "  %s %s\n", prefix, item)
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+func TestDiffIscPackages(t *testing.T) {
+	cfg := v2alpha1.ImageSetConfiguration{
+		ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+			Mirror: v2alpha1.Mirror{
+				Operators: []v2alpha1.Operator{
+					{Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.15", IncludeConfig: v2alpha1.IncludeConfig{Packages: []v2alpha1.IncludePackage{{Name: "foo"}}}},
+					{Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.16", IncludeConfig: v2alpha1.IncludeConfig{Packages: []v2alpha1.IncludePackage{{Name: "bar"}}}},
+					{Catalog: "registry.redhat.io/redhat/certified-operator-index:v4.16"},
+				},
+			},
+		},
+	}
+
+	packages, err := iscPackages(cfg, "registry.redhat.io/redhat/redhat-operator-index@sha256:0123456789012345678901234567890123456789012345678901234567890123")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, packages)
+
+	packages, err = iscPackages(cfg, "registry.redhat.io/redhat/certified-operator-index:v4.17")
+	assert.NoError(t, err)
+	assert.Nil(t, packages)
+
+	_, err = iscPackages(cfg, "registry.redhat.io/redhat/community-operator-index:v4.16")
+	assert.EqualError(t, err, "no operator catalog registry.redhat.io/redhat/community-operator-index in the imageset configuration")
+}
+
+func TestDiffWriteCatalogDiff(t *testing.T) {
+	var out bytes.Buffer
+	writeCatalogDiff(&out, operator.CatalogDiff{
+		AddedBundles:         []string{"foo/foo.v1.1.0"},
+		AddedEdges:           []string{"foo/stable: foo.v1.1.0 replaces foo.v1.0.0"},
+		RemovedRelatedImages: []string{"quay.io/foo/operator:v0.9.0"},
+	})
+	assert.Equal(t, `  + bundle foo/foo.v1.1.0
+  + edge foo/stable: foo.v1.1.0 replaces foo.v1.0.0
+  - image quay.io/foo/operator:v0.9.0
+`, out.String())
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/openshift/oc-mirror/v2/internal/pkg/delete"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/helm"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/history"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/imagebuilder"
 	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
@@ -130,6 +131,7 @@ type ExecutorSchema struct {
 	LocalStorageService registry.Registry
 	LocalStorageDisk    string
 	ClusterResources    clusterresources.GeneratorInterface
+	ClusterApplier      clusterresources.ApplierInterface
 	ImageBuilder        imagebuilder.ImageBuilderInterface
 	CatalogBuilder      imagebuilder.CatalogBuilderInterface
 	MirrorArchiver      archive.Archiver
@@ -238,6 +240,11 @@ func NewMirrorCmd(log clog.PluggableLoggerInterface) *cobra.Command {
 	}
 	cmd.AddCommand(version.NewVersionCommand(log))
 	cmd.AddCommand(NewDeleteCommand(log, opts))
+	cmd.AddCommand(NewListCommand(log, opts))
+	cmd.AddCommand(NewPreviewCommand(log, opts))
+	cmd.AddCommand(NewDiffCommand(log, opts))
+	cmd.AddCommand(NewCacheCommand(log, opts))
+	cmd.AddCommand(NewHistoryCommand(log, opts))
 	// common flags
 	cmd.PersistentFlags().StringVarP(&opts.Global.ConfigPath, "config", "c", "", "Path to imageset configuration file")
 	cmd.MarkPersistentFlagFilename("config", "yaml")
@@ -271,6 +278,12 @@ func NewMirrorCmd(log clog.PluggableLoggerInterface) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.Global.StrictArchiving, "strict-archive", false, "If set, generates archives that are strictly less than archiveSize (set in the imageSetConfig). Mirroring will exit in error if a file being archived exceed archiveSize(GB)")
 	cmd.Flags().StringVar(&opts.RootlessStoragePath, "rootless-storage-path", "", "Override the default container rootless storage path (usually in etc/containers/storage.conf)")
 	cmd.Flags().BoolVar(&opts.RemoveSignatures, "remove-signatures", false, "Do not copy image signature")
+	cmd.Flags().StringVar(&opts.Global.ExistingMirrorSets, "existing-mirror-sets", "", "Directory containing the IDMS/ITMS exported from the cluster (and optionally delete-images files) to merge with the generated IDMS/ITMS")
+	cmd.Flags().BoolVar(&opts.Global.ApplyClusterResources, "apply-cluster-resources", false, "Server-side apply the generated cluster resources to the cluster, and wait for the CatalogSources to be ready")
+	cmd.Flags().StringVar(&opts.Global.Receipt, "receipt", "", "Receipt written by a diskToMirror run (working-dir/mirror-receipt.yaml): the archive leaves out the manifests and blobs it lists, instead of the ones of the previous archives")
+	cmd.Flags().StringSliceVar(&opts.Global.DeliveredBlobs, "delivered-blobs", nil, "Blobs the destination already has, left out of the archive along with the ones of the previous archives: a registry to walk (docker://<registry>[/<namespace>]), a blob list file with one digest per line, or a previous archive (or a directory of archives)")
+	cmd.Flags().BoolVar(&opts.Global.IgnoreHistory, "ignore-history", false, "Leave out of the archive the blobs of --delivered-blobs only, and not the ones of the previous archives")
+	cmd.Flags().StringVar(&opts.Global.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig used by --apply-cluster-resources (defaults to $KUBECONFIG or ~/.kube/config)")
 	HideFlags(cmd)
 
 	ex.Opts.Stdout = cmd.OutOrStdout()
@@ -362,6 +375,37 @@ func (o ExecutorSchema) Validate(dest []string) error {
 	if strings.Contains(dest[0], fileProtocol) && o.Opts.Global.WorkingDir != "" {
 		return fmt.Errorf("when destination is file://, mirrorToDisk workflow is assumed, and the --workspace argument is not needed")
 	}
+	if strings.Contains(dest[0], fileProtocol) && o.Opts.Global.ExistingMirrorSets != "" {
+		return fmt.Errorf("--existing-mirror-sets can only be used with the mirrorToMirror and diskToMirror workflows")
+	}
+	if strings.Contains(dest[0], fileProtocol) && o.Opts.Global.ApplyClusterResources {
+		return fmt.Errorf("--apply-cluster-resources can only be used with the mirrorToMirror and diskToMirror workflows")
+	}
+	if o.Opts.Global.Receipt != "" {
+		if !strings.Contains(dest[0], fileProtocol) {
+			return fmt.Errorf("--receipt can only be used with the mirrorToDisk workflow")
+		}
+		if o.Opts.Global.SinceString != "" {
+			return fmt.Errorf("--receipt and --since can not be used together")
+		}
+		if fi, err := os.Stat(o.Opts.Global.Receipt); err != nil || fi.IsDir() {
+			return fmt.Errorf("--receipt must be an existing file: %s", o.Opts.Global.Receipt)
+		}
+	}
+	if len(o.Opts.Global.DeliveredBlobs) > 0 && !strings.Contains(dest[0], fileProtocol) {
+		return fmt.Errorf("--delivered-blobs can only be used with the mirrorToDisk workflow")
+	}
+	if o.Opts.Global.IgnoreHistory && len(o.Opts.Global.DeliveredBlobs) == 0 {
+		return fmt.Errorf("--ignore-history can only be used with --delivered-blobs")
+	}
+	if o.Opts.Global.Kubeconfig != "" && !o.Opts.Global.ApplyClusterResources {
+		return fmt.Errorf("--kubeconfig can only be used with --apply-cluster-resources")
+	}
+	if o.Opts.Global.ExistingMirrorSets != "" {
+		if fi, err := os.Stat(o.Opts.Global.ExistingMirrorSets); err != nil || !fi.IsDir() {
+			return fmt.Errorf("--existing-mirror-sets must be an existing directory: %s", o.Opts.Global.ExistingMirrorSets)
+		}
+	}
 	if strings.Contains(dest[0], dockerProtocol) && o.Opts.Global.WorkingDir != "" && o.Opts.Global.From != "" {
 		return fmt.Errorf("when destination is docker://, --from (assumes disk to mirror workflow) and --workspace (assumes mirror to mirror workflow) cannot be used together")
 	}
@@ -474,7 +518,13 @@ func (o *ExecutorSchema) Complete(args []string) error {
 	o.Operator = operator.NewWithFilter(o.Log, o.LogsDir, o.Config, *o.Opts, o.Mirror, o.Manifest)
 	o.AdditionalImages = additional.New(o.Log, o.Config, *o.Opts, o.Mirror, o.Manifest)
 	o.HelmCollector = helm.New(o.Log, o.Config, *o.Opts, nil, nil, &http.Client{Timeout: time.Duration(5) * time.Second})
-	o.ClusterResources = clusterresources.New(o.Log, o.Opts.Global.WorkingDir, o.Config, o.Opts.LocalStorageFQDN)
+	o.ClusterResources = clusterresources.New(o.Log, o.Opts.Global.WorkingDir, o.Config, o.Opts.LocalStorageFQDN, o.Opts.Global.ExistingMirrorSets)
+	if o.Opts.Global.ApplyClusterResources {
+		o.ClusterApplier, err = clusterresources.NewApplier(o.Log, o.Opts.Global.WorkingDir, o.Opts.Global.Kubeconfig)
+		if err != nil {
+			return err
+		}
+	}
 	o.Batch = batch.New(batch.ChannelConcurrentWorker, o.Log, o.LogsDir, o.Mirror, o.Opts.ParallelImages)
 
 	if o.Opts.IsMirrorToDisk() {
@@ -794,6 +844,12 @@ func (o *ExecutorSchema) RunMirrorToDisk(cmd *cobra.Command, args []string) erro
 		return batchError
 	}
 
+	if o.Opts.Global.Receipt != "" {
+		o.Log.Info("the archive leaves out the content listed by the receipt %s", o.Opts.Global.Receipt)
+	}
+	if len(o.Opts.Global.DeliveredBlobs) > 0 {
+		o.Log.Info("the archive leaves out the content delivered according to %s", strings.Join(o.Opts.Global.DeliveredBlobs, ", "))
+	}
 	// prepare tar.gz when mirror to disk
 	o.Log.Info(emoji.Package + " Preparing the tarball archive...")
 	// next, generate the archive
@@ -838,6 +894,7 @@ func (o *ExecutorSchema) RunMirrorToMirror(cmd *cobra.Command, args []string) er
 	// call the batch worker
 	// NOTE: we will check for batch errors at the end
 	copiedSchema, batchError := o.Batch.Worker(cmd.Context(), collectorSchema, *o.Opts)
+	o.recordMirroredImages(copiedSchema.AllImages)
 
 	// create IDMS/ITMS
 	forceRepositoryScope := o.Opts.Global.MaxNestedPaths > 0
@@ -845,6 +902,11 @@ func (o *ExecutorSchema) RunMirrorToMirror(cmd *cobra.Command, args []string) er
 		return err
 	}
 
+	// create ClusterImagePolicy/ImagePolicy
+	if err := o.ClusterResources.ImagePolicyGenerator(copiedSchema.AllImages, forceRepositoryScope); err != nil {
+		return err
+	}
+
 	if err := o.ClusterResources.CatalogSourceGenerator(copiedSchema.AllImages); err != nil {
 		return err
 	}
@@ -874,9 +936,51 @@ func (o *ExecutorSchema) RunMirrorToMirror(cmd *cobra.Command, args []string) er
 		}
 	}
 
+	// create kustomization, listing all the resources generated above
+	if err := o.ClusterResources.KustomizationGenerator(); err != nil {
+		return err
+	}
+
+	// apply the generated resources to the cluster
+	if err := o.applyClusterResources(cmd.Context(), batchError); err != nil {
+		return err
+	}
+
 	return batchError
 }
 
+// applyClusterResources applies the generated resources to the cluster when requested.
+// They are not applied when some images failed to be mirrored: they would point the cluster to missing images.
+func (o *ExecutorSchema) applyClusterResources(ctx context.Context, batchError error) error {
+	if !o.Opts.Global.ApplyClusterResources {
+		return nil
+	}
+	if batchError != nil {
+		o.Log.Warn("some images failed to be mirrored: the cluster resources are not applied to the cluster")
+		return nil
+	}
+	return o.ClusterApplier.Apply(ctx)
+}
+
+// recordMirroredImages records the images copied to the destination registry, with the date
+// of this run, so that they can later be deleted by age
+func (o *ExecutorSchema) recordMirroredImages(images []v2alpha1.CopyImageSchema) {
+	if err := history.RecordMirroredImages(o.Opts.Global.WorkingDir, images, time.Now().UTC()); err != nil {
+		o.Log.Warn("unable to record the mirrored images: %v", err)
+	}
+}
+
+// writeReceipt records the images pushed to the destination in the receipt of the working-dir, to be
+// passed to the next mirrorToDisk run with --receipt. The images pushed are not affected when it fails.
+func (o *ExecutorSchema) writeReceipt(ctx context.Context, images []v2alpha1.CopyImageSchema) {
+	receiptPath, err := archive.WriteReceipt(ctx, o.Log, archive.NewImageBlobGatherer(o.Opts), o.Opts.Global.WorkingDir, o.Opts.Destination, images, time.Now().UTC())
+	if err != nil {
+		o.Log.Warn("unable to write the receipt: %v", err)
+		return
+	}
+	o.Log.Info(emoji.Memo+" receipt of the images pushed written to %s: pass it to the next mirrorToDisk run with --receipt", receiptPath)
+}
+
 // RunDiskToMirror execute the disk to mirror functionality
 func (o *ExecutorSchema) RunDiskToMirror(cmd *cobra.Command, args []string) error {
 	// extract the archive
@@ -917,6 +1021,8 @@ func (o *ExecutorSchema) RunDiskToMirror(cmd *cobra.Command, args []string) erro
 	// call the batch worker
 	// NOTE: we will check for batch errors at the end
 	copiedSchema, batchError := o.Batch.Worker(cmd.Context(), collectorSchema, *o.Opts)
+	o.recordMirroredImages(copiedSchema.AllImages)
+	o.writeReceipt(cmd.Context(), copiedSchema.AllImages)
 
 	// create IDMS/ITMS
 	forceRepositoryScope := o.Opts.Global.MaxNestedPaths > 0
@@ -924,6 +1030,11 @@ func (o *ExecutorSchema) RunDiskToMirror(cmd *cobra.Command, args []string) erro
 		return err
 	}
 
+	// create ClusterImagePolicy/ImagePolicy
+	if err := o.ClusterResources.ImagePolicyGenerator(copiedSchema.AllImages, forceRepositoryScope); err != nil {
+		return err
+	}
+
 	// create catalog source
 	if err := o.ClusterResources.CatalogSourceGenerator(copiedSchema.AllImages); err != nil {
 		return err
@@ -954,6 +1065,16 @@ func (o *ExecutorSchema) RunDiskToMirror(cmd *cobra.Command, args []string) erro
 		}
 	}
 
+	// create kustomization, listing all the resources generated above
+	if err := o.ClusterResources.KustomizationGenerator(); err != nil {
+		return err
+	}
+
+	// apply the generated resources to the cluster
+	if err := o.applyClusterResources(cmd.Context(), batchError); err != nil {
+		return err
+	}
+
 	return batchError
 }
 
@@ -563,6 +563,58 @@ func TestExecutorValidate(t *testing.T) {
 		opts.Global.From = ""       // reset
 		opts.Global.WorkingDir = "" // reset
 		assert.Equal(t, "when destination is docker://, either --from (assumes disk to mirror workflow) or --workspace (assumes mirror to mirror workflow) need to be provided", ex.Validate([]string{"docker://test"}).Error())
+
+		// should not be able to merge existing mirror sets in mirror-to-disk workflow
+		opts.Global.ExistingMirrorSets = t.TempDir()
+		assert.Equal(t, "--existing-mirror-sets can only be used with the mirrorToMirror and diskToMirror workflows", ex.Validate([]string{"file://test"}).Error())
+
+		// should be able to merge existing mirror sets in mirror-to-mirror workflow
+		opts.Global.WorkingDir = "file://test"
+		assert.NoError(t, ex.Validate([]string{"docker://test"}))
+
+		// existing mirror sets should be an existing directory
+		opts.Global.ExistingMirrorSets = "/tmp/does-not-exist"
+		assert.Equal(t, "--existing-mirror-sets must be an existing directory: /tmp/does-not-exist", ex.Validate([]string{"docker://test"}).Error())
+		opts.Global.ExistingMirrorSets = "" // reset
+
+		// should not be able to apply cluster resources in mirror-to-disk workflow
+		opts.Global.WorkingDir = ""
+		opts.Global.ApplyClusterResources = true
+		assert.Equal(t, "--apply-cluster-resources can only be used with the mirrorToMirror and diskToMirror workflows", ex.Validate([]string{"file://test"}).Error())
+
+		// should be able to apply cluster resources in mirror-to-mirror workflow
+		opts.Global.WorkingDir = "file://test"
+		opts.Global.Kubeconfig = "/tmp/kubeconfig"
+		assert.NoError(t, ex.Validate([]string{"docker://test"}))
+
+		// kubeconfig is only used to apply cluster resources
+		opts.Global.ApplyClusterResources = false
+		assert.Equal(t, "--kubeconfig can only be used with --apply-cluster-resources", ex.Validate([]string{"docker://test"}).Error())
+		opts.Global.Kubeconfig = "" // reset
+
+		// the receipt of diskToMirror is only used by mirror-to-disk
+		opts.Global.WorkingDir = ""
+		opts.Global.Receipt = filepath.Join(t.TempDir(), "mirror-receipt.yaml")
+		assert.Equal(t, "--receipt must be an existing file: "+opts.Global.Receipt, ex.Validate([]string{"file://test"}).Error())
+		assert.NoError(t, os.WriteFile(opts.Global.Receipt, []byte("kind: MirrorReceipt"), 0644))
+		assert.NoError(t, ex.Validate([]string{"file://test"}))
+		opts.Global.SinceString = "2024-01-01"
+		assert.Equal(t, "--receipt and --since can not be used together", ex.Validate([]string{"file://test"}).Error())
+		opts.Global.SinceString = "" // reset
+		opts.Global.WorkingDir = "file://test"
+		assert.Equal(t, "--receipt can only be used with the mirrorToDisk workflow", ex.Validate([]string{"docker://test"}).Error())
+		opts.Global.Receipt = "" // reset
+
+		// the blobs delivered are only used by mirror-to-disk
+		opts.Global.DeliveredBlobs = []string{"docker://mirror.example.com"}
+		assert.Equal(t, "--delivered-blobs can only be used with the mirrorToDisk workflow", ex.Validate([]string{"docker://test"}).Error())
+		opts.Global.WorkingDir = ""
+		opts.Global.IgnoreHistory = true
+		assert.NoError(t, ex.Validate([]string{"file://test"}))
+		opts.Global.DeliveredBlobs = nil
+		assert.Equal(t, "--ignore-history can only be used with --delivered-blobs", ex.Validate([]string{"file://test"}).Error())
+		opts.Global.IgnoreHistory = false // reset
+		opts.Global.WorkingDir = "file://test"
 	})
 }
 
@@ -927,6 +979,39 @@ func TestExcludeImages(t *testing.T) {
 	}
 }
 
+func TestExecutorApplyClusterResources(t *testing.T) {
+	log := clog.New("trace")
+	opts := &mirror.CopyOptions{Global: &mirror.GlobalOptions{ApplyClusterResources: true}}
+
+	t.Run("Testing ExecutorApplyClusterResources - mirror succeeded : should apply", func(t *testing.T) {
+		applier := &MockClusterApplier{}
+		ex := &ExecutorSchema{Log: log, Opts: opts, ClusterApplier: applier}
+		err := ex.applyClusterResources(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.True(t, applier.Applied)
+	})
+	t.Run("Testing ExecutorApplyClusterResources - mirror failed : should not apply", func(t *testing.T) {
+		applier := &MockClusterApplier{}
+		ex := &ExecutorSchema{Log: log, Opts: opts, ClusterApplier: applier}
+		err := ex.applyClusterResources(context.Background(), fmt.Errorf("forced batch error"))
+		assert.NoError(t, err)
+		assert.False(t, applier.Applied)
+	})
+	t.Run("Testing ExecutorApplyClusterResources - not requested : should not apply", func(t *testing.T) {
+		applier := &MockClusterApplier{}
+		ex := &ExecutorSchema{Log: log, Opts: &mirror.CopyOptions{Global: &mirror.GlobalOptions{}}, ClusterApplier: applier}
+		err := ex.applyClusterResources(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.False(t, applier.Applied)
+	})
+	t.Run("Testing ExecutorApplyClusterResources - apply fails : should return the error", func(t *testing.T) {
+		applier := &MockClusterApplier{Fail: true}
+		ex := &ExecutorSchema{Log: log, Opts: opts, ClusterApplier: applier}
+		err := ex.applyClusterResources(context.Background(), nil)
+		assert.Error(t, err)
+	})
+}
+
 // setup mocks
 
 type Mirror struct {
@@ -968,6 +1053,11 @@ type MockMirrorUnArchiver struct {
 
 type MockClusterResources struct{}
 
+type MockClusterApplier struct {
+	Fail    bool
+	Applied bool
+}
+
 type MockMakeDir struct {
 	Fail bool
 	Dir  string
@@ -1022,6 +1112,14 @@ func (o MockClusterResources) ClusterCatalogGenerator(allRelatedImages []v2alpha
 	return nil
 }
 
+func (o MockClusterResources) ImagePolicyGenerator(allRelatedImages []v2alpha1.CopyImageSchema, forceRepositoryScope bool) error {
+	return nil
+}
+
+func (o MockClusterResources) KustomizationGenerator() error {
+	return nil
+}
+
 func (o Batch) Worker(ctx context.Context, collectorSchema v2alpha1.CollectorSchema, opts mirror.CopyOptions) (v2alpha1.CollectorSchema, error) {
 	copiedImages := v2alpha1.CollectorSchema{
 		AllImages:             []v2alpha1.CopyImageSchema{},
@@ -1157,3 +1255,11 @@ func (l *LogMock) Warn(msg string, val ...interface{}) {
 }
 func (l *LogMock) Level(level string) { l.level = level }
 func (l *LogMock) GetLevel() string   { return l.level }
+
+func (o *MockClusterApplier) Apply(ctx context.Context) error {
+	if o.Fail {
+		return fmt.Errorf("forced apply error")
+	}
+	o.Applied = true
+	return nil
+}
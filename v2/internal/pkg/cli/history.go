@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/history"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+type HistorySchema struct {
+	Log    clog.PluggableLoggerInterface
+	Opts   *mirror.CopyOptions
+	Output string
+	Blobs  bool
+	// workingDir is the working-dir of the workspace, holding the history
+	workingDir string
+}
+
+// NewHistoryCommand - setup the 'history' sub command and its 'ls', 'show', 'rollback' and 'not-delivered' sub commands
+func NewHistoryCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Inspect and fix the history of the archives built by mirrorToDisk in a workspace",
+		Long: templates.LongDesc(`
+			Each mirrorToDisk run records a history snapshot in the working-dir of its workspace: the blobs of
+			all the archives built so far. The next archive only includes the blobs which are not in the latest
+			snapshot. When an archive is lost in transit, roll back the history, or mark the archive as not
+			delivered, so that the next archive includes its blobs again.
+		`),
+	}
+	cmd.AddCommand(newHistoryListCommand(log, opts))
+	cmd.AddCommand(newHistoryShowCommand(log, opts))
+	cmd.AddCommand(newHistoryRollbackCommand(log, opts))
+	cmd.AddCommand(newHistoryNotDeliveredCommand(log, opts))
+	return cmd
+}
+
+// newHistoryListCommand - setup the 'history ls' sub command
+func newHistoryListCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &HistorySchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the history snapshots, with their dates and blob counts",
+		Example: templates.Examples(`
+			# List the history snapshots of a workspace
+			oc-mirror history ls --workspace file:///home/<user>/oc-mirror/mirror1 --v2
+		`),
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(); err != nil {
+				return err
+			}
+			snapshots, err := history.ListSnapshots(ex.workingDir)
+			if err != nil {
+				return err
+			}
+			if ex.Output == outputJSON {
+				return writeJSON(cmd.OutOrStdout(), snapshots)
+			}
+			return writeSnapshotsTable(cmd.OutOrStdout(), snapshots)
+		},
+	}
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or a table when not set")
+	return cmd
+}
+
+// newHistoryShowCommand - setup the 'history show' sub command
+func newHistoryShowCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &HistorySchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "show <snapshot>",
+		Short: "Show the images and blobs a history snapshot added: the content of its archive",
+		Example: templates.Examples(`
+			# Show the images a snapshot added, with their blob counts
+			oc-mirror history show 2024-11-05T10:12:44Z --workspace file:///home/<user>/oc-mirror/mirror1 --v2
+
+			# Show the blobs a snapshot added
+			oc-mirror history show 2024-11-05T10:12:44Z --blobs --workspace file:///home/<user>/oc-mirror/mirror1 --v2
+		`),
+		Args:              cobra.ExactArgs(1),
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(); err != nil {
+				return err
+			}
+			diff, err := history.ShowSnapshot(ex.workingDir, args[0])
+			if err != nil {
+				return err
+			}
+			if ex.Output == outputJSON {
+				return writeJSON(cmd.OutOrStdout(), diff)
+			}
+			return ex.writeSnapshotDiffTable(cmd.OutOrStdout(), diff)
+		},
+	}
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or a table when not set")
+	cmd.Flags().BoolVar(&ex.Blobs, "blobs", false, "List the blobs added, instead of the images")
+	return cmd
+}
+
+// newHistoryRollbackCommand - setup the 'history rollback' sub command
+func newHistoryRollbackCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &HistorySchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "rollback <snapshot>",
+		Short: "Remove the history snapshots after a snapshot: the next archive includes the blobs of their archives",
+		Example: templates.Examples(`
+			# The archives built after 2024-11-05T10:12:44Z were lost: include their blobs in the next archive
+			oc-mirror history rollback 2024-11-05T10:12:44Z --workspace file:///home/<user>/oc-mirror/mirror1 --v2
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(); err != nil {
+				return err
+			}
+			removed, err := history.Rollback(ex.workingDir, args[0])
+			for _, name := range removed {
+				ex.Log.Info("history snapshot %s removed", name)
+			}
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				ex.Log.Info("%s is the latest history snapshot: nothing to roll back", args[0])
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// newHistoryNotDeliveredCommand - setup the 'history not-delivered' sub command
+func newHistoryNotDeliveredCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &HistorySchema{Log: log, Opts: opts}
+	cmd := &cobra.Command{
+		Use:   "not-delivered <snapshot>",
+		Short: "Mark the archive of a history snapshot as not delivered: the next archive includes its blobs again",
+		Example: templates.Examples(`
+			# The archive built at 2024-11-05T10:12:44Z was lost, but not the next ones
+			oc-mirror history not-delivered 2024-11-05T10:12:44Z --workspace file:///home/<user>/oc-mirror/mirror1 --v2
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.complete(); err != nil {
+				return err
+			}
+			blobs, err := history.MarkNotDelivered(ex.workingDir, args[0])
+			if err != nil {
+				return err
+			}
+			ex.Log.Info("archive of %s marked as not delivered: its %d blobs will be included in the next archive", args[0], len(blobs))
+			return nil
+		},
+	}
+	return cmd
+}
+
+// Validate - cobra validation
+func (o HistorySchema) Validate() error {
+	if o.Output != "" && o.Output != outputJSON {
+		return fmt.Errorf("invalid --output %q: only json is supported", o.Output)
+	}
+	if len(o.Opts.Global.WorkingDir) == 0 {
+		return fmt.Errorf("the --workspace flag is mandatory")
+	}
+	if !strings.HasPrefix(o.Opts.Global.WorkingDir, fileProtocol) {
+		return fmt.Errorf("when --workspace is used, it must have file:// prefix")
+	}
+	return nil
+}
+
+// complete validates the flags, and sets up the working-dir of the workspace
+func (o *HistorySchema) complete() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	o.Log.Level(o.Opts.Global.LogLevel)
+	o.workingDir = filepath.Join(strings.TrimPrefix(o.Opts.Global.WorkingDir, fileProtocol), workingDir)
+	return nil
+}
+
+// writeSnapshotsTable writes the snapshots, named after their dates, from the oldest to the latest
+func writeSnapshotsTable(out io.Writer, snapshots []history.Snapshot) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SNAPSHOT\tBLOBS\tADDED BLOBS\tIMAGES\tNOT DELIVERED")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%t\n", s.Name, s.Blobs, s.AddedBlobs, s.Images, s.NotDelivered)
+	}
+	return w.Flush()
+}
+
+// writeSnapshotDiffTable writes the images the snapshot added, with their blob counts, or its blobs
+// when --blobs is set or the images were not recorded
+func (o HistorySchema) writeSnapshotDiffTable(out io.Writer, diff history.SnapshotDiff) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if o.Blobs || len(diff.Images) == 0 {
+		fmt.Fprintln(w, "BLOB")
+		for _, blob := range diff.Blobs {
+			fmt.Fprintln(w, blob)
+		}
+		return w.Flush()
+	}
+	images := make([]string, 0, len(diff.Images))
+	for img := range diff.Images {
+		images = append(images, img)
+	}
+	sort.Strings(images)
+	fmt.Fprintln(w, "IMAGE\tADDED BLOBS")
+	for _, img := range images {
+		fmt.Fprintf(w, "%s\t%d\n", img, len(diff.Images[img]))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/history"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+func TestHistoryValidate(t *testing.T) {
+	type testCase struct {
+		caseName      string
+		workspace     string
+		output        string
+		expectedError string
+	}
+	testCases := []testCase{
+		{
+			caseName:  "Testing HistorySchema.Validate - workspace : should pass",
+			workspace: "file:///home/user/oc-mirror/mirror1",
+			output:    "json",
+		},
+		{
+			caseName:      "Testing HistorySchema.Validate - no workspace : should fail",
+			expectedError: "the --workspace flag is mandatory",
+		},
+		{
+			caseName:      "Testing HistorySchema.Validate - workspace without file:// : should fail",
+			workspace:     "/home/user/oc-mirror/mirror1",
+			expectedError: "when --workspace is used, it must have file:// prefix",
+		},
+		{
+			caseName:      "Testing HistorySchema.Validate - yaml output : should fail",
+			workspace:     "file:///home/user/oc-mirror/mirror1",
+			output:        "yaml",
+			expectedError: `invalid --output "yaml": only json is supported`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(t *testing.T) {
+			schema := HistorySchema{
+				Log:    clog.New("error"),
+				Opts:   &mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: testCase.workspace}},
+				Output: testCase.output,
+			}
+			err := schema.Validate()
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func TestHistoryWriteTables(t *testing.T) {
+	t.Run("Testing writeSnapshotsTable : should write the snapshots", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, writeSnapshotsTable(&out, []history.Snapshot{
+			{Name: "2024-01-01T00:00:00Z", Blobs: 2, AddedBlobs: 2, Images: 1},
+			{Name: "2024-02-01T00:00:00Z", Blobs: 2, AddedBlobs: 1, NotDelivered: true},
+		}))
+		assert.Equal(t, "SNAPSHOT              BLOBS  ADDED BLOBS  IMAGES  NOT DELIVERED\n"+
+			"2024-01-01T00:00:00Z  2      2            1       false\n"+
+			"2024-02-01T00:00:00Z  2      1            0       true\n", out.String())
+	})
+
+	diff := history.SnapshotDiff{
+		Snapshot: "2024-01-01T00:00:00Z",
+		Blobs:    []string{"sha256:a", "sha256:b"},
+		Images:   map[string][]string{"docker://registry.redhat.io/ubi9/ubi:latest": {"sha256:b"}, "docker://registry.redhat.io/ubi8/ubi:latest": {"sha256:a"}},
+	}
+
+	t.Run("Testing writeSnapshotDiffTable : should write the images added", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, HistorySchema{}.writeSnapshotDiffTable(&out, diff))
+		assert.Equal(t, "IMAGE                                        ADDED BLOBS\n"+
+			"docker://registry.redhat.io/ubi8/ubi:latest  1\n"+
+			"docker://registry.redhat.io/ubi9/ubi:latest  1\n", out.String())
+	})
+
+	t.Run("Testing writeSnapshotDiffTable - blobs : should write the blobs added", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, HistorySchema{Blobs: true}.writeSnapshotDiffTable(&out, diff))
+		assert.Equal(t, "BLOB\nsha256:a\nsha256:b\n", out.String())
+	})
+}
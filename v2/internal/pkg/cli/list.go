@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+const (
+	// catalogs pulled by the list command are kept under the cache directory, to be reused by the next runs
+	listWorkingDir string = "list-working-dir"
+	outputJSON     string = "json"
+)
+
+type ListOperatorsSchema struct {
+	Log     clog.PluggableLoggerInterface
+	Opts    *mirror.CopyOptions
+	Catalog string
+	Package string
+	Channel string
+	Output  string
+}
+
+// NewListCommand - setup the 'list' sub command and its 'operators' sub command
+func NewListCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List available content to author an imageset configuration",
+	}
+	cmd.AddCommand(NewListOperatorsCommand(log, opts))
+	return cmd
+}
+
+// NewListOperatorsCommand - setup the 'list operators' sub command
+func NewListOperatorsCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &ListOperatorsSchema{
+		Log:  log,
+		Opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "operators",
+		Short: "List the packages, channels and bundles of an operator catalog",
+		Example: templates.Examples(`
+			# List all operator packages in a catalog
+			oc-mirror list operators --catalog registry.redhat.io/redhat/redhat-operator-index:v4.16 --v2
+
+			# List all channels, with their head and versions, of an operator package
+			oc-mirror list operators --catalog registry.redhat.io/redhat/redhat-operator-index:v4.16 --package aws-load-balancer-operator --v2
+
+			# List the bundles of a channel, in JSON
+			oc-mirror list operators --catalog oci:///home/<user>/catalogs/redhat-operator-index --package aws-load-balancer-operator --channel stable-v1 --output json --v2
+		`),
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.Validate(); err != nil {
+				return err
+			}
+			if err := ex.Complete(); err != nil {
+				return err
+			}
+			return ex.Run(cmd)
+		},
+	}
+	cmd.Flags().StringVar(&ex.Catalog, "catalog", "", "Catalog image to list, with a docker:// (default) or oci:// reference")
+	cmd.Flags().StringVar(&ex.Package, "package", "", "List the channels of this package only")
+	cmd.Flags().StringVar(&ex.Channel, "channel", "", "List the bundles of this channel of the package only, requires --package")
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or a table when not set")
+	return cmd
+}
+
+// logsToStderr is the PersistentPreRunE of the commands writing their result to stdout:
+// the logs go to stderr, so that the result can be piped
+func logsToStderr(cmd *cobra.Command, args []string) error {
+	log.SetOutput(os.Stderr)
+	if root := cmd.Root(); root.PersistentPreRunE != nil {
+		return root.PersistentPreRunE(cmd, args)
+	}
+	return nil
+}
+
+// Validate - cobra validation
+func (o ListOperatorsSchema) Validate() error {
+	if o.Catalog == "" {
+		return fmt.Errorf("the --catalog flag is mandatory")
+	}
+	if o.Channel != "" && o.Package == "" {
+		return fmt.Errorf("the --channel flag can only be used alongside the --package flag")
+	}
+	if o.Output != "" && o.Output != outputJSON {
+		return fmt.Errorf("invalid --output %q: only json is supported", o.Output)
+	}
+	if len(o.Opts.Global.WorkingDir) > 0 && !strings.HasPrefix(o.Opts.Global.WorkingDir, fileProtocol) {
+		return fmt.Errorf("when --workspace is used, it must have file:// prefix")
+	}
+	return nil
+}
+
+// Complete - cobra complete
+func (o *ListOperatorsSchema) Complete() error {
+	o.Log.Level(o.Opts.Global.LogLevel)
+	return completeCatalogWorkingDir(o.Opts)
+}
+
+// completeCatalogWorkingDir sets up the working directory where the commands reading catalogs
+// without mirroring them pull the catalogs: the working-dir of the workspace when --workspace
+// is used, so that the catalogs of the previous runs are reused, or a directory under the cache directory.
+func completeCatalogWorkingDir(opts *mirror.CopyOptions) error {
+	if len(opts.Global.WorkingDir) > 0 {
+		opts.Global.WorkingDir = filepath.Join(strings.TrimPrefix(opts.Global.WorkingDir, fileProtocol), workingDir)
+	} else {
+		opts.Global.WorkingDir = filepath.Join(opts.Global.CacheDir, ocmirrorRelativePath, listWorkingDir)
+	}
+	if err := os.MkdirAll(opts.Global.WorkingDir, 0755); err != nil {
+		return fmt.Errorf("create working directory: %w", err)
+	}
+	opts.Mode = mirror.MirrorToDisk
+	opts.Function = string(mirror.CopyMode)
+	opts.LocalStorageFQDN = "localhost:" + strconv.Itoa(int(opts.Global.Port))
+	return nil
+}
+
+// Run - lists the catalog content
+func (o *ListOperatorsSchema) Run(cmd *cobra.Command) error {
+	listing, err := operator.ListCatalog(cmd.Context(), o.Log, *o.Opts, mirror.New(mirror.NewMirrorCopy(), nil), manifest.New(o.Log), o.Catalog, o.Package, o.Channel)
+	if err != nil {
+		return err
+	}
+	if o.Output == outputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(listing)
+	}
+	return o.writeTable(cmd.OutOrStdout(), listing)
+}
+
+// writeTable writes the packages of the listing, the channels of the package or the bundles of the channel
+func (o ListOperatorsSchema) writeTable(out io.Writer, listing operator.CatalogListing) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	switch {
+	case o.Channel != "":
+		fmt.Fprintln(w, "BUNDLE\tVERSION\tRELATED IMAGES")
+		for _, pkg := range listing.Packages {
+			for _, ch := range pkg.Channels {
+				for _, b := range ch.Bundles {
+					fmt.Fprintf(w, "%s\t%s\t%d\n", b.Name, b.Version, b.RelatedImages)
+				}
+			}
+		}
+	case o.Package != "":
+		fmt.Fprintln(w, "CHANNEL\tDEFAULT\tHEAD\tVERSIONS")
+		for _, pkg := range listing.Packages {
+			for _, ch := range pkg.Channels {
+				versions := make([]string, 0, len(ch.Bundles))
+				for _, b := range ch.Bundles {
+					versions = append(versions, b.Version)
+				}
+				fmt.Fprintf(w, "%s\t%t\t%s\t%s\n", ch.Name, ch.Name == pkg.DefaultChannel, ch.Head, strings.Join(versions, ","))
+			}
+		}
+	default:
+		fmt.Fprintln(w, "PACKAGE\tDEFAULT CHANNEL\tCHANNELS")
+		for _, pkg := range listing.Packages {
+			channels := make([]string, 0, len(pkg.Channels))
+			for _, ch := range pkg.Channels {
+				channels = append(channels, ch.Name)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", pkg.Name, pkg.DefaultChannel, strings.Join(channels, ","))
+		}
+	}
+	return w.Flush()
+}
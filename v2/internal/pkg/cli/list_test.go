@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+func TestListOperatorsValidate(t *testing.T) {
+	type testCase struct {
+		caseName      string
+		schema        ListOperatorsSchema
+		expectedError string
+	}
+	opts := &mirror.CopyOptions{Global: &mirror.GlobalOptions{}}
+	testCases := []testCase{
+		{
+			caseName: "Testing ListOperatorsSchema.Validate - catalog and package : should pass",
+			schema:   ListOperatorsSchema{Opts: opts, Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.16", Package: "foo", Output: "json"},
+		},
+		{
+			caseName:      "Testing ListOperatorsSchema.Validate - no catalog : should fail",
+			schema:        ListOperatorsSchema{Opts: opts},
+			expectedError: "the --catalog flag is mandatory",
+		},
+		{
+			caseName:      "Testing ListOperatorsSchema.Validate - channel without package : should fail",
+			schema:        ListOperatorsSchema{Opts: opts, Catalog: "oci:///catalog", Channel: "stable"},
+			expectedError: "the --channel flag can only be used alongside the --package flag",
+		},
+		{
+			caseName:      "Testing ListOperatorsSchema.Validate - yaml output : should fail",
+			schema:        ListOperatorsSchema{Opts: opts, Catalog: "oci:///catalog", Output: "yaml"},
+			expectedError: `invalid --output "yaml": only json is supported`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(t *testing.T) {
+			testCase.schema.Log = clog.New("error")
+			err := testCase.schema.Validate()
+			if testCase.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func TestListOperatorsWriteTable(t *testing.T) {
+	listing := operator.CatalogListing{
+		Catalog: "oci:///catalog",
+		Packages: []operator.PackageListing{{
+			Name:           "foo",
+			DefaultChannel: "stable",
+			Channels: []operator.ChannelListing{
+				{Name: "fast", Head: "foo.v2.0.0", Bundles: []operator.BundleListing{{Name: "foo.v2.0.0", Version: "2.0.0", RelatedImages: 3}}},
+				{Name: "stable", Head: "foo.v1.1.0", Bundles: []operator.BundleListing{
+					{Name: "foo.v1.0.0", Version: "1.0.0", RelatedImages: 2},
+					{Name: "foo.v1.1.0", Version: "1.1.0", RelatedImages: 2},
+				}},
+			},
+		}},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, ListOperatorsSchema{}.writeTable(&out, listing))
+	assert.Equal(t, "PACKAGE  DEFAULT CHANNEL  CHANNELS\nfoo      stable           fast,stable\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, ListOperatorsSchema{Package: "foo"}.writeTable(&out, listing))
+	assert.Equal(t, "CHANNEL  DEFAULT  HEAD        VERSIONS\nfast     false    foo.v2.0.0  2.0.0\nstable   true     foo.v1.1.0  1.0.0,1.1.0\n", out.String())
+}
@@ -5,13 +5,15 @@ import (
 	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
 )
 
-type MockDelete struct{}
+type MockDelete struct {
+	deleteList v2alpha1.DeleteImageList
+}
 
 func (o MockDelete) ReadDeleteMetaData() (v2alpha1.DeleteImageList, error) {
-	return v2alpha1.DeleteImageList{}, nil
+	return o.deleteList, nil
 }
 
-func (o MockDelete) WriteDeleteMetaData([]v2alpha1.CopyImageSchema) error {
+func (o MockDelete) WriteDeleteMetaData([]v2alpha1.CopyImageSchema, []v2alpha1.CopyImageSchema, []v2alpha1.DeleteCatalog) error {
 	return nil
 }
 
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/config"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+type PreviewSchema struct {
+	Log       clog.PluggableLoggerInterface
+	Opts      *mirror.CopyOptions
+	Config    v2alpha1.ImageSetConfiguration
+	Output    string
+	WithSizes bool
+}
+
+// NewPreviewCommand - setup the 'preview' sub command
+func NewPreviewCommand(log clog.PluggableLoggerInterface, opts *mirror.CopyOptions) *cobra.Command {
+	ex := &PreviewSchema{
+		Log:  log,
+		Opts: opts,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Preview the operator content selected by an imageset configuration, without mirroring it",
+		Example: templates.Examples(`
+			# Preview the packages, channels and bundles selected by the operator filters
+			oc-mirror preview -c ./isc.yaml --v2
+
+			# Compare with the catalogs filtered with the same filters by the last mirroring run of a workspace, in JSON
+			oc-mirror preview -c ./isc.yaml --workspace file:///home/<user>/oc-mirror/mirror1 --output json --v2
+		`),
+		PersistentPreRunE: logsToStderr,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ex.Validate(); err != nil {
+				return err
+			}
+			if err := ex.Complete(); err != nil {
+				return err
+			}
+			return ex.Run(cmd)
+		},
+	}
+	cmd.Flags().StringVarP(&ex.Output, "output", "o", "", "Output format: json, or tables when not set")
+	cmd.Flags().BoolVar(&ex.WithSizes, "sizes", true, "Compute the compressed size of the related images from their manifests, for all architectures")
+	return cmd
+}
+
+// Validate - cobra validation
+func (o PreviewSchema) Validate() error {
+	if len(o.Opts.Global.ConfigPath) == 0 {
+		return fmt.Errorf("use the --config flag it is mandatory")
+	}
+	if o.Output != "" && o.Output != outputJSON {
+		return fmt.Errorf("invalid --output %q: only json is supported", o.Output)
+	}
+	if len(o.Opts.Global.WorkingDir) > 0 && !strings.HasPrefix(o.Opts.Global.WorkingDir, fileProtocol) {
+		return fmt.Errorf("when --workspace is used, it must have file:// prefix")
+	}
+	return nil
+}
+
+// Complete - cobra complete
+func (o *PreviewSchema) Complete() error {
+	o.Log.Level(o.Opts.Global.LogLevel)
+	cfg, err := config.ReadConfig(o.Opts.Global.ConfigPath, v2alpha1.ImageSetConfigurationKind)
+	if err != nil {
+		return err
+	}
+	o.Config = cfg.(v2alpha1.ImageSetConfiguration)
+	if len(o.Config.Mirror.Operators) == 0 {
+		return fmt.Errorf("no operator catalog to preview in %s", o.Opts.Global.ConfigPath)
+	}
+	return completeCatalogWorkingDir(o.Opts)
+}
+
+// Run - filters the catalogs and writes the selected content
+func (o *PreviewSchema) Run(cmd *cobra.Command) error {
+	previews, err := operator.PreviewFilters(cmd.Context(), o.Log, o.Config, *o.Opts, mirror.New(mirror.NewMirrorCopy(), nil), manifest.New(o.Log), o.WithSizes)
+	if err != nil {
+		return err
+	}
+	if o.Output == outputJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(previews)
+	}
+	for _, preview := range previews {
+		if err := o.writePreview(cmd.OutOrStdout(), preview); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePreview writes a table of the channels selected for each package of the catalog,
+// followed by the changes since the previous run
+func (o PreviewSchema) writePreview(out io.Writer, preview operator.CatalogPreview) error {
+	fmt.Fprintf(out, "%s: %d related images%s\n", preview.Catalog, preview.RelatedImages, o.size(preview.CompressedSize))
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tCHANNEL\tDEFAULT\tHEAD\tVERSIONS\tRELATED IMAGES")
+	for _, pkg := range preview.Packages {
+		for i, ch := range pkg.Channels {
+			versions := make([]string, 0, len(ch.Bundles))
+			for _, b := range ch.Bundles {
+				versions = append(versions, b.Version)
+			}
+			images := ""
+			if i == 0 {
+				images = fmt.Sprintf("%d%s", pkg.RelatedImages, o.size(pkg.CompressedSize))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n", pkg.Name, ch.Name, ch.Name == pkg.DefaultChannel, ch.Head, strings.Join(versions, ","), images)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	switch {
+	case preview.Diff == nil:
+		fmt.Fprintln(out, "no catalog filtered by a previous run")
+	case preview.Diff.IsEmpty():
+		fmt.Fprintln(out, "no change since the previous run")
+	default:
+		fmt.Fprintln(out, "changes since the previous run:")
+		writeCatalogDiff(out, *preview.Diff)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+func (o PreviewSchema) size(size int64) string {
+	if !o.WithSizes {
+		return ""
+	}
+	return " (" + units.HumanSize(float64(size)) + ")"
+}
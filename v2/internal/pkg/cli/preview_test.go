@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+func TestPreviewValidate(t *testing.T) {
+	ex := PreviewSchema{Opts: &mirror.CopyOptions{Global: &mirror.GlobalOptions{}}}
+	assert.EqualError(t, ex.Validate(), "use the --config flag it is mandatory")
+
+	ex.Opts.Global.ConfigPath = "isc.yaml"
+	assert.NoError(t, ex.Validate())
+
+	ex.Opts.Global.WorkingDir = "/home/user/mirror1"
+	assert.EqualError(t, ex.Validate(), "when --workspace is used, it must have file:// prefix")
+}
+
+func TestPreviewWritePreview(t *testing.T) {
+	preview := operator.CatalogPreview{
+		Catalog:       "oci:///catalog",
+		RelatedImages: 3,
+		Packages: []operator.PackagePreview{{
+			PackageListing: operator.PackageListing{
+				Name:           "foo",
+				DefaultChannel: "stable",
+				Channels: []operator.ChannelListing{
+					{Name: "stable", Head: "foo.v1.1.0", Bundles: []operator.BundleListing{{Name: "foo.v1.0.0", Version: "1.0.0"}, {Name: "foo.v1.1.0", Version: "1.1.0"}}},
+				},
+			},
+			RelatedImages: 3,
+		}},
+		Diff: &operator.CatalogDiff{AddedBundles: []string{"foo/foo.v1.1.0"}},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, PreviewSchema{}.writePreview(&out, preview))
+	assert.Equal(t, `oci:///catalog: 3 related images
+PACKAGE  CHANNEL  DEFAULT  HEAD        VERSIONS     RELATED IMAGES
+foo      stable   true     foo.v1.1.0  1.0.0,1.1.0  3
+changes since the previous run:
+  + bundle foo/foo.v1.1.0
+
+`, out.String())
+}
@@ -0,0 +1,238 @@
+package clusterresources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+const (
+	fieldManager                 = "oc-mirror"
+	catalogSourceKind            = "CatalogSource"
+	catalogSourceReadyState      = "READY"
+	catalogSourceReadyTimeout    = 5 * time.Minute
+	catalogSourceReadyPollPeriod = 5 * time.Second
+)
+
+// appliedResource is the resource name and scope of the kinds generated under cluster-resources
+type appliedResource struct {
+	resource   string
+	namespaced bool
+}
+
+var appliedResources = map[string]appliedResource{
+	"ImageDigestMirrorSet":    {resource: "imagedigestmirrorsets"},
+	"ImageTagMirrorSet":       {resource: "imagetagmirrorsets"},
+	"ClusterImagePolicy":      {resource: "clusterimagepolicies"},
+	"ImagePolicy":             {resource: "imagepolicies", namespaced: true},
+	catalogSourceKind:         {resource: "catalogsources", namespaced: true},
+	"ClusterCatalog":          {resource: "clustercatalogs"},
+	updateServiceResourceKind: {resource: "updateservices", namespaced: true},
+	configMapKind:             {resource: "configmaps", namespaced: true},
+}
+
+type ClusterResourcesApplier struct {
+	Log              clog.PluggableLoggerInterface
+	WorkingDir       string
+	Client           dynamic.Interface
+	DefaultNamespace string
+	ReadyTimeout     time.Duration
+	PollPeriod       time.Duration
+}
+
+// NewApplier creates an applier for the cluster targeted by kubeconfig.
+// When kubeconfig is empty, the usual loading rules apply ($KUBECONFIG, ~/.kube/config, in-cluster).
+func NewApplier(log clog.PluggableLoggerInterface, workingDir, kubeconfig string) (ApplierInterface, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %w", err)
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create kubernetes client: %w", err)
+	}
+	return &ClusterResourcesApplier{
+		Log:              log,
+		WorkingDir:       workingDir,
+		Client:           client,
+		DefaultNamespace: namespace,
+		ReadyTimeout:     catalogSourceReadyTimeout,
+		PollPeriod:       catalogSourceReadyPollPeriod,
+	}, nil
+}
+
+// Apply server-side applies all the resources generated under cluster-resources,
+// with the oc-mirror field manager, then waits for the CatalogSources to become READY.
+// The fields managed by other field managers are not taken over: they are reported as conflicts.
+func (o *ClusterResourcesApplier) Apply(ctx context.Context) error {
+	o.Log.Info(emoji.Rocket + " Applying cluster resources...")
+	objs, err := o.loadClusterResources()
+	if err != nil {
+		return err
+	}
+
+	applyErrs := []string{}
+	catalogSources := []*unstructured.Unstructured{}
+	for _, obj := range objs {
+		applied, err := o.applyObject(ctx, obj)
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				err = fmt.Errorf("%w: the conflicting fields are managed by another field manager, update them manually", err)
+			}
+			o.Log.Error("unable to apply %s %s: %v", obj.GetKind(), obj.GetName(), err)
+			applyErrs = append(applyErrs, fmt.Sprintf("%s %s: %v", obj.GetKind(), obj.GetName(), err))
+			continue
+		}
+		if !applied {
+			continue
+		}
+		o.Log.Info("%s %s applied", obj.GetKind(), obj.GetName())
+		if obj.GetKind() == catalogSourceKind {
+			catalogSources = append(catalogSources, obj)
+		}
+	}
+	if len(applyErrs) > 0 {
+		return fmt.Errorf("unable to apply cluster resources: %s", strings.Join(applyErrs, "; "))
+	}
+	return o.waitForCatalogSources(ctx, catalogSources)
+}
+
+// loadClusterResources reads the yaml files of cluster-resources.
+// The json files are skipped: they duplicate the yaml ones.
+func (o *ClusterResourcesApplier) loadClusterResources() ([]*unstructured.Unstructured, error) {
+	crPath := filepath.Join(o.WorkingDir, clusterResourcesDir)
+	files, err := os.ReadDir(crPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cluster resources: %w", err)
+	}
+	names := []string{}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || f.Name() == kustomizationFilename || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+
+	objs := []*unstructured.Unstructured{}
+	for _, name := range names {
+		decoded, err := decodeAllObjects(filepath.Join(crPath, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range decoded {
+			objs = append(objs, flattenList(obj)...)
+		}
+	}
+	return objs, nil
+}
+
+func flattenList(obj map[string]interface{}) []*unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: obj}
+	if !u.IsList() {
+		return []*unstructured.Unstructured{u}
+	}
+	objs := []*unstructured.Unstructured{}
+	items, _ := obj["items"].([]interface{})
+	for _, item := range items {
+		if itemObj, ok := item.(map[string]interface{}); ok {
+			objs = append(objs, flattenList(itemObj)...)
+		}
+	}
+	return objs
+}
+
+// applyObject server-side applies obj. It returns false when the kind is not one generated by oc-mirror.
+func (o *ClusterResourcesApplier) applyObject(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	ar, ok := appliedResources[obj.GetKind()]
+	if !ok {
+		o.Log.Warn("skipping %s %s: kind not supported", obj.GetKind(), obj.GetName())
+		return false, nil
+	}
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		return false, err
+	}
+	gvr := gv.WithResource(ar.resource)
+	options := metav1.ApplyOptions{FieldManager: fieldManager}
+	if !ar.namespaced {
+		_, err = o.Client.Resource(gvr).Apply(ctx, obj.GetName(), obj, options)
+		return err == nil, err
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(o.DefaultNamespace)
+	}
+	_, err = o.Client.Resource(gvr).Namespace(obj.GetNamespace()).Apply(ctx, obj.GetName(), obj, options)
+	return err == nil, err
+}
+
+// waitForCatalogSources polls the CatalogSources until they are all READY, and reports their state
+func (o *ClusterResourcesApplier) waitForCatalogSources(ctx context.Context, catalogSources []*unstructured.Unstructured) error {
+	if len(catalogSources) == 0 {
+		return nil
+	}
+	o.Log.Info(emoji.Eyes+" Waiting for %d CatalogSource(s) to be %s...", len(catalogSources), catalogSourceReadyState)
+	states := map[string]string{}
+	err := wait.PollUntilContextTimeout(ctx, o.PollPeriod, o.ReadyTimeout, true, func(ctx context.Context) (bool, error) {
+		ready := true
+		for _, cs := range catalogSources {
+			gv, err := schema.ParseGroupVersion(cs.GetAPIVersion())
+			if err != nil {
+				return false, err
+			}
+			current, err := o.Client.Resource(gv.WithResource(appliedResources[catalogSourceKind].resource)).Namespace(cs.GetNamespace()).Get(ctx, cs.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			state, _, _ := unstructured.NestedString(current.Object, "status", "connectionState", "lastObservedState")
+			states[cs.GetNamespace()+"/"+cs.GetName()] = state
+			if state != catalogSourceReadyState {
+				ready = false
+			}
+		}
+		return ready, nil
+	})
+
+	notReady := []string{}
+	for _, cs := range catalogSources {
+		key := cs.GetNamespace() + "/" + cs.GetName()
+		state := states[key]
+		if state == "" {
+			state = "UNKNOWN"
+		}
+		o.Log.Info("CatalogSource %s: %s", key, state)
+		if state != catalogSourceReadyState {
+			notReady = append(notReady, key)
+		}
+	}
+	if err != nil {
+		if len(notReady) > 0 {
+			return fmt.Errorf("CatalogSource(s) not %s: %s: %w", catalogSourceReadyState, strings.Join(notReady, ", "), err)
+		}
+		return err
+	}
+	return nil
+}
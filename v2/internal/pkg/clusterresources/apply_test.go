@@ -0,0 +1,149 @@
+package clusterresources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+const (
+	catalogSourceYaml = `apiVersion: operators.coreos.com/v1alpha1
+kind: CatalogSource
+metadata:
+  name: cs-redhat-operator-index-v4-15
+  namespace: openshift-marketplace
+spec:
+  image: myregistry/redhat/redhat-operator-index:v4.15
+  sourceType: grpc
+`
+	updateServiceYaml = `apiVersion: updateservice.operator.openshift.io/v1
+kind: UpdateService
+metadata:
+  name: update-service-oc-mirror
+spec:
+  graphDataImage: myregistry/openshift/graph-image:latest
+  releases: myregistry/openshift/release-images
+  replicas: 2
+`
+)
+
+// newFakeApplyClient returns a fake dynamic client supporting server-side apply:
+// the fake object tracker only applies to existing objects.
+// When catalogSourceState is set, it is reported as the state of the applied CatalogSources.
+func newFakeApplyClient(catalogSourceState string, applied map[string]bool) *dynamicfake.FakeDynamicClient {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchActionImpl)
+		if patchAction.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(patchAction.GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		if obj.GetKind() == catalogSourceKind && catalogSourceState != "" {
+			if err := unstructured.SetNestedField(obj.Object, catalogSourceState, "status", "connectionState", "lastObservedState"); err != nil {
+				return true, nil, err
+			}
+		}
+		applied[patchAction.GetResource().Resource+"/"+patchAction.GetNamespace()+"/"+patchAction.GetName()] = true
+		gvr := patchAction.GetResource()
+		err := client.Tracker().Create(gvr, obj, patchAction.GetNamespace())
+		if apierrors.IsAlreadyExists(err) {
+			err = client.Tracker().Update(gvr, obj, patchAction.GetNamespace())
+		}
+		return true, obj, err
+	})
+	return client
+}
+
+func TestClusterResourcesApplier(t *testing.T) {
+	log := clog.New("trace")
+
+	writeClusterResources := func(t *testing.T, workingDir string) {
+		crPath := filepath.Join(workingDir, clusterResourcesDir)
+		assert.NoError(t, os.MkdirAll(crPath, 0755))
+		cr := &ClusterResourcesGenerator{
+			Log:              log,
+			WorkingDir:       workingDir,
+			LocalStorageFQDN: "localhost:55000",
+		}
+		assert.NoError(t, cr.IDMS_ITMSGenerator(imageListMixed, false))
+		assert.NoError(t, os.WriteFile(filepath.Join(crPath, "cs-redhat-operator-index-v4-15.yaml"), []byte(catalogSourceYaml), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(crPath, updateServiceFilename), []byte(updateServiceYaml), 0644))
+		// duplicates of the yaml files, and kustomization: should not be applied
+		assert.NoError(t, os.WriteFile(filepath.Join(crPath, "signature-configmap.json"), []byte(`{"kind":"ConfigMap"}`), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(crPath, kustomizationFilename), []byte("kind: Kustomization\n"), 0644))
+	}
+
+	t.Run("Testing Apply - catalog sources ready : should apply all resources", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		writeClusterResources(t, workingDir)
+		applied := map[string]bool{}
+		client := newFakeApplyClient(catalogSourceReadyState, applied)
+
+		applier := &ClusterResourcesApplier{
+			Log:              log,
+			WorkingDir:       workingDir,
+			Client:           client,
+			DefaultNamespace: "openshift-update-service",
+			ReadyTimeout:     time.Second,
+			PollPeriod:       10 * time.Millisecond,
+		}
+		err := applier.Apply(context.Background())
+		assert.NoError(t, err)
+
+		assert.Contains(t, applied, "imagedigestmirrorsets//idms-operator-0")
+		assert.Contains(t, applied, "imagetagmirrorsets//itms-generic-0")
+		assert.Contains(t, applied, "catalogsources/openshift-marketplace/cs-redhat-operator-index-v4-15")
+		assert.Contains(t, applied, "updateservices/openshift-update-service/update-service-oc-mirror")
+		// 2 IDMS, 2 ITMS, the CatalogSource and the UpdateService: the json duplicate and the kustomization are not applied
+		assert.Len(t, applied, 6)
+
+		gvr := schema.GroupVersionResource{Group: "updateservice.operator.openshift.io", Version: "v1", Resource: "updateservices"}
+		_, err = client.Resource(gvr).Namespace("openshift-update-service").Get(context.Background(), updateServiceResourceName, metav1.GetOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Testing Apply - catalog sources not ready : should fail after timeout", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		writeClusterResources(t, workingDir)
+		client := newFakeApplyClient("CONNECTING", map[string]bool{})
+
+		applier := &ClusterResourcesApplier{
+			Log:              log,
+			WorkingDir:       workingDir,
+			Client:           client,
+			DefaultNamespace: "default",
+			ReadyTimeout:     50 * time.Millisecond,
+			PollPeriod:       10 * time.Millisecond,
+		}
+		err := applier.Apply(context.Background())
+		assert.ErrorContains(t, err, "CatalogSource(s) not READY: openshift-marketplace/cs-redhat-operator-index-v4-15")
+	})
+
+	t.Run("Testing Apply - no cluster resources : should fail", func(t *testing.T) {
+		applier := &ClusterResourcesApplier{
+			Log:        log,
+			WorkingDir: filepath.Join(t.TempDir(), "working-dir"),
+			Client:     newFakeApplyClient("", map[string]bool{}),
+		}
+		err := applier.Apply(context.Background())
+		assert.ErrorContains(t, err, "unable to read cluster resources")
+	})
+}
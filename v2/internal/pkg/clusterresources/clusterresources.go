@@ -15,12 +15,14 @@ import (
 	"unicode"
 
 	confv1 "github.com/openshift/api/config/v1"
+	confv1alpha1 "github.com/openshift/api/config/v1alpha1"
 	cm "github.com/openshift/oc-mirror/v2/internal/pkg/api/kubernetes/core"
 	ofv1 "github.com/openshift/oc-mirror/v2/internal/pkg/api/operator-framework/v1"
 	ofv1alpha1 "github.com/openshift/oc-mirror/v2/internal/pkg/api/operator-framework/v1alpha1"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
 	updateservicev1 "github.com/openshift/oc-mirror/v2/internal/pkg/clusterresources/updateservice/v1"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/history"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
 	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/parser"
@@ -40,8 +42,9 @@ func New(log clog.PluggableLoggerInterface,
 	workingDir string,
 	conf v2alpha1.ImageSetConfiguration,
 	localStorageFQDN string,
+	existingMirrorSetsDir string,
 ) GeneratorInterface {
-	return &ClusterResourcesGenerator{Log: log, WorkingDir: workingDir, Config: conf, LocalStorageFQDN: localStorageFQDN}
+	return &ClusterResourcesGenerator{Log: log, WorkingDir: workingDir, Config: conf, LocalStorageFQDN: localStorageFQDN, ExistingMirrorSetsDir: existingMirrorSetsDir}
 }
 
 type ClusterResourcesGenerator struct {
@@ -49,6 +52,9 @@ type ClusterResourcesGenerator struct {
 	WorkingDir       string
 	Config           v2alpha1.ImageSetConfiguration
 	LocalStorageFQDN string
+	// ExistingMirrorSetsDir is a directory containing the IDMS/ITMS already applied on the cluster
+	// (and optionally the delete-images files of the delete workflow) to merge with the generated ones
+	ExistingMirrorSetsDir string
 }
 
 type (
@@ -56,6 +62,12 @@ type (
 	mirrorCategory            int
 )
 
+// resourceListItem constrains the resources that are written
+// as a multi-document yaml file in cluster-resources
+type resourceListItem interface {
+	confv1.ImageDigestMirrorSet | confv1.ImageTagMirrorSet | confv1alpha1.ClusterImagePolicy | confv1alpha1.ImagePolicy
+}
+
 type categorizedMirrors struct {
 	category mirrorCategory
 	mirrors  map[string][]confv1.ImageMirror
@@ -79,17 +91,42 @@ func (o *ClusterResourcesGenerator) IDMS_ITMSGenerator(allRelatedImages []v2alph
 		return nil
 	}
 
+	scopes, err := o.resolveMirrorScopes(allRelatedImages, forceRepositoryScope)
+	if err != nil {
+		return err
+	}
+	if err := o.writeMirrorScopesReport(scopes); err != nil {
+		o.Log.Warn("unable to write the report of the IDMS/ITMS scopes: %v", err)
+	}
+
 	// byDigestMirrors
-	byDigestMirrors, err := o.generateImageMirrors(allRelatedImages, DigestsOnlyMode, forceRepositoryScope)
+	byDigestMirrors, err := o.generateImageMirrors(allRelatedImages, DigestsOnlyMode, scopes)
 	if err != nil {
 		return err
 	}
 
 	// byTagMirrors
-	byTagMirrors, err := o.generateImageMirrors(allRelatedImages, TagsOnlyMode, forceRepositoryScope)
+	byTagMirrors, err := o.generateImageMirrors(allRelatedImages, TagsOnlyMode, scopes)
 	if err != nil {
 		return err
 	}
+
+	if o.ExistingMirrorSetsDir != "" {
+		o.Log.Info(emoji.PageFacingUp+" Merging with the existing IDMS and ITMS found in %s...", o.ExistingMirrorSetsDir)
+		existing, err := loadExistingMirrorSets(o.ExistingMirrorSetsDir)
+		if err != nil {
+			return err
+		}
+		records, err := history.ReadMirroredImages(o.WorkingDir)
+		if err != nil {
+			return err
+		}
+		if err := existing.setRemaining(records); err != nil {
+			return err
+		}
+		byDigestMirrors = mergeMirrors(byDigestMirrors, existing.byDigestMirrors, existing.deleted)
+		byTagMirrors = mergeMirrors(byTagMirrors, existing.byTagMirrors, existing.deleted)
+	}
 	// if byTagMirrors not empty
 	if len(byDigestMirrors) > 0 {
 		o.Log.Info(emoji.PageFacingUp + " Generating IDMS file...")
@@ -98,7 +135,7 @@ func (o *ClusterResourcesGenerator) IDMS_ITMSGenerator(allRelatedImages []v2alph
 			return err
 		}
 
-		err = writeMirrorSet(idmsList, o.WorkingDir, idmsFileName, o.Log)
+		err = writeResourceList(idmsList, o.WorkingDir, idmsFileName, o.Log)
 		if err != nil {
 			return err
 		}
@@ -112,7 +149,7 @@ func (o *ClusterResourcesGenerator) IDMS_ITMSGenerator(allRelatedImages []v2alph
 		if err != nil {
 			return err
 		}
-		err = writeMirrorSet(itmsList, o.WorkingDir, itmsFileName, o.Log)
+		err = writeResourceList(itmsList, o.WorkingDir, itmsFileName, o.Log)
 		if err != nil {
 			return err
 		}
@@ -151,7 +188,7 @@ func (o *ClusterResourcesGenerator) generateITMS(mirrorsByCategory []categorized
 	return itmsList, nil
 }
 
-func writeMirrorSet[T confv1.ImageDigestMirrorSet | confv1.ImageTagMirrorSet](mirrorSetsList []T, workingDir, fileName string, log clog.PluggableLoggerInterface) error {
+func writeResourceList[T resourceListItem](mirrorSetsList []T, workingDir, fileName string, log clog.PluggableLoggerInterface) error {
 	msFilePath := filepath.Join(workingDir, clusterResourcesDir, fileName)
 	msAggregation := []byte{}
 	var err error
@@ -520,7 +557,9 @@ func (o *ClusterResourcesGenerator) generateIDMS(mirrorsByCategory []categorized
 	return idmsList, nil
 }
 
-func (o *ClusterResourcesGenerator) generateImageMirrors(allRelatedImages []v2alpha1.CopyImageSchema, mode imageMirrorsGeneratorMode, forceRepositoryScope bool) ([]categorizedMirrors, error) {
+// generateImageMirrors returns the mirrors of the images by digest or by tag depending on mode,
+// with the scopes resolved by resolveMirrorScopes for all the images
+func (o *ClusterResourcesGenerator) generateImageMirrors(allRelatedImages []v2alpha1.CopyImageSchema, mode imageMirrorsGeneratorMode, scopes map[repositoryPair]resolvedScope) ([]categorizedMirrors, error) {
 	mirrorsByCategory := make(map[mirrorCategory]categorizedMirrors)
 	for _, relatedImage := range allRelatedImages {
 		if relatedImage.Origin == "" {
@@ -560,14 +599,9 @@ func (o *ClusterResourcesGenerator) generateImageMirrors(allRelatedImages []v2al
 		if !toBeAdded {
 			continue
 		}
-		source := ""
-		mirror := ""
-		if forceRepositoryScope {
-			source = repositoryScope(srcImgSpec)
-			mirror = repositoryScope(dstImgSpec)
-		} else {
-			source, mirror = attemptNamespaceScope(srcImgSpec, dstImgSpec)
-		}
+		scope := scopes[repositoryPair{source: srcImgSpec.Name, mirror: dstImgSpec.Name}]
+		source := scope.source
+		mirror := scope.mirror
 
 		categoryOfImage := imageTypeToCategory(relatedImage.Type)
 		if _, ok := mirrorsByCategory[categoryOfImage]; !ok {
@@ -413,7 +413,11 @@ func TestGenerateIDMS(t *testing.T) {
 				WorkingDir:       workingDir,
 				LocalStorageFQDN: "localhost:55000",
 			}
-			idmsList, err := cr.generateImageMirrors(testCase.imgList, DigestsOnlyMode, false)
+			scopes, err := cr.resolveMirrorScopes(testCase.imgList, false)
+			if err != nil {
+				t.Fatalf("should not fail")
+			}
+			idmsList, err := cr.generateImageMirrors(testCase.imgList, DigestsOnlyMode, scopes)
 			if err != nil {
 				t.Fatalf("should not fail")
 			}
@@ -538,7 +542,11 @@ func TestGenerateITMS(t *testing.T) {
 				WorkingDir:       workingDir,
 				LocalStorageFQDN: "localhost:55000",
 			}
-			itmsList, err := cr.generateImageMirrors(testCase.imgList, TagsOnlyMode, false)
+			scopes, err := cr.resolveMirrorScopes(testCase.imgList, false)
+			if err != nil {
+				t.Fatalf("should not fail")
+			}
+			itmsList, err := cr.generateImageMirrors(testCase.imgList, TagsOnlyMode, scopes)
 			if err != nil {
 				t.Fatalf("should not fail")
 			}
@@ -1283,7 +1291,11 @@ func TestGenerateImageMirrors(t *testing.T) {
 	}
 	for _, test := range testCases {
 		t.Run(test.caseName, func(t *testing.T) {
-			mirrors, err := cr.generateImageMirrors(test.imgList, test.mode, test.forceRepositoryScope)
+			var mirrors []categorizedMirrors
+			scopes, err := cr.resolveMirrorScopes(test.imgList, test.forceRepositoryScope)
+			if err == nil {
+				mirrors, err = cr.generateImageMirrors(test.imgList, test.mode, scopes)
+			}
 			if err == nil && test.expectedError {
 				t.Fatalf("expecting error, but function did not return in error")
 			}
@@ -1430,3 +1442,180 @@ func TestGenerateSignatureConfigMap(t *testing.T) {
 		}
 	})
 }
+
+func TestImagePolicyGenerator(t *testing.T) {
+	log := clog.New("trace")
+
+	tmpDir := t.TempDir()
+	keyFile := filepath.Join(tmpDir, "release.pub")
+	keyData := []byte("-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE\n-----END PUBLIC KEY-----\n")
+	if err := os.WriteFile(keyFile, keyData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Testing ImagePolicyGenerator - ClusterImagePolicy scoped to releases : should pass", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: workingDir,
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name:          "release",
+								Scopes:        []string{"quay.io/openshift-release-dev"},
+								PublicKeyFile: keyFile,
+							},
+						},
+					},
+				},
+			},
+		}
+		err := cr.ImagePolicyGenerator(imageListMixed, false)
+		assert.NoError(t, err)
+
+		cipFile := filepath.Join(workingDir, clusterResourcesDir, "cip-release.yaml")
+		_, err = os.Stat(cipFile)
+		assert.NoError(t, err, "cip-release.yaml should exist")
+
+		content, err := os.ReadFile(cipFile)
+		assert.NoError(t, err)
+		docs := strings.Split(strings.TrimPrefix(string(content), "---\n"), "---\n")
+		assert.Len(t, docs, 1)
+		assert.Contains(t, docs[0], "kind: ClusterImagePolicy")
+		assert.Contains(t, docs[0], "name: release-0")
+		assert.Contains(t, docs[0], "- myregistry/mynamespace/openshift-release-dev")
+		assert.Contains(t, docs[0], "matchPolicy: RemapIdentity")
+		assert.Contains(t, docs[0], "prefix: myregistry/mynamespace/openshift-release-dev")
+		assert.Contains(t, docs[0], "signedPrefix: quay.io/openshift-release-dev")
+		assert.Contains(t, docs[0], "policyType: PublicKey")
+	})
+
+	t.Run("Testing ImagePolicyGenerator - namespaced ImagePolicy narrower than the namespace scope : should pass", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: workingDir,
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name:          "cockroach",
+								Namespace:     "cockroachdb",
+								Scopes:        []string{"quay.io/helmoperators/cockroachdb"},
+								PublicKeyFile: keyFile,
+							},
+						},
+					},
+				},
+			},
+		}
+		err := cr.ImagePolicyGenerator(imageListMixed, false)
+		assert.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(workingDir, clusterResourcesDir, "ip-cockroachdb-cockroach.yaml"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "kind: ImagePolicy")
+		assert.Contains(t, string(content), "namespace: cockroachdb")
+		assert.Contains(t, string(content), "- myregistry/mynamespace/helmoperators/cockroachdb\n")
+		assert.Contains(t, string(content), "signedPrefix: quay.io/helmoperators/cockroachdb\n")
+	})
+
+	t.Run("Testing ImagePolicyGenerator - same ImagePolicy in two namespaces : should generate a file for each", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: workingDir,
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{Name: "cockroach", Namespace: "team-a", Scopes: []string{"quay.io/helmoperators/cockroachdb"}, PublicKeyFile: keyFile},
+							{Name: "cockroach", Namespace: "team-b", Scopes: []string{"quay.io/helmoperators/cockroachdb"}, PublicKeyFile: keyFile},
+						},
+					},
+				},
+			},
+		}
+		assert.NoError(t, cr.ImagePolicyGenerator(imageListMixed, false))
+		for _, namespace := range []string{"team-a", "team-b"} {
+			content, err := os.ReadFile(filepath.Join(workingDir, clusterResourcesDir, "ip-"+namespace+"-cockroach.yaml"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(content), "namespace: "+namespace+"\n")
+		}
+	})
+
+	t.Run("Testing ImagePolicyGenerator - registry scope requested : should use the IDMS/ITMS scope", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: workingDir,
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{{Name: "all", PublicKeyFile: keyFile}},
+					},
+				},
+			},
+		}
+		cr.Config.ClusterResources.MirrorScope.Aggregation = v2alpha1.RegistryScope
+		images := []v2alpha1.CopyImageSchema{{
+			Origin:      "docker://quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64",
+			Destination: "docker://myregistry/mynamespace/openshift-release-dev/ocp-release:4.16.1-x86_64",
+			Type:        v2alpha1.TypeOCPRelease,
+		}}
+		assert.NoError(t, cr.ImagePolicyGenerator(images, false))
+		content, err := os.ReadFile(filepath.Join(workingDir, clusterResourcesDir, "cip-all.yaml"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "prefix: myregistry/mynamespace\n")
+		assert.Contains(t, string(content), "signedPrefix: quay.io\n")
+	})
+
+	t.Run("Testing ImagePolicyGenerator - no image in scope : should not generate", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: workingDir,
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name:          "other",
+								Scopes:        []string{"registry.example.com"},
+								PublicKeyFile: keyFile,
+							},
+						},
+					},
+				},
+			},
+		}
+		err := cr.ImagePolicyGenerator(imageListMixed, false)
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(workingDir, clusterResourcesDir, "cip-other.yaml"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Testing ImagePolicyGenerator - missing key file : should fail", func(t *testing.T) {
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: filepath.Join(t.TempDir(), "working-dir"),
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name:          "release",
+								PublicKeyFile: filepath.Join(tmpDir, "missing.pub"),
+							},
+						},
+					},
+				},
+			},
+		}
+		err := cr.ImagePolicyGenerator(imageListRelease, false)
+		assert.ErrorContains(t, err, "unable to read public key")
+	})
+}
@@ -13,4 +13,9 @@ const (
 	signatureLabel                        = "release.openshift.io/verification-signatures"
 	signatureConfigMapMsg                 = "[GenerateSignatureConfigMap] %v"
 	signatureDir                          = "signatures"
+	logsDir                               = "logs"
+	mirrorScopesReportFilename            = "mirror-scopes.txt"
+	kustomizationFilename                 = "kustomization.yaml"
+	kustomizationApiVersion               = "kustomize.config.k8s.io/v1beta1"
+	kustomizationKind                     = "Kustomization"
 )
@@ -0,0 +1,267 @@
+package clusterresources
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	confv1alpha1 "github.com/openshift/api/config/v1alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// scopedMirror associates the scope of the original (signed) repositories
+// with the scope of the repositories they were mirrored to.
+type scopedMirror struct {
+	source string
+	mirror string
+}
+
+// ImagePolicyGenerator generates, for each image policy of the ImageSetConfiguration,
+// ClusterImagePolicy (or ImagePolicy when a namespace is set) resources scoped to the
+// mirrored release and operator repositories, with the same scopes as the IDMS/ITMS entries.
+// The signedIdentity of each policy remaps the mirror repositories to the original repositories,
+// against which the signatures were issued.
+func (o *ClusterResourcesGenerator) ImagePolicyGenerator(allRelatedImages []v2alpha1.CopyImageSchema, forceRepositoryScope bool) error {
+	if len(o.Config.Mirror.ImagePolicies) == 0 {
+		return nil
+	}
+	if len(allRelatedImages) == 0 {
+		o.Log.Info(emoji.PageFacingUp + " Nothing mirrored. Skipping image policies generation.")
+		return nil
+	}
+
+	mirrorScopes, err := o.resolveMirrorScopes(allRelatedImages, forceRepositoryScope)
+	if err != nil {
+		return err
+	}
+	for _, policyConfig := range o.Config.Mirror.ImagePolicies {
+		scopedMirrors, err := policyScopedMirrors(allRelatedImages, policyConfig.Scopes, mirrorScopes)
+		if err != nil {
+			return err
+		}
+		if len(scopedMirrors) == 0 {
+			o.Log.Info(emoji.PageFacingUp+" No mirrored images in the scope of image policy %s. Skipping its generation.", policyConfig.Name)
+			continue
+		}
+
+		rootOfTrust, err := policyRootOfTrust(policyConfig)
+		if err != nil {
+			return err
+		}
+
+		o.Log.Info(emoji.PageFacingUp+" Generating image policy %s file...", policyConfig.Name)
+		if policyConfig.Namespace == "" {
+			cipList, err := generateClusterImagePolicies(policyConfig, rootOfTrust, scopedMirrors)
+			if err != nil {
+				return err
+			}
+			if err := writeResourceList(cipList, o.WorkingDir, "cip-"+policyConfig.Name+".yaml", o.Log); err != nil {
+				return err
+			}
+		} else {
+			ipList, err := generateImagePolicies(policyConfig, rootOfTrust, scopedMirrors)
+			if err != nil {
+				return err
+			}
+			// policies of the same name may be set in several namespaces
+			if err := writeResourceList(ipList, o.WorkingDir, "ip-"+policyConfig.Namespace+"-"+policyConfig.Name+".yaml", o.Log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func generateClusterImagePolicies(policyConfig v2alpha1.ImagePolicy, rootOfTrust confv1alpha1.PolicyRootOfTrust, scopedMirrors []scopedMirror) ([]confv1alpha1.ClusterImagePolicy, error) {
+	cipList := make([]confv1alpha1.ClusterImagePolicy, 0, len(scopedMirrors))
+	for index, sm := range scopedMirrors {
+		name, err := imagePolicyName(policyConfig.Name, index)
+		if err != nil {
+			return nil, err
+		}
+		cipList = append(cipList, confv1alpha1.ClusterImagePolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: confv1alpha1.GroupVersion.String(),
+				Kind:       "ClusterImagePolicy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: generateOcMirrorAnnotations(),
+			},
+			Spec: confv1alpha1.ClusterImagePolicySpec{
+				Scopes: []confv1alpha1.ImageScope{confv1alpha1.ImageScope(sm.mirror)},
+				Policy: confv1alpha1.Policy{
+					RootOfTrust:    rootOfTrust,
+					SignedIdentity: mirrorSignedIdentity(sm),
+				},
+			},
+		})
+	}
+	return cipList, nil
+}
+
+func generateImagePolicies(policyConfig v2alpha1.ImagePolicy, rootOfTrust confv1alpha1.PolicyRootOfTrust, scopedMirrors []scopedMirror) ([]confv1alpha1.ImagePolicy, error) {
+	ipList := make([]confv1alpha1.ImagePolicy, 0, len(scopedMirrors))
+	for index, sm := range scopedMirrors {
+		name, err := imagePolicyName(policyConfig.Name, index)
+		if err != nil {
+			return nil, err
+		}
+		ipList = append(ipList, confv1alpha1.ImagePolicy{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: confv1alpha1.GroupVersion.String(),
+				Kind:       "ImagePolicy",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   policyConfig.Namespace,
+				Annotations: generateOcMirrorAnnotations(),
+			},
+			Spec: confv1alpha1.ImagePolicySpec{
+				Scopes: []confv1alpha1.ImageScope{confv1alpha1.ImageScope(sm.mirror)},
+				Policy: confv1alpha1.Policy{
+					RootOfTrust:    rootOfTrust,
+					SignedIdentity: mirrorSignedIdentity(sm),
+				},
+			},
+		})
+	}
+	return ipList, nil
+}
+
+// policyScopedMirrors returns the sorted list of mirror scopes for the release and operator
+// images whose origin is under one of the scopes of the policy (all of them when scopes is empty).
+// Scopes are the ones resolved for the IDMS/ITMS entries, narrowed to the repository unless
+// this would widen the policy beyond its configured scopes.
+func policyScopedMirrors(allRelatedImages []v2alpha1.CopyImageSchema, scopes []string, mirrorScopes map[repositoryPair]resolvedScope) ([]scopedMirror, error) {
+	found := map[scopedMirror]bool{}
+	for _, relatedImage := range allRelatedImages {
+		switch relatedImage.Type {
+		case v2alpha1.TypeOCPRelease, v2alpha1.TypeOCPReleaseContent, v2alpha1.TypeOperatorBundle, v2alpha1.TypeOperatorRelatedImage:
+		default:
+			// the cincinnati graph image and the rebuilt catalogs are generated by oc-mirror
+			// and are therefore not signed by the original authors
+			continue
+		}
+		if relatedImage.Origin == "" {
+			return nil, fmt.Errorf("unable to generate image policies: original reference for (%s,%s) undetermined", relatedImage.Source, relatedImage.Destination)
+		}
+		srcImgSpec, err := image.ParseRef(relatedImage.Origin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate image policies: %v", err)
+		}
+		dstImgSpec, err := image.ParseRef(relatedImage.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate image policies: %v", err)
+		}
+
+		sm := scopedMirror{source: repositoryScope(srcImgSpec), mirror: repositoryScope(dstImgSpec)}
+		if rs, ok := mirrorScopes[repositoryPair{source: srcImgSpec.Name, mirror: dstImgSpec.Name}]; ok {
+			sm = rs.scopedMirror
+		}
+
+		if len(scopes) > 0 {
+			matchingScope := ""
+			for _, scope := range scopes {
+				if isInScope(srcImgSpec.Name, scope) {
+					matchingScope = scope
+					break
+				}
+			}
+			if matchingScope == "" {
+				continue
+			}
+			if !isInScope(sm.source, matchingScope) {
+				sm = scopedMirror{source: repositoryScope(srcImgSpec), mirror: repositoryScope(dstImgSpec)}
+			}
+		}
+		found[sm] = true
+	}
+
+	scopedMirrors := make([]scopedMirror, 0, len(found))
+	for sm := range found {
+		scopedMirrors = append(scopedMirrors, sm)
+	}
+	sort.Slice(scopedMirrors, func(i, j int) bool {
+		if scopedMirrors[i].mirror == scopedMirrors[j].mirror {
+			return scopedMirrors[i].source < scopedMirrors[j].source
+		}
+		return scopedMirrors[i].mirror < scopedMirrors[j].mirror
+	})
+	return scopedMirrors, nil
+}
+
+// isInScope returns true when repository is the scope or a sub path of the scope
+func isInScope(repository, scope string) bool {
+	scope = strings.TrimSuffix(scope, "/")
+	return repository == scope || strings.HasPrefix(repository, scope+"/")
+}
+
+func mirrorSignedIdentity(sm scopedMirror) confv1alpha1.PolicyIdentity {
+	if sm.source == sm.mirror {
+		return confv1alpha1.PolicyIdentity{
+			MatchPolicy: confv1alpha1.IdentityMatchPolicyMatchRepoDigestOrExact,
+		}
+	}
+	return confv1alpha1.PolicyIdentity{
+		MatchPolicy: confv1alpha1.IdentityMatchPolicyRemapIdentity,
+		PolicyMatchRemapIdentity: &confv1alpha1.PolicyMatchRemapIdentity{
+			Prefix:       confv1alpha1.IdentityRepositoryPrefix(sm.mirror),
+			SignedPrefix: confv1alpha1.IdentityRepositoryPrefix(sm.source),
+		},
+	}
+}
+
+func policyRootOfTrust(policyConfig v2alpha1.ImagePolicy) (confv1alpha1.PolicyRootOfTrust, error) {
+	if policyConfig.PublicKeyFile != "" {
+		keyData, err := os.ReadFile(policyConfig.PublicKeyFile)
+		if err != nil {
+			return confv1alpha1.PolicyRootOfTrust{}, fmt.Errorf("image policy %s: unable to read public key: %v", policyConfig.Name, err)
+		}
+		publicKey := &confv1alpha1.PublicKey{KeyData: keyData}
+		if policyConfig.RekorKeyFile != "" {
+			publicKey.RekorKeyData, err = os.ReadFile(policyConfig.RekorKeyFile)
+			if err != nil {
+				return confv1alpha1.PolicyRootOfTrust{}, fmt.Errorf("image policy %s: unable to read rekor key: %v", policyConfig.Name, err)
+			}
+		}
+		return confv1alpha1.PolicyRootOfTrust{
+			PolicyType: confv1alpha1.PublicKeyRootOfTrust,
+			PublicKey:  publicKey,
+		}, nil
+	}
+
+	fulcioCAData, err := os.ReadFile(policyConfig.FulcioCAFile)
+	if err != nil {
+		return confv1alpha1.PolicyRootOfTrust{}, fmt.Errorf("image policy %s: unable to read fulcio CA: %v", policyConfig.Name, err)
+	}
+	rekorKeyData, err := os.ReadFile(policyConfig.RekorKeyFile)
+	if err != nil {
+		return confv1alpha1.PolicyRootOfTrust{}, fmt.Errorf("image policy %s: unable to read rekor key: %v", policyConfig.Name, err)
+	}
+	return confv1alpha1.PolicyRootOfTrust{
+		PolicyType: confv1alpha1.FulcioCAWithRekorRootOfTrust,
+		FulcioCAWithRekor: &confv1alpha1.FulcioCAWithRekor{
+			FulcioCAData: fulcioCAData,
+			RekorKeyData: rekorKeyData,
+			FulcioSubject: confv1alpha1.PolicyFulcioSubject{
+				OIDCIssuer:  policyConfig.OIDCIssuer,
+				SignedEmail: policyConfig.SignedEmail,
+			},
+		},
+	}, nil
+}
+
+func imagePolicyName(policyName string, index int) (string, error) {
+	name := strings.ReplaceAll(policyName+"-"+strconv.Itoa(index), ".", "-")
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+		return "", fmt.Errorf("error creating image policy name: %s", strings.Join(errs, ", "))
+	}
+	return name, nil
+}
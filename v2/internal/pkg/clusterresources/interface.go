@@ -1,6 +1,8 @@
 package clusterresources
 
 import (
+	"context"
+
 	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
 )
 
@@ -10,4 +12,10 @@ type GeneratorInterface interface {
 	CatalogSourceGenerator(allRelatedImages []v2alpha1.CopyImageSchema) error
 	GenerateSignatureConfigMap(allRelatedImages []v2alpha1.CopyImageSchema) error
 	ClusterCatalogGenerator(allRelatedImages []v2alpha1.CopyImageSchema) error
+	ImagePolicyGenerator(allRelatedImages []v2alpha1.CopyImageSchema, forceRepositoryScope bool) error
+	KustomizationGenerator() error
+}
+
+type ApplierInterface interface {
+	Apply(ctx context.Context) error
 }
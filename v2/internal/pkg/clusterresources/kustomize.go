@@ -0,0 +1,85 @@
+package clusterresources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/emoji"
+)
+
+// kustomization is the subset of the kustomize.config.k8s.io/v1beta1 Kustomization
+// used by oc-mirror
+type kustomization struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	NamePrefix string            `json:"namePrefix,omitempty"`
+	Labels     []kustomizeLabels `json:"labels,omitempty"`
+	Resources  []string          `json:"resources"`
+}
+
+type kustomizeLabels struct {
+	Pairs            map[string]string `json:"pairs"`
+	IncludeSelectors bool              `json:"includeSelectors"`
+}
+
+// KustomizationGenerator writes a kustomization.yaml in cluster-resources listing all
+// the resources generated by oc-mirror (IDMS, ITMS, CatalogSources, ClusterCatalogs,
+// UpdateService, signature ConfigMaps...), so that GitOps tooling can sync the directory.
+// It must be called after all the other generators.
+func (o *ClusterResourcesGenerator) KustomizationGenerator() error {
+	kustomizeConfig := o.Config.ClusterResources.Kustomize
+	if kustomizeConfig == nil {
+		return nil
+	}
+	o.Log.Info(emoji.PageFacingUp + " Generating kustomization file...")
+
+	crPath := filepath.Join(o.WorkingDir, clusterResourcesDir)
+	if err := os.MkdirAll(crPath, 0755); err != nil {
+		return fmt.Errorf("unable to generate kustomization: %w", err)
+	}
+	files, err := os.ReadDir(crPath)
+	if err != nil {
+		return fmt.Errorf("unable to generate kustomization: %w", err)
+	}
+
+	resources := []string{}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		// json files are skipped: the signature ConfigMap is also generated in yaml,
+		// and listing both would duplicate the resource
+		if f.IsDir() || f.Name() == kustomizationFilename || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		resources = append(resources, f.Name())
+	}
+	sort.Strings(resources)
+	if len(resources) == 0 {
+		o.Log.Warn("no cluster resources generated, the kustomization is empty")
+	}
+
+	k := kustomization{
+		APIVersion: kustomizationApiVersion,
+		Kind:       kustomizationKind,
+		NamePrefix: kustomizeConfig.NamePrefix,
+		Resources:  resources,
+	}
+	if len(kustomizeConfig.Labels) > 0 {
+		// selectors are left untouched: they are immutable on some resources
+		k.Labels = []kustomizeLabels{{Pairs: kustomizeConfig.Labels}}
+	}
+
+	bytes, err := yaml.Marshal(k)
+	if err != nil {
+		return fmt.Errorf("unable to generate kustomization: %w", err)
+	}
+	kustomizationPath := filepath.Join(crPath, kustomizationFilename)
+	if err := os.WriteFile(kustomizationPath, bytes, 0644); err != nil {
+		return fmt.Errorf("unable to generate kustomization: %w", err)
+	}
+	o.Log.Info("%s file created", kustomizationPath)
+	return nil
+}
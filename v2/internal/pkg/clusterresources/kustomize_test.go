@@ -0,0 +1,71 @@
+package clusterresources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/parser"
+)
+
+func TestKustomizationGenerator(t *testing.T) {
+	log := clog.New("trace")
+
+	t.Run("Testing KustomizationGenerator - not configured : should not generate a kustomization", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:        log,
+			WorkingDir: workingDir,
+		}
+		err := cr.KustomizationGenerator()
+		assert.NoError(t, err)
+		_, err = os.Stat(filepath.Join(workingDir, clusterResourcesDir, kustomizationFilename))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Testing KustomizationGenerator - configured : should list all yaml resources", func(t *testing.T) {
+		workingDir := filepath.Join(t.TempDir(), "working-dir")
+		cr := &ClusterResourcesGenerator{
+			Log:              log,
+			WorkingDir:       workingDir,
+			LocalStorageFQDN: "localhost:55000",
+			Config: v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					ClusterResources: v2alpha1.ClusterResources{
+						Kustomize: &v2alpha1.Kustomize{
+							NamePrefix: "disconnected-",
+							Labels:     map[string]string{"app.kubernetes.io/managed-by": "oc-mirror"},
+						},
+					},
+				},
+			},
+		}
+		err := cr.IDMS_ITMSGenerator(imageListMixed, false)
+		assert.NoError(t, err)
+		err = cr.UpdateServiceGenerator("localhost:5000/openshift/graph-image:latest", "quay.io/openshift-release-dev/ocp-release:4.13.10-x86_64")
+		assert.NoError(t, err)
+		// generated in both json and yaml, only the yaml should be listed
+		crPath := filepath.Join(workingDir, clusterResourcesDir)
+		assert.NoError(t, os.WriteFile(filepath.Join(crPath, "signature-configmap.json"), []byte("{}"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(crPath, "signature-configmap.yaml"), []byte("{}"), 0644))
+
+		err = cr.KustomizationGenerator()
+		assert.NoError(t, err)
+
+		k, err := parser.ParseYamlFile[kustomization](filepath.Join(crPath, kustomizationFilename))
+		assert.NoError(t, err)
+		assert.Equal(t, kustomization{
+			APIVersion: kustomizationApiVersion,
+			Kind:       kustomizationKind,
+			NamePrefix: "disconnected-",
+			Labels: []kustomizeLabels{
+				{Pairs: map[string]string{"app.kubernetes.io/managed-by": "oc-mirror"}},
+			},
+			Resources: []string{idmsFileName, itmsFileName, "signature-configmap.yaml", updateServiceFilename},
+		}, k)
+	})
+}
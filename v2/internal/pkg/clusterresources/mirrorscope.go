@@ -0,0 +1,177 @@
+package clusterresources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+)
+
+// scopeLevels orders the aggregation levels from the narrowest to the widest
+var scopeLevels = []v2alpha1.ScopeAggregation{v2alpha1.RepositoryScope, v2alpha1.NamespaceScope, v2alpha1.RegistryScope}
+
+// repositoryPair is an original repository and the repository it was mirrored to
+type repositoryPair struct {
+	source string
+	mirror string
+}
+
+// resolvedScope is the scope chosen for the IDMS/ITMS entry of a repositoryPair
+type resolvedScope struct {
+	scopedMirror
+	requested v2alpha1.ScopeAggregation
+	chosen    v2alpha1.ScopeAggregation
+}
+
+// resolveMirrorScopes chooses, for each mirrored repository, the scope of its IDMS/ITMS entry.
+// Each repository is aggregated up to the level requested in the ImageSetConfiguration (namespace by default)
+// as long as it is safe: when repositories under the same aggregated source would be mirrored under
+// different destination prefixes, they are downgraded to a narrower scope.
+// When forceRepositoryScope is set, all entries are repository scoped.
+func (o *ClusterResourcesGenerator) resolveMirrorScopes(allRelatedImages []v2alpha1.CopyImageSchema, forceRepositoryScope bool) (map[repositoryPair]resolvedScope, error) {
+	specs := map[repositoryPair][2]image.ImageSpec{}
+	levels := map[repositoryPair]int{}
+	resolved := map[repositoryPair]resolvedScope{}
+	for _, relatedImage := range allRelatedImages {
+		if relatedImage.Type == v2alpha1.TypeCincinnatiGraph || relatedImage.Type == v2alpha1.TypeOperatorCatalog {
+			continue
+		}
+		if relatedImage.Origin == "" {
+			return nil, fmt.Errorf("unable to generate IDMS/ITMS: original reference for (%s,%s) undetermined", relatedImage.Source, relatedImage.Destination)
+		}
+		srcImgSpec, err := image.ParseRef(relatedImage.Origin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate IDMS/ITMS: %v", err)
+		}
+		dstImgSpec, err := image.ParseRef(relatedImage.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate IDMS/ITMS: %v", err)
+		}
+		pair := repositoryPair{source: srcImgSpec.Name, mirror: dstImgSpec.Name}
+		if _, ok := specs[pair]; ok {
+			continue
+		}
+		specs[pair] = [2]image.ImageSpec{srcImgSpec, dstImgSpec}
+		requested := v2alpha1.RepositoryScope
+		if !forceRepositoryScope {
+			requested = o.requestedAggregation(srcImgSpec.Name)
+		}
+		levels[pair] = scopeLevelIndex(requested)
+		resolved[pair] = resolvedScope{requested: requested}
+	}
+
+	// downgrade the pairs sharing an aggregated source with conflicting mirrors until all sources are safe
+	for {
+		mirrorsBySource := map[string]map[string]bool{}
+		for pair, level := range levels {
+			sm := scopeAtLevel(specs[pair][0], specs[pair][1], scopeLevels[level])
+			if mirrorsBySource[sm.source] == nil {
+				mirrorsBySource[sm.source] = map[string]bool{}
+			}
+			mirrorsBySource[sm.source][sm.mirror] = true
+		}
+		downgraded := false
+		for pair, level := range levels {
+			if level == 0 {
+				continue
+			}
+			sm := scopeAtLevel(specs[pair][0], specs[pair][1], scopeLevels[level])
+			if len(mirrorsBySource[sm.source]) > 1 {
+				levels[pair] = level - 1
+				downgraded = true
+			}
+		}
+		if !downgraded {
+			break
+		}
+	}
+
+	for pair, level := range levels {
+		rs := resolved[pair]
+		rs.scopedMirror = scopeAtLevel(specs[pair][0], specs[pair][1], scopeLevels[level])
+		rs.chosen = scopeLevels[level]
+		if rs.scopedMirror == (scopedMirror{source: pair.source, mirror: pair.mirror}) {
+			rs.chosen = v2alpha1.RepositoryScope
+		}
+		resolved[pair] = rs
+	}
+	return resolved, nil
+}
+
+// requestedAggregation returns the aggregation of the longest override matching
+// the repository, or the default aggregation
+func (o *ClusterResourcesGenerator) requestedAggregation(repository string) v2alpha1.ScopeAggregation {
+	mirrorScope := o.Config.ClusterResources.MirrorScope
+	aggregation := mirrorScope.Aggregation
+	if aggregation == "" {
+		aggregation = v2alpha1.NamespaceScope
+	}
+	longestMatch := 0
+	for _, override := range mirrorScope.Overrides {
+		if isInScope(repository, override.Source) && len(override.Source) > longestMatch {
+			longestMatch = len(override.Source)
+			aggregation = override.Aggregation
+		}
+	}
+	return aggregation
+}
+
+func scopeLevelIndex(aggregation v2alpha1.ScopeAggregation) int {
+	for index, level := range scopeLevels {
+		if level == aggregation {
+			return index
+		}
+	}
+	return 1 // namespace
+}
+
+// scopeAtLevel returns the source and mirror scopes of an image at the requested level.
+// It falls back to the repository scope when the destination does not preserve the original
+// path, because aggregating would then point to the wrong location.
+func scopeAtLevel(srcImgSpec, dstImgSpec image.ImageSpec, level v2alpha1.ScopeAggregation) scopedMirror {
+	switch level {
+	case v2alpha1.RegistryScope:
+		if dstImgSpec.PathComponent == srcImgSpec.PathComponent {
+			return scopedMirror{source: srcImgSpec.Domain, mirror: dstImgSpec.Domain}
+		}
+		if strings.HasSuffix(dstImgSpec.PathComponent, "/"+srcImgSpec.PathComponent) {
+			prefix := strings.TrimSuffix(dstImgSpec.PathComponent, "/"+srcImgSpec.PathComponent)
+			return scopedMirror{source: srcImgSpec.Domain, mirror: dstImgSpec.Domain + "/" + prefix}
+		}
+	case v2alpha1.NamespaceScope:
+		source, mirror := attemptNamespaceScope(srcImgSpec, dstImgSpec)
+		return scopedMirror{source: source, mirror: mirror}
+	}
+	return scopedMirror{source: repositoryScope(srcImgSpec), mirror: repositoryScope(dstImgSpec)}
+}
+
+// writeMirrorScopesReport writes the scopes chosen for the IDMS/ITMS entries to the logs directory
+func (o *ClusterResourcesGenerator) writeMirrorScopesReport(resolved map[repositoryPair]resolvedScope) error {
+	lines := map[string]bool{}
+	for _, rs := range resolved {
+		line := fmt.Sprintf("%s -> %s (%s)", rs.source, rs.mirror, rs.chosen)
+		if scopeLevelIndex(rs.chosen) < scopeLevelIndex(rs.requested) {
+			line += fmt.Sprintf(" : %s scope requested, narrowed because the destination does not preserve the original path or conflicts with other repositories", rs.requested)
+		}
+		lines[line] = true
+	}
+	report := make([]string, 0, len(lines))
+	for line := range lines {
+		report = append(report, line)
+	}
+	sort.Strings(report)
+
+	reportPath := filepath.Join(o.WorkingDir, logsDir, mirrorScopesReportFilename)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(reportPath, []byte(strings.Join(report, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	o.Log.Info("%s file created", reportPath)
+	return nil
+}
@@ -0,0 +1,173 @@
+package clusterresources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	confv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+func TestGenerateImageMirrorsWithMirrorScope(t *testing.T) {
+	imgList := []v2alpha1.CopyImageSchema{
+		{
+			Source:      "docker://localhost:55000/kubebuilder/kube-rbac-proxy:v0.5.0",
+			Destination: "docker://myregistry/mynamespace/kubebuilder/kube-rbac-proxy:v0.5.0",
+			Origin:      "docker://gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0",
+			Type:        v2alpha1.TypeOperatorRelatedImage,
+		},
+		{
+			Source:      "docker://localhost:55000/cockroachdb/cockroach-helm-operator:6.0.0",
+			Destination: "docker://myregistry/mynamespace/cockroachdb/cockroach-helm-operator:6.0.0",
+			Origin:      "docker://quay.io/cockroachdb/cockroach-helm-operator:6.0.0",
+			Type:        v2alpha1.TypeOperatorRelatedImage,
+		},
+		{
+			Source:      "docker://localhost:55000/helmoperators/cockroachdb:v5.0.3",
+			Destination: "docker://myregistry/mynamespace/helmoperators/cockroachdb:v5.0.3",
+			Origin:      "docker://quay.io/helmoperators/cockroachdb:v5.0.3",
+			Type:        v2alpha1.TypeOperatorRelatedImage,
+		},
+		{
+			Source:      "docker://localhost:55000/openshift/release:4.14.38-x86_64-agent-installer-api-server",
+			Destination: "docker://myregistry/mynamespace/openshift/release:4.14.38-x86_64-agent-installer-api-server",
+			Origin:      "docker://quay.io/openshift-release-dev/ocp-v4.0-art-dev:4.14.38-x86_64-agent-installer-api-server",
+			Type:        v2alpha1.TypeOCPReleaseContent,
+		},
+	}
+
+	type testCase struct {
+		caseName        string
+		mirrorScope     v2alpha1.MirrorScope
+		expectedMirrors map[mirrorCategory]map[string][]confv1.ImageMirror
+		expectedReport  string
+	}
+	testCases := []testCase{
+		{
+			caseName:    "Testing GenerateImageMirrors - registry aggregation : should aggregate per registry and narrow the release repository",
+			mirrorScope: v2alpha1.MirrorScope{Aggregation: v2alpha1.RegistryScope},
+			expectedMirrors: map[mirrorCategory]map[string][]confv1.ImageMirror{
+				operatorCategory: {
+					"gcr.io":  {"myregistry/mynamespace"},
+					"quay.io": {"myregistry/mynamespace"},
+				},
+				releaseCategory: {
+					"quay.io/openshift-release-dev/ocp-v4.0-art-dev": {"myregistry/mynamespace/openshift/release"},
+				},
+			},
+			expectedReport: "gcr.io -> myregistry/mynamespace (registry)\n" +
+				"quay.io -> myregistry/mynamespace (registry)\n" +
+				"quay.io/openshift-release-dev/ocp-v4.0-art-dev -> myregistry/mynamespace/openshift/release (repository) : registry scope requested, narrowed because the destination does not preserve the original path or conflicts with other repositories\n",
+		},
+		{
+			caseName: "Testing GenerateImageMirrors - registry aggregation with override : should use repository scope for the override",
+			mirrorScope: v2alpha1.MirrorScope{
+				Aggregation: v2alpha1.RegistryScope,
+				Overrides: []v2alpha1.MirrorScopeOverride{
+					{Source: "quay.io/helmoperators", Aggregation: v2alpha1.RepositoryScope},
+				},
+			},
+			expectedMirrors: map[mirrorCategory]map[string][]confv1.ImageMirror{
+				operatorCategory: {
+					"gcr.io":                            {"myregistry/mynamespace"},
+					"quay.io":                           {"myregistry/mynamespace"},
+					"quay.io/helmoperators/cockroachdb": {"myregistry/mynamespace/helmoperators/cockroachdb"},
+				},
+				releaseCategory: {
+					"quay.io/openshift-release-dev/ocp-v4.0-art-dev": {"myregistry/mynamespace/openshift/release"},
+				},
+			},
+		},
+		{
+			caseName:    "Testing GenerateImageMirrors - repository aggregation : should not aggregate",
+			mirrorScope: v2alpha1.MirrorScope{Aggregation: v2alpha1.RepositoryScope},
+			expectedMirrors: map[mirrorCategory]map[string][]confv1.ImageMirror{
+				operatorCategory: {
+					"gcr.io/kubebuilder/kube-rbac-proxy":          {"myregistry/mynamespace/kubebuilder/kube-rbac-proxy"},
+					"quay.io/cockroachdb/cockroach-helm-operator": {"myregistry/mynamespace/cockroachdb/cockroach-helm-operator"},
+					"quay.io/helmoperators/cockroachdb":           {"myregistry/mynamespace/helmoperators/cockroachdb"},
+				},
+				releaseCategory: {
+					"quay.io/openshift-release-dev/ocp-v4.0-art-dev": {"myregistry/mynamespace/openshift/release"},
+				},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(t *testing.T) {
+			workingDir := filepath.Join(t.TempDir(), "working-dir")
+			cr := &ClusterResourcesGenerator{
+				Log:              clog.New("trace"),
+				WorkingDir:       workingDir,
+				LocalStorageFQDN: "localhost:55000",
+				Config: v2alpha1.ImageSetConfiguration{
+					ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+						ClusterResources: v2alpha1.ClusterResources{
+							MirrorScope: testCase.mirrorScope,
+						},
+					},
+				},
+			}
+			scopes, err := cr.resolveMirrorScopes(imgList, false)
+			assert.NoError(t, err)
+			mirrors, err := cr.generateImageMirrors(imgList, TagsOnlyMode, scopes)
+			assert.NoError(t, err)
+			assert.Equal(t, len(testCase.expectedMirrors), len(mirrors))
+			for _, actual := range mirrors {
+				assert.Equal(t, testCase.expectedMirrors[actual.category], actual.mirrors)
+			}
+
+			if testCase.expectedReport != "" {
+				err = cr.IDMS_ITMSGenerator(imgList, false)
+				assert.NoError(t, err)
+				report, err := os.ReadFile(filepath.Join(workingDir, logsDir, mirrorScopesReportFilename))
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedReport, string(report))
+			}
+		})
+	}
+}
+
+func TestResolveMirrorScopesConflicts(t *testing.T) {
+	// both repositories are under quay.io/ns, but are mirrored under different destination prefixes:
+	// aggregating at the namespace or registry level is not safe
+	imgList := []v2alpha1.CopyImageSchema{
+		{
+			Source:      "docker://localhost:55000/ns/a:v1",
+			Destination: "docker://registry-a/ns/a:v1",
+			Origin:      "docker://quay.io/ns/a:v1",
+			Type:        v2alpha1.TypeGeneric,
+		},
+		{
+			Source:      "docker://localhost:55000/ns/b:v1",
+			Destination: "docker://registry-b/ns/b:v1",
+			Origin:      "docker://quay.io/ns/b:v1",
+			Type:        v2alpha1.TypeGeneric,
+		},
+	}
+	cr := &ClusterResourcesGenerator{
+		Log: clog.New("trace"),
+		Config: v2alpha1.ImageSetConfiguration{
+			ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+				ClusterResources: v2alpha1.ClusterResources{
+					MirrorScope: v2alpha1.MirrorScope{Aggregation: v2alpha1.RegistryScope},
+				},
+			},
+		},
+	}
+	resolved, err := cr.resolveMirrorScopes(imgList, false)
+	assert.NoError(t, err)
+	assert.Equal(t, scopedMirror{source: "quay.io/ns/a", mirror: "registry-a/ns/a"}, resolved[repositoryPair{source: "quay.io/ns/a", mirror: "registry-a/ns/a"}].scopedMirror)
+	assert.Equal(t, scopedMirror{source: "quay.io/ns/b", mirror: "registry-b/ns/b"}, resolved[repositoryPair{source: "quay.io/ns/b", mirror: "registry-b/ns/b"}].scopedMirror)
+	assert.Equal(t, v2alpha1.RepositoryScope, resolved[repositoryPair{source: "quay.io/ns/a", mirror: "registry-a/ns/a"}].chosen)
+
+	// forcing the repository scope ignores the configured aggregation
+	imgList[1].Destination = "docker://registry-a/ns/b:v1"
+	resolved, err = cr.resolveMirrorScopes(imgList, true)
+	assert.NoError(t, err)
+	assert.Equal(t, scopedMirror{source: "quay.io/ns/b", mirror: "registry-a/ns/b"}, resolved[repositoryPair{source: "quay.io/ns/b", mirror: "registry-a/ns/b"}].scopedMirror)
+}
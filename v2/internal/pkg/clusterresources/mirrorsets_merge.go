@@ -0,0 +1,280 @@
+package clusterresources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	confv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ocMirrorSetNameRegex matches the names of IDMS/ITMS generated by oc-mirror,
+// capturing the category of the mirrors it contains
+var ocMirrorSetNameRegex = regexp.MustCompile(`^(?:idms|itms)-(release|operator|generic)-\d+$`)
+
+// existingMirrorSets holds the content of the mirror sets already applied on a cluster,
+// as well as the images deleted by the delete workflow
+type existingMirrorSets struct {
+	byDigestMirrors []categorizedMirrors
+	byTagMirrors    []categorizedMirrors
+	deleted         deletedContent
+	// deletedReferences are the references, in the destination, of the images deleted by the delete workflow
+	deletedReferences map[string]bool
+}
+
+// deletedContent tells which existing entries only cover content deleted by the delete workflow
+type deletedContent struct {
+	// repositories of the images deleted by the delete workflow
+	deleted []string
+	// repositories of the images still mirrored according to the workspace
+	remaining []string
+}
+
+// loadExistingMirrorSets reads all yaml and json files of dir, and extracts:
+// * the ImageDigestMirrorSet and ImageTagMirrorSet resources (standalone, multi-document, or as items of a List)
+// * the DeleteImageList resources generated by the delete workflow
+func loadExistingMirrorSets(dir string) (existingMirrorSets, error) {
+	existing := existingMirrorSets{deletedReferences: map[string]bool{}}
+	idmsMirrors := map[mirrorCategory]categorizedMirrors{}
+	itmsMirrors := map[mirrorCategory]categorizedMirrors{}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return existing, fmt.Errorf("unable to read existing mirror sets: %w", err)
+	}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		objs, err := decodeAllObjects(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return existing, err
+		}
+		for _, obj := range objs {
+			if err := existing.add(obj, idmsMirrors, itmsMirrors); err != nil {
+				return existing, fmt.Errorf("unable to read existing mirror sets from %s: %w", f.Name(), err)
+			}
+		}
+	}
+	for _, cm := range idmsMirrors {
+		existing.byDigestMirrors = append(existing.byDigestMirrors, cm)
+	}
+	for _, cm := range itmsMirrors {
+		existing.byTagMirrors = append(existing.byTagMirrors, cm)
+	}
+	return existing, nil
+}
+
+func (e *existingMirrorSets) add(obj map[string]interface{}, idmsMirrors, itmsMirrors map[mirrorCategory]categorizedMirrors) error {
+	switch obj["kind"] {
+	case "List", "ImageDigestMirrorSetList", "ImageTagMirrorSetList":
+		items, _ := obj["items"].([]interface{})
+		for _, item := range items {
+			if itemObj, ok := item.(map[string]interface{}); ok {
+				if err := e.add(itemObj, idmsMirrors, itmsMirrors); err != nil {
+					return err
+				}
+			}
+		}
+	case "ImageDigestMirrorSet":
+		var idms confv1.ImageDigestMirrorSet
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &idms); err != nil {
+			return err
+		}
+		category := mirrorSetCategory(idms.Name)
+		for _, idm := range idms.Spec.ImageDigestMirrors {
+			addImageMirrors(idmsMirrors, category, idm.Source, idm.Mirrors)
+		}
+	case "ImageTagMirrorSet":
+		var itms confv1.ImageTagMirrorSet
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &itms); err != nil {
+			return err
+		}
+		category := mirrorSetCategory(itms.Name)
+		for _, itm := range itms.Spec.ImageTagMirrors {
+			addImageMirrors(itmsMirrors, category, itm.Source, itm.Mirrors)
+		}
+	case "DeleteImageList":
+		var deleteList v2alpha1.DeleteImageList
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &deleteList); err != nil {
+			return err
+		}
+		for _, item := range deleteList.Items {
+			imgSpec, err := image.ParseRef(item.ImageName)
+			if err != nil {
+				return err
+			}
+			e.deleted.deleted = append(e.deleted.deleted, imgSpec.Name)
+			refSpec, err := image.ParseRef(item.ImageReference)
+			if err != nil {
+				return err
+			}
+			e.deletedReferences[refSpec.Reference] = true
+		}
+	}
+	return nil
+}
+
+// setRemaining records the repositories of the images mirrored by the workspace which were not deleted
+func (e *existingMirrorSets) setRemaining(records []v2alpha1.MirroredImage) error {
+	for _, r := range records {
+		refSpec, err := image.ParseRef(r.ImageReference)
+		if err != nil {
+			return err
+		}
+		if e.deletedReferences[refSpec.Reference] || r.ImageName == "" {
+			continue
+		}
+		imgSpec, err := image.ParseRef(r.ImageName)
+		if err != nil {
+			return err
+		}
+		e.deleted.remaining = append(e.deleted.remaining, imgSpec.Name)
+	}
+	return nil
+}
+
+func decodeAllObjects(path string) ([]map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	objs := []map[string]interface{}{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+		}
+		if len(obj) > 0 {
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
+
+func mirrorSetCategory(name string) mirrorCategory {
+	matches := ocMirrorSetNameRegex.FindStringSubmatch(name)
+	if len(matches) != 2 {
+		return genericCategory
+	}
+	switch matches[1] {
+	case "release":
+		return releaseCategory
+	case "operator":
+		return operatorCategory
+	default:
+		return genericCategory
+	}
+}
+
+func addImageMirrors(mirrorsByCategory map[mirrorCategory]categorizedMirrors, category mirrorCategory, source string, imgMirrors []confv1.ImageMirror) {
+	if _, ok := mirrorsByCategory[category]; !ok {
+		mirrorsByCategory[category] = categorizedMirrors{
+			category: category,
+			mirrors:  make(map[string][]confv1.ImageMirror),
+		}
+	}
+	mirrors := mirrorsByCategory[category].mirrors
+	for _, m := range imgMirrors {
+		if !slices.Contains(mirrors[source], m) {
+			mirrors[source] = append(mirrors[source], m)
+		}
+	}
+}
+
+// mergeMirrors merges the mirrors generated for this run with the existing ones:
+// * existing entries are removed when the delete workflow deleted everything they cover
+// * duplicate mirrors are removed
+// * entries already covered by a broader entry (same mirrors, under a parent scope) are removed
+func mergeMirrors(generated, existing []categorizedMirrors, deleted deletedContent) []categorizedMirrors {
+	merged := map[mirrorCategory]categorizedMirrors{}
+	for _, cm := range existing {
+		for source, imgMirrors := range cm.mirrors {
+			if deleted.coversAll(source, generated) {
+				continue
+			}
+			addImageMirrors(merged, cm.category, source, imgMirrors)
+		}
+	}
+	for _, cm := range generated {
+		for source, imgMirrors := range cm.mirrors {
+			addImageMirrors(merged, cm.category, source, imgMirrors)
+		}
+	}
+
+	// a source is redundant when a parent scope, in any category, mirrors it to the same locations
+	for _, cm := range merged {
+		for source, imgMirrors := range cm.mirrors {
+			if isCoveredByParentScope(merged, source, imgMirrors) {
+				delete(cm.mirrors, source)
+			}
+		}
+	}
+
+	mergedList := make([]categorizedMirrors, 0, len(merged))
+	for _, cm := range merged {
+		if len(cm.mirrors) > 0 {
+			mergedList = append(mergedList, cm)
+		}
+	}
+	return mergedList
+}
+
+// coversAll returns true when the delete workflow deleted images under the scope of source, and no other image
+// mirrored by the workspace, or by this run, falls under it.
+// The images mirrored to the same scope by other workspaces are unknown: the delete file must only be provided
+// when the workspace is the only one mirroring to the scopes of its images.
+func (d deletedContent) coversAll(source string, generated []categorizedMirrors) bool {
+	inSource := func(repository string) bool { return isInScope(repository, source) }
+	if !slices.ContainsFunc(d.deleted, inSource) || slices.ContainsFunc(d.remaining, inSource) {
+		return false
+	}
+	for _, cm := range generated {
+		for generatedSource := range cm.mirrors {
+			if isInScope(generatedSource, source) || isInScope(source, generatedSource) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isCoveredByParentScope(mirrorsByCategory map[mirrorCategory]categorizedMirrors, source string, imgMirrors []confv1.ImageMirror) bool {
+	for _, cm := range mirrorsByCategory {
+		for parentSource, parentMirrors := range cm.mirrors {
+			if parentSource == source || !strings.HasPrefix(source, parentSource+"/") {
+				continue
+			}
+			subPath := strings.TrimPrefix(source, parentSource)
+			covered := true
+			for _, m := range imgMirrors {
+				if !slices.ContainsFunc(parentMirrors, func(pm confv1.ImageMirror) bool {
+					return string(pm)+subPath == string(m)
+				}) {
+					covered = false
+					break
+				}
+			}
+			if covered {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package clusterresources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	confv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+const (
+	existingIDMS = `---
+apiVersion: config.openshift.io/v1
+kind: ImageDigestMirrorSet
+metadata:
+  name: idms-operator-0
+spec:
+  imageDigestMirrors:
+  - mirrors:
+    - myregistry/mynamespace/deleted
+    source: quay.io/deleted/operator
+  - mirrors:
+    - myregistry/mynamespace/previous
+    source: quay.io/previous
+  - mirrors:
+    - myregistry/mynamespace/previous/operator
+    source: quay.io/previous/operator
+`
+	existingITMSList = `apiVersion: v1
+kind: List
+items:
+- apiVersion: config.openshift.io/v1
+  kind: ImageTagMirrorSet
+  metadata:
+    name: other-team-itms
+  spec:
+    imageTagMirrors:
+    - mirrors:
+      - otherregistry/ubi8
+      source: registry.redhat.io/ubi8
+`
+	deletedImages = `apiVersion: mirror.openshift.io/v2alpha1
+kind: DeleteImageList
+items:
+- imageName: docker://quay.io/deleted/operator@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea
+  imageReference: docker://myregistry/mynamespace/deleted/operator@sha256:f30638f60452062aba36a26ee6c036feead2f03b28f2c47f2b0a991e41baebea
+  type: operatorRelatedImage
+`
+)
+
+func TestIDMS_ITMSGeneratorWithExistingMirrorSets(t *testing.T) {
+	log := clog.New("trace")
+
+	existingDir := t.TempDir()
+	for name, content := range map[string]string{
+		"idms.yaml":          existingIDMS,
+		"itms.yaml":          existingITMSList,
+		"delete-images.yaml": deletedImages,
+		"README.md":          "not a resource",
+	} {
+		if err := os.WriteFile(filepath.Join(existingDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workingDir := filepath.Join(t.TempDir(), "working-dir")
+	cr := &ClusterResourcesGenerator{
+		Log:                   log,
+		WorkingDir:            workingDir,
+		LocalStorageFQDN:      "localhost:55000",
+		ExistingMirrorSetsDir: existingDir,
+	}
+	err := cr.IDMS_ITMSGenerator(imageListMixed, false)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(workingDir, clusterResourcesDir, idmsFileName))
+	assert.NoError(t, err)
+	idms := string(content)
+	// previously mirrored content is kept
+	assert.Contains(t, idms, "source: quay.io/previous\n")
+	// covered by the quay.io/previous namespace scope
+	assert.NotContains(t, idms, "source: quay.io/previous/operator\n")
+	// removed by the delete workflow
+	assert.NotContains(t, idms, "source: quay.io/deleted/operator\n")
+	// generated by this run
+	assert.Contains(t, idms, "source: quay.io/openshift-community-operators\n")
+
+	content, err = os.ReadFile(filepath.Join(workingDir, clusterResourcesDir, itmsFileName))
+	assert.NoError(t, err)
+	itms := string(content)
+	assert.Contains(t, itms, "- otherregistry/ubi8\n")
+	assert.Contains(t, itms, "- myregistry/mynamespace/ubi8\n")
+	assert.Contains(t, itms, "name: itms-generic-0")
+}
+
+func TestMergeMirrors(t *testing.T) {
+	generated := []categorizedMirrors{
+		{
+			category: operatorCategory,
+			mirrors: map[string][]confv1.ImageMirror{
+				"quay.io/ns":       {"myregistry/ns"},
+				"quay.io/ns/other": {"myregistry/ns/other", "otherregistry/other"},
+			},
+		},
+	}
+	existing := []categorizedMirrors{
+		{
+			category: operatorCategory,
+			mirrors: map[string][]confv1.ImageMirror{
+				"quay.io/ns/repo":     {"myregistry/ns/repo"},
+				"quay.io/deleted/rep": {"myregistry/deleted/rep"},
+				"quay.io/ns":          {"myregistry/ns"},
+				// a tag of quay.io/kept/rep was deleted, but not all its images
+				"quay.io/kept/rep": {"myregistry/kept/rep"},
+				// all the images of the namespace were deleted
+				"quay.io/gone": {"myregistry/gone"},
+			},
+		},
+		{
+			category: releaseCategory,
+			mirrors: map[string][]confv1.ImageMirror{
+				"quay.io/openshift-release-dev": {"myregistry/openshift-release-dev"},
+			},
+		},
+	}
+
+	deleted := deletedContent{
+		deleted:   []string{"quay.io/deleted/rep", "quay.io/kept/rep", "quay.io/gone/rep1", "quay.io/gone/sub/rep2", "quay.io/ns/deleted"},
+		remaining: []string{"quay.io/kept/rep"},
+	}
+	merged := mergeMirrors(generated, existing, deleted)
+	assert.Len(t, merged, 2)
+	for _, cm := range merged {
+		switch cm.category {
+		case operatorCategory:
+			assert.Equal(t, map[string][]confv1.ImageMirror{
+				"quay.io/ns": {"myregistry/ns"},
+				// not fully covered by quay.io/ns
+				"quay.io/ns/other": {"myregistry/ns/other", "otherregistry/other"},
+				"quay.io/kept/rep": {"myregistry/kept/rep"},
+			}, cm.mirrors)
+		case releaseCategory:
+			assert.Equal(t, map[string][]confv1.ImageMirror{
+				"quay.io/openshift-release-dev": {"myregistry/openshift-release-dev"},
+			}, cm.mirrors)
+		default:
+			t.Fatalf("unexpected category %s", cm.category.toString())
+		}
+	}
+}
+
+func TestSetRemaining(t *testing.T) {
+	existing := existingMirrorSets{deletedReferences: map[string]bool{}}
+	deleteList := map[string]interface{}{
+		"kind": "DeleteImageList",
+		"items": []interface{}{
+			map[string]interface{}{
+				"imageName":      "docker://quay.io/ns/rep:v1",
+				"imageReference": "docker://myregistry/ns/rep:v1",
+			},
+		},
+	}
+	assert.NoError(t, existing.add(deleteList, nil, nil))
+	assert.NoError(t, existing.setRemaining([]v2alpha1.MirroredImage{
+		{ImageName: "docker://quay.io/ns/rep:v1", ImageReference: "docker://myregistry/ns/rep:v1"},
+		{ImageName: "docker://quay.io/ns/rep:v2", ImageReference: "docker://myregistry/ns/rep:v2"},
+	}))
+	assert.Equal(t, deletedContent{deleted: []string{"quay.io/ns/rep"}, remaining: []string{"quay.io/ns/rep"}}, existing.deleted)
+	assert.False(t, existing.deleted.coversAll("quay.io/ns", nil))
+}
@@ -226,6 +226,30 @@ func TestLoadConfigDelete(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Delete-Valid/AgePolicy",
+			inline: `
+apiVersion: mirror.openshift.io/v2alpha1
+kind: DeleteImageSetConfiguration
+delete:
+  age:
+    maxAgeDays: 180
+    types:
+    - ocpRelease
+    - generic
+    keepLast: 3
+`,
+			assertion: require.NoError,
+			expConfig: v2alpha1.DeleteImageSetConfigurationSpec{
+				Delete: v2alpha1.Delete{
+					Age: &v2alpha1.AgePolicy{
+						MaxAgeDays: 180,
+						Types:      []v2alpha1.ImageType{v2alpha1.TypeOCPRelease, v2alpha1.TypeGeneric},
+						KeepLast:   3,
+					},
+				},
+			},
+		},
 		{
 			name: "Invalid/UnknownKey",
 			inline: `
@@ -2,18 +2,22 @@ package config
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
 )
 
 type validationFunc func(cfg *v2alpha1.ImageSetConfiguration) []error
 type validationDeleteFunc func(cfg *v2alpha1.DeleteImageSetConfiguration) error
 
-var validationChecks = []validationFunc{validateOperatorOptions, validateReleaseChannels}
-var validationDeleteChecks = []validationDeleteFunc{validateOperatorOptionsDelete, validateReleaseChannelsDelete}
+var validationChecks = []validationFunc{validateOperatorOptions, validateReleaseChannels, validateImagePolicies, validateMirrorScope, validateKustomize, validatePlatformCompatibility}
+var validationDeleteChecks = []validationDeleteFunc{validateOperatorOptionsDelete, validateReleaseChannelsDelete, validateAgePolicyDelete}
 
 // Validate will check an ImagesetConfiguration for input errors.
 func Validate(cfg *v2alpha1.ImageSetConfiguration) error {
@@ -27,29 +31,50 @@ func Validate(cfg *v2alpha1.ImageSetConfiguration) error {
 }
 
 func validateOperatorOptions(cfg *v2alpha1.ImageSetConfiguration) []error {
-	seen := map[string]bool{}
+	seen := map[string][]v2alpha1.Operator{}
 	errs := []error{}
 	for _, ctlg := range cfg.Mirror.Operators {
 		ctlgName, err := ctlg.GetUniqueName()
 		if err != nil {
 			errs = append(errs, err)
 		}
-		if seen[ctlgName] {
+		if slices.ContainsFunc(seen[ctlgName], func(other v2alpha1.Operator) bool { return !isMergedWith(ctlg, other) }) {
 			errs = append(errs, fmt.Errorf(
 				"catalog %q: duplicate found in configuration", ctlgName,
 			))
 		}
+		if ctlg.MergeTargetCatalog && (ctlg.TargetCatalog == "" || ctlg.TargetTag == "") {
+			errs = append(errs, fmt.Errorf("catalog %q: mergeTargetCatalog requires both targetCatalog and targetTag", ctlg.Catalog))
+		}
 		if filterErrs := validateOperatorFiltering(ctlg); len(filterErrs) > 0 {
 			errs = append(errs, filterErrs...)
 		}
 
-		seen[ctlgName] = true
+		seen[ctlgName] = append(seen[ctlgName], ctlg)
 	}
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
+
+// isMergedWith returns true when ctlg and other, sharing the name of their target catalog, are distinct
+// catalogs merged into it. The same catalog twice is a duplicate: their results are stored by reference.
+func isMergedWith(ctlg, other v2alpha1.Operator) bool {
+	if !ctlg.MergeTargetCatalog || !other.MergeTargetCatalog {
+		return false
+	}
+	ctlgSpec, err := image.ParseRef(ctlg.Catalog)
+	if err != nil {
+		return false
+	}
+	otherSpec, err := image.ParseRef(other.Catalog)
+	if err != nil {
+		return false
+	}
+	return ctlgSpec.ReferenceWithTransport != otherSpec.ReferenceWithTransport
+}
+
 func validateOperatorFiltering(ctlg v2alpha1.Operator) []error {
 	errs := []error{}
 	if len(ctlg.Packages) > 0 {
@@ -88,13 +113,58 @@ func validateOperatorFiltering(ctlg v2alpha1.Operator) []error {
 					}
 				}
 			}
+			if pkg.BundleSelector != nil {
+				errs = append(errs, validateBundleSelector(*pkg.BundleSelector, fmt.Sprintf("catalog %q: operator %q", ctlg.Catalog, pkg.Name))...)
+			}
+			if slices.Contains(ctlg.ExcludePackages, pkg.Name) {
+				errs = append(errs, fmt.Errorf("catalog %q: operator %q: cannot be both included and excluded", ctlg.Catalog, pkg.Name))
+			}
+			for _, chFilter := range pkg.Channels {
+				if slices.Contains(pkg.ExcludeChannels, chFilter.Name) {
+					errs = append(errs, fmt.Errorf("catalog %q: operator %q: channel %q cannot be both included and excluded", ctlg.Catalog, pkg.Name, chFilter.Name))
+				}
+			}
 		}
 	}
+	if ctlg.BundleSelector != nil {
+		errs = append(errs, validateBundleSelector(*ctlg.BundleSelector, fmt.Sprintf("catalog %q", ctlg.Catalog))...)
+	}
+	if !ctlg.DeprecatedContent.IsValid() {
+		errs = append(errs, fmt.Errorf("catalog %q: deprecatedContent %q is not one of preserve, warn or skip", ctlg.Catalog, ctlg.DeprecatedContent))
+	}
+	for i, bundle := range ctlg.AdditionalBundles {
+		if bundle.Image == "" {
+			errs = append(errs, fmt.Errorf("catalog %q: additionalBundles[%d]: image is mandatory, it must reference the existing bundle image", ctlg.Catalog, i))
+			continue
+		}
+		if _, err := image.ParseRef(bundle.Image); err != nil {
+			errs = append(errs, fmt.Errorf("catalog %q: additionalBundles[%d]: %w", ctlg.Catalog, i, err))
+		}
+	}
+	if slices.Contains(ctlg.ExcludePackages, "") {
+		errs = append(errs, fmt.Errorf("catalog %q: excludePackages: package name cannot be empty", ctlg.Catalog))
+	}
 	if len(errs) > 0 {
 		return errs
 	}
 	return nil
 }
+
+func validateBundleSelector(selector v2alpha1.BundleSelector, context string) []error {
+	errs := []error{}
+	if selector.OpenShiftVersion != "" {
+		if _, err := semver.NewVersion(selector.OpenShiftVersion); err != nil {
+			errs = append(errs, fmt.Errorf("%s: bundleSelector: openShiftVersion %q must respect semantic versioning notation", context, selector.OpenShiftVersion))
+		}
+	}
+	for _, arch := range selector.Architectures {
+		if arch == "" {
+			errs = append(errs, fmt.Errorf("%s: bundleSelector: architectures cannot be empty", context))
+		}
+	}
+	return errs
+}
+
 func validateReleaseChannels(cfg *v2alpha1.ImageSetConfiguration) []error {
 	seen := map[string]bool{}
 	for _, channel := range cfg.Mirror.Platform.Channels {
@@ -108,6 +178,114 @@ func validateReleaseChannels(cfg *v2alpha1.ImageSetConfiguration) []error {
 	return nil
 }
 
+func validateImagePolicies(cfg *v2alpha1.ImageSetConfiguration) []error {
+	seen := map[string]bool{}
+	errs := []error{}
+	for _, policy := range cfg.Mirror.ImagePolicies {
+		if policy.Name == "" {
+			errs = append(errs, fmt.Errorf("imagePolicies: name is mandatory"))
+			continue
+		}
+		if seen[policy.Namespace+"/"+policy.Name] {
+			errs = append(errs, fmt.Errorf("image policy %q: duplicate found in configuration", policy.Name))
+		}
+		seen[policy.Namespace+"/"+policy.Name] = true
+		switch {
+		case policy.PublicKeyFile != "" && policy.FulcioCAFile != "":
+			errs = append(errs, fmt.Errorf("image policy %q: publicKeyFile and fulcioCAFile cannot be used together", policy.Name))
+		case policy.PublicKeyFile == "" && policy.FulcioCAFile == "":
+			errs = append(errs, fmt.Errorf("image policy %q: either publicKeyFile or fulcioCAFile is mandatory", policy.Name))
+		case policy.FulcioCAFile != "" && (policy.RekorKeyFile == "" || policy.OIDCIssuer == "" || policy.SignedEmail == ""):
+			errs = append(errs, fmt.Errorf("image policy %q: rekorKeyFile, oidcIssuer and signedEmail are mandatory when using fulcioCAFile", policy.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateMirrorScope(cfg *v2alpha1.ImageSetConfiguration) []error {
+	errs := []error{}
+	mirrorScope := cfg.ClusterResources.MirrorScope
+	if !mirrorScope.Aggregation.IsValid() {
+		errs = append(errs, fmt.Errorf("mirrorScope: aggregation %q is not one of repository, namespace or registry", mirrorScope.Aggregation))
+	}
+	for _, override := range mirrorScope.Overrides {
+		if override.Source == "" {
+			errs = append(errs, fmt.Errorf("mirrorScope: override source is mandatory"))
+		}
+		if override.Aggregation == "" || !override.Aggregation.IsValid() {
+			errs = append(errs, fmt.Errorf("mirrorScope: override %q: aggregation %q is not one of repository, namespace or registry", override.Source, override.Aggregation))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateKustomize(cfg *v2alpha1.ImageSetConfiguration) []error {
+	kustomize := cfg.ClusterResources.Kustomize
+	if kustomize == nil {
+		return nil
+	}
+	errs := []error{}
+	if kustomize.NamePrefix != "" {
+		// the prefix must keep the generated names valid DNS subdomains
+		if msgs := validation.IsDNS1123Subdomain(strings.TrimSuffix(kustomize.NamePrefix, "-")); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("kustomize: namePrefix %q is invalid: %s", kustomize.NamePrefix, strings.Join(msgs, ", ")))
+		}
+	}
+	for key, value := range kustomize.Labels {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("kustomize: label key %q is invalid: %s", key, strings.Join(msgs, ", ")))
+		}
+		if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("kustomize: label %q value %q is invalid: %s", key, value, strings.Join(msgs, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validatePlatformCompatibility(cfg *v2alpha1.ImageSetConfiguration) []error {
+	errs := []error{}
+	for _, ctlg := range cfg.Mirror.Operators {
+		compatibility := ctlg.PlatformCompatibility
+		if compatibility == nil {
+			continue
+		}
+		if compatibility.MinOpenShiftVersion == "" && compatibility.MaxOpenShiftVersion == "" {
+			if len(cfg.Mirror.Platform.Channels) == 0 {
+				errs = append(errs, fmt.Errorf("catalog %q: platformCompatibility: minOpenShiftVersion or maxOpenShiftVersion must be set when no platform channel is mirrored", ctlg.Catalog))
+			}
+			continue
+		}
+		var minVersion, maxVersion *semver.Version
+		var err error
+		if compatibility.MinOpenShiftVersion != "" {
+			if minVersion, err = semver.NewVersion(compatibility.MinOpenShiftVersion); err != nil {
+				errs = append(errs, fmt.Errorf("catalog %q: platformCompatibility: minOpenShiftVersion %q must respect semantic versioning notation", ctlg.Catalog, compatibility.MinOpenShiftVersion))
+			}
+		}
+		if compatibility.MaxOpenShiftVersion != "" {
+			if maxVersion, err = semver.NewVersion(compatibility.MaxOpenShiftVersion); err != nil {
+				errs = append(errs, fmt.Errorf("catalog %q: platformCompatibility: maxOpenShiftVersion %q must respect semantic versioning notation", ctlg.Catalog, compatibility.MaxOpenShiftVersion))
+			}
+		}
+		if minVersion != nil && maxVersion != nil && minVersion.GreaterThan(maxVersion) {
+			errs = append(errs, fmt.Errorf("catalog %q: platformCompatibility: minOpenShiftVersion %q is greater than maxOpenShiftVersion %q", ctlg.Catalog, compatibility.MinOpenShiftVersion, compatibility.MaxOpenShiftVersion))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 // ValidateDelete will check an DeleteImagesetConfiguration for input errors.
 func ValidateDelete(cfg *v2alpha1.DeleteImageSetConfiguration) error {
 	var errs []error
@@ -148,3 +326,25 @@ func validateReleaseChannelsDelete(cfg *v2alpha1.DeleteImageSetConfiguration) er
 	}
 	return nil
 }
+
+func validateAgePolicyDelete(cfg *v2alpha1.DeleteImageSetConfiguration) error {
+	age := cfg.Delete.Age
+	if age == nil {
+		return nil
+	}
+	if age.MaxAgeDays <= 0 {
+		return fmt.Errorf("age: maxAgeDays must be greater than 0")
+	}
+	if age.KeepLast < 0 {
+		return fmt.Errorf("age: keepLast must not be negative")
+	}
+	for i, t := range age.Types {
+		if t.String() == "" {
+			return fmt.Errorf("age: types[%d]: unknown image type", i)
+		}
+		if !slices.Contains(v2alpha1.AgePolicyTypes, t) {
+			return fmt.Errorf("age: types[%d]: %s images cannot be deleted by age, only ocpRelease, generic and helmImage images can", i, t)
+		}
+	}
+	return nil
+}
@@ -275,6 +275,403 @@ func TestValidate(t *testing.T) {
 			},
 			expError: "invalid configuration: release channel \"channel\": duplicate found in configuration",
 		},
+		{
+			name: "Valid/ImagePolicyWithPublicKey",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name:          "release",
+								PublicKeyFile: "/tmp/key.pub",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/ImagePolicyWithoutKey",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name: "release",
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: image policy \"release\": either publicKeyFile or fulcioCAFile is mandatory",
+		},
+		{
+			name: "Invalid/ImagePolicyFulcioWithoutRekor",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						ImagePolicies: []v2alpha1.ImagePolicy{
+							{
+								Name:         "operators",
+								FulcioCAFile: "/tmp/fulcio.pem",
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: image policy \"operators\": rekorKeyFile, oidcIssuer and signedEmail are mandatory when using fulcioCAFile",
+		},
+		{
+			name: "Valid/BundleSelector",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								BundleSelector: &v2alpha1.BundleSelector{
+									OpenShiftVersion: "4.16",
+									Annotations:      map[string]string{"features.operators.openshift.io/disconnected": "true"},
+									Architectures:    []string{"amd64", "arm64"},
+								},
+								IncludeConfig: v2alpha1.IncludeConfig{
+									Packages: []v2alpha1.IncludePackage{
+										{
+											Name:           "foo",
+											BundleSelector: &v2alpha1.BundleSelector{ExcludeDeprecated: true},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/BundleSelectorOpenShiftVersion",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								IncludeConfig: v2alpha1.IncludeConfig{
+									Packages: []v2alpha1.IncludePackage{
+										{
+											Name:           "foo",
+											BundleSelector: &v2alpha1.BundleSelector{OpenShiftVersion: "latest"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": operator \"foo\": bundleSelector: openShiftVersion \"latest\" must respect semantic versioning notation",
+		},
+		{
+			name: "Valid/ExcludePackages",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:         "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								Full:            true,
+								ExcludePackages: []string{"bar"},
+								IncludeConfig: v2alpha1.IncludeConfig{
+									Packages: []v2alpha1.IncludePackage{
+										{
+											Name:            "foo",
+											ExcludeChannels: []string{"beta"},
+											ExcludeBundles:  []string{"foo.v1.0.0"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/ExcludeIncludedPackage",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:         "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								ExcludePackages: []string{"foo"},
+								IncludeConfig: v2alpha1.IncludeConfig{
+									Packages: []v2alpha1.IncludePackage{
+										{
+											Name: "foo",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": operator \"foo\": cannot be both included and excluded",
+		},
+		{
+			name: "Invalid/ExcludeIncludedChannel",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								IncludeConfig: v2alpha1.IncludeConfig{
+									Packages: []v2alpha1.IncludePackage{
+										{
+											Name:            "foo",
+											Channels:        []v2alpha1.IncludeChannel{{Name: "stable"}},
+											ExcludeChannels: []string{"stable"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": operator \"foo\": channel \"stable\" cannot be both included and excluded",
+		},
+		{
+			name: "Valid/PlatformCompatibilityFromPlatformChannels",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Platform: v2alpha1.Platform{
+							Channels: []v2alpha1.ReleaseChannel{{Name: "stable-4.16"}},
+						},
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:               "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								PlatformCompatibility: &v2alpha1.PlatformCompatibility{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/PlatformCompatibilityNoVersion",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:               "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								PlatformCompatibility: &v2alpha1.PlatformCompatibility{},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": platformCompatibility: minOpenShiftVersion or maxOpenShiftVersion must be set when no platform channel is mirrored",
+		},
+		{
+			name: "Invalid/PlatformCompatibilityRange",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog: "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								PlatformCompatibility: &v2alpha1.PlatformCompatibility{
+									MinOpenShiftVersion: "4.17",
+									MaxOpenShiftVersion: "4.16",
+								},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": platformCompatibility: minOpenShiftVersion \"4.17\" is greater than maxOpenShiftVersion \"4.16\"",
+		},
+		{
+			name: "Invalid/DeprecatedContentMode",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:           "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								DeprecatedContent: "ignore",
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": deprecatedContent \"ignore\" is not one of preserve, warn or skip",
+		},
+		{
+			name: "Invalid/MergeTargetCatalogWithoutTargetTag",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:            "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								TargetCatalog:      "mirror/operator-index",
+								MergeTargetCatalog: true,
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": mergeTargetCatalog requires both targetCatalog and targetTag",
+		},
+		{
+			name: "Valid/MergeTargetCatalog",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:            "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								TargetCatalog:      "mirror/operator-index",
+								TargetTag:          "v4.16",
+								MergeTargetCatalog: true,
+							},
+							{
+								Catalog:            "registry.redhat.io/redhat/certified-operator-index:v4.16",
+								TargetCatalog:      "mirror/operator-index",
+								TargetTag:          "v4.16",
+								MergeTargetCatalog: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/MergeTargetCatalogSameCatalog",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:            "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								TargetCatalog:      "mirror/operator-index",
+								TargetTag:          "v4.16",
+								MergeTargetCatalog: true,
+							},
+							{
+								Catalog:            "docker://registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								TargetCatalog:      "mirror/operator-index",
+								TargetTag:          "v4.16",
+								MergeTargetCatalog: true,
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/mirror/operator-index:v4.16\": duplicate found in configuration",
+		},
+		{
+			name: "Invalid/MergeTargetCatalogWithNotMergedCatalog",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:       "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								TargetCatalog: "mirror/operator-index",
+								TargetTag:     "v4.16",
+							},
+							{
+								Catalog:            "registry.redhat.io/redhat/certified-operator-index:v4.16",
+								TargetCatalog:      "mirror/operator-index",
+								TargetTag:          "v4.16",
+								MergeTargetCatalog: true,
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/mirror/operator-index:v4.16\": duplicate found in configuration",
+		},
+		{
+			name: "Invalid/AdditionalBundleWithoutImage",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					Mirror: v2alpha1.Mirror{
+						Operators: []v2alpha1.Operator{
+							{
+								Catalog:           "registry.redhat.io/redhat/redhat-operator-index:v4.16",
+								AdditionalBundles: []v2alpha1.AdditionalBundle{{Path: "/bundles/inhouse-operator"}},
+							},
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: catalog \"registry.redhat.io/redhat/redhat-operator-index:v4.16\": additionalBundles[0]: image is mandatory, it must reference the existing bundle image",
+		},
+		{
+			name: "Valid/MirrorScopeWithOverrides",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					ClusterResources: v2alpha1.ClusterResources{
+						MirrorScope: v2alpha1.MirrorScope{
+							Aggregation: v2alpha1.RegistryScope,
+							Overrides: []v2alpha1.MirrorScopeOverride{
+								{
+									Source:      "quay.io/openshift-release-dev",
+									Aggregation: v2alpha1.RepositoryScope,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/MirrorScopeAggregation",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					ClusterResources: v2alpha1.ClusterResources{
+						MirrorScope: v2alpha1.MirrorScope{
+							Aggregation: "cluster",
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: mirrorScope: aggregation \"cluster\" is not one of repository, namespace or registry",
+		},
+		{
+			name: "Valid/Kustomize",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					ClusterResources: v2alpha1.ClusterResources{
+						Kustomize: &v2alpha1.Kustomize{
+							NamePrefix: "disconnected-",
+							Labels:     map[string]string{"app.kubernetes.io/managed-by": "oc-mirror"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Invalid/KustomizeNamePrefix",
+			config: &v2alpha1.ImageSetConfiguration{
+				ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+					ClusterResources: v2alpha1.ClusterResources{
+						Kustomize: &v2alpha1.Kustomize{
+							NamePrefix: "Disconnected_",
+						},
+					},
+				},
+			},
+			expError: "invalid configuration: kustomize: namePrefix \"Disconnected_\" is invalid: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
+		},
 	}
 
 	for _, c := range cases {
@@ -288,3 +685,48 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDelete(t *testing.T) {
+	type spec struct {
+		name     string
+		age      *v2alpha1.AgePolicy
+		expError string
+	}
+
+	cases := []spec{
+		{
+			name: "Valid/AgePolicy",
+			age:  &v2alpha1.AgePolicy{MaxAgeDays: 180, Types: []v2alpha1.ImageType{v2alpha1.TypeOCPRelease}, KeepLast: 2},
+		},
+		{
+			name:     "Invalid/AgePolicyWithoutMaxAge",
+			age:      &v2alpha1.AgePolicy{KeepLast: 2},
+			expError: "invalid configuration: age: maxAgeDays must be greater than 0",
+		},
+		{
+			name:     "Invalid/AgePolicyUnknownType",
+			age:      &v2alpha1.AgePolicy{MaxAgeDays: 180, Types: []v2alpha1.ImageType{v2alpha1.TypeInvalid}},
+			expError: "invalid configuration: age: types[0]: unknown image type",
+		},
+		{
+			name:     "Invalid/AgePolicyOperatorType",
+			age:      &v2alpha1.AgePolicy{MaxAgeDays: 180, Types: []v2alpha1.ImageType{v2alpha1.TypeOCPRelease, v2alpha1.TypeOperatorBundle}},
+			expError: "invalid configuration: age: types[1]: operatorBundle images cannot be deleted by age, only ocpRelease, generic and helmImage images can",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := &v2alpha1.DeleteImageSetConfiguration{
+				DeleteImageSetConfigurationSpec: v2alpha1.DeleteImageSetConfigurationSpec{
+					Delete: v2alpha1.Delete{Age: c.age},
+				},
+			}
+			err := ValidateDelete(cfg)
+			if c.expError != "" {
+				require.EqualError(t, err, c.expError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package delete
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+)
+
+// ImagesByAge returns the images mirrored to destination that policy selects: the ones of the
+// AgePolicyTypes last mirrored more than MaxAgeDays before now, except the KeepLast images last
+// mirrored to each repository
+func ImagesByAge(records []v2alpha1.MirroredImage, policy v2alpha1.AgePolicy, destination string, now time.Time) []v2alpha1.CopyImageSchema {
+	types := policy.Types
+	if len(types) == 0 {
+		types = v2alpha1.AgePolicyTypes
+	}
+	byRepository := map[string][]v2alpha1.MirroredImage{}
+	for _, r := range records {
+		if !strings.HasPrefix(r.ImageReference, strings.TrimSuffix(destination, "/")+"/") {
+			continue
+		}
+		if !slices.Contains(types, r.Type) || !slices.Contains(v2alpha1.AgePolicyTypes, r.Type) {
+			continue
+		}
+		imgSpec, err := image.ParseRef(r.ImageReference)
+		if err != nil {
+			continue
+		}
+		byRepository[imgSpec.Name] = append(byRepository[imgSpec.Name], r)
+	}
+
+	limit := now.AddDate(0, 0, -policy.MaxAgeDays)
+	var images []v2alpha1.CopyImageSchema
+	for _, repoRecords := range byRepository {
+		// most recently mirrored first
+		sort.SliceStable(repoRecords, func(i, j int) bool { return repoRecords[i].LastMirrored.After(repoRecords[j].LastMirrored) })
+		for i, r := range repoRecords {
+			if i < policy.KeepLast || !r.LastMirrored.Before(limit) {
+				continue
+			}
+			images = append(images, v2alpha1.CopyImageSchema{
+				Origin:      r.ImageName,
+				Destination: r.ImageReference,
+				Type:        r.Type,
+			})
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Destination < images[j].Destination })
+	return images
+}
@@ -0,0 +1,51 @@
+package delete
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+func TestImagesByAge(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	release := func(version string, lastMirrored time.Time) v2alpha1.MirroredImage {
+		return v2alpha1.MirroredImage{
+			ImageName:      "quay.io/openshift-release-dev/ocp-release:" + version,
+			ImageReference: "docker://myregistry/openshift/release-images:" + version,
+			Type:           v2alpha1.TypeOCPRelease,
+			LastMirrored:   lastMirrored,
+		}
+	}
+	records := []v2alpha1.MirroredImage{
+		release("4.14.1-x86_64", daysAgo(400)),
+		release("4.14.2-x86_64", daysAgo(300)),
+		release("4.15.1-x86_64", daysAgo(200)),
+		release("4.16.1-x86_64", daysAgo(10)),
+		{ImageName: "registry.redhat.io/ubi8/ubi:8.6", ImageReference: "docker://myregistry/ubi8/ubi:8.6", Type: v2alpha1.TypeGeneric, LastMirrored: daysAgo(365)},
+		{ImageName: "registry.redhat.io/ubi8/ubi:8.6", ImageReference: "docker://otherregistry/ubi8/ubi:8.6", Type: v2alpha1.TypeGeneric, LastMirrored: daysAgo(365)},
+		{ImageName: "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:0a1b2c", ImageReference: "docker://myregistry/openshift/release:4.14.1-x86_64-etcd", Type: v2alpha1.TypeOCPReleaseContent, LastMirrored: daysAgo(400)},
+		{ImageName: "registry.redhat.io/foo/foo-bundle:v1.0.0", ImageReference: "docker://myregistry/foo/foo-bundle:v1.0.0", Type: v2alpha1.TypeOperatorBundle, LastMirrored: daysAgo(400)},
+	}
+	toCopy := func(r v2alpha1.MirroredImage) v2alpha1.CopyImageSchema {
+		return v2alpha1.CopyImageSchema{Origin: r.ImageName, Destination: r.ImageReference, Type: r.Type}
+	}
+
+	t.Run("Testing ImagesByAge - max age : should return the images of the destination mirrored before, except the release components and the operator images", func(t *testing.T) {
+		images := ImagesByAge(records, v2alpha1.AgePolicy{MaxAgeDays: 180}, "docker://myregistry", now)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{toCopy(records[0]), toCopy(records[1]), toCopy(records[2]), toCopy(records[4])}, images)
+	})
+
+	t.Run("Testing ImagesByAge - types and keep last : should keep the last images of each repository", func(t *testing.T) {
+		images := ImagesByAge(records, v2alpha1.AgePolicy{MaxAgeDays: 180, Types: []v2alpha1.ImageType{v2alpha1.TypeOCPRelease}, KeepLast: 2}, "docker://myregistry/", now)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{toCopy(records[0]), toCopy(records[1])}, images)
+	})
+
+	t.Run("Testing ImagesByAge - operator type : should not return the operator images", func(t *testing.T) {
+		images := ImagesByAge(records, v2alpha1.AgePolicy{MaxAgeDays: 180, Types: []v2alpha1.ImageType{v2alpha1.TypeOperatorBundle}}, "docker://myregistry", now)
+		assert.Empty(t, images)
+	})
+}
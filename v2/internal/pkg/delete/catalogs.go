@@ -0,0 +1,113 @@
+package delete
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/operator"
+)
+
+// RemainingCatalog returns current without the bundles of deleted.
+// The channel entries of the deleted bundles are removed, and the entries replacing them are rewired
+// like the bundles removed by the filters, so that each channel keeps a single head.
+// The channels, the packages and the deprecations left without bundle are removed too.
+func RemainingCatalog(current, deleted declcfg.DeclarativeConfig) declcfg.DeclarativeConfig {
+	deletedBundles := map[string]bool{}
+	for _, b := range deleted.Bundles {
+		deletedBundles[b.Package+"/"+b.Name] = true
+	}
+
+	var remaining declcfg.DeclarativeConfig
+	remainingBundles := map[string]bool{}
+	remainingPackages := map[string]bool{}
+	for _, b := range current.Bundles {
+		if deletedBundles[b.Package+"/"+b.Name] {
+			continue
+		}
+		remaining.Bundles = append(remaining.Bundles, b)
+		remainingBundles[b.Package+"/"+b.Name] = true
+		remainingPackages[b.Package] = true
+	}
+
+	remainingChannels := map[string][]string{}
+	for _, ch := range current.Channels {
+		removed := map[string]bool{}
+		for _, entry := range ch.Entries {
+			if !remainingBundles[ch.Package+"/"+entry.Name] {
+				removed[entry.Name] = true
+			}
+		}
+		entries := operator.RewireChannelEntries(ch.Entries, removed)
+		if len(entries) == 0 {
+			continue
+		}
+		ch.Entries = entries
+		remaining.Channels = append(remaining.Channels, ch)
+		remainingChannels[ch.Package] = append(remainingChannels[ch.Package], ch.Name)
+	}
+
+	for _, pkg := range current.Packages {
+		if !remainingPackages[pkg.Name] {
+			continue
+		}
+		// the default channel must exist: the first remaining one replaces it when all its bundles are deleted
+		if channels := remainingChannels[pkg.Name]; len(channels) > 0 && !slices.Contains(channels, pkg.DefaultChannel) {
+			slices.Sort(channels)
+			pkg.DefaultChannel = channels[0]
+		}
+		remaining.Packages = append(remaining.Packages, pkg)
+	}
+	for _, d := range current.Deprecations {
+		if remainingPackages[d.Package] {
+			remaining.Deprecations = append(remaining.Deprecations, d)
+		}
+	}
+	for _, m := range current.Others {
+		if len(m.Package) == 0 || remainingPackages[m.Package] {
+			remaining.Others = append(remaining.Others, m)
+		}
+	}
+	return remaining
+}
+
+// RemainingCatalogTag returns the tag of the catalog rebuilt with the bundles of remaining.
+// It only depends on the bundles, so that deleting the same operators twice rebuilds the same catalog.
+func RemainingCatalogTag(remaining declcfg.DeclarativeConfig) string {
+	bundles := []string{}
+	for _, b := range remaining.Bundles {
+		bundles = append(bundles, b.Package+"/"+b.Name)
+	}
+	slices.Sort(bundles)
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(bundles, ","))))
+}
+
+// RebuiltCatalogs returns the digests of the catalogs rebuilt from the filtered catalogs of filteredCatalogsDir,
+// by tag: each filtered catalog is in a directory named after the tag of the rebuilt catalog,
+// along with a digest file once the catalog is rebuilt
+func RebuiltCatalogs(filteredCatalogsDir string) (map[string]string, error) {
+	entries, err := os.ReadDir(filteredCatalogsDir)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rebuilt := map[string]string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(filteredCatalogsDir, entry.Name(), "digest"))
+		if err != nil {
+			continue
+		}
+		rebuilt[entry.Name()] = strings.TrimPrefix(strings.TrimSpace(string(d)), "sha256:")
+	}
+	return rebuilt, nil
+}
@@ -0,0 +1,183 @@
+package delete
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+func TestRemainingCatalog(t *testing.T) {
+	current := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{
+			{Name: "op1", DefaultChannel: "stable"},
+			{Name: "op2", DefaultChannel: "stable"},
+		},
+		Channels: []declcfg.Channel{
+			{Name: "stable", Package: "op1", Entries: []declcfg.ChannelEntry{{Name: "op1.v1"}, {Name: "op1.v2", Replaces: "op1.v1"}}},
+			{Name: "fast", Package: "op1", Entries: []declcfg.ChannelEntry{{Name: "op1.v3"}}},
+			{Name: "stable", Package: "op2", Entries: []declcfg.ChannelEntry{{Name: "op2.v1"}}},
+		},
+		Bundles: []declcfg.Bundle{
+			{Name: "op1.v1", Package: "op1"},
+			{Name: "op1.v2", Package: "op1"},
+			{Name: "op1.v3", Package: "op1"},
+			{Name: "op2.v1", Package: "op2"},
+		},
+		Deprecations: []declcfg.Deprecation{{Package: "op1"}, {Package: "op2"}},
+	}
+
+	t.Run("Testing RemainingCatalog - some bundles deleted : should remove them and the emptied content", func(t *testing.T) {
+		deleted := declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{
+			{Name: "op1.v1", Package: "op1"},
+			{Name: "op1.v2", Package: "op1"},
+			{Name: "op2.v1", Package: "op2"},
+		}}
+		remaining := RemainingCatalog(current, deleted)
+		assert.Equal(t, []declcfg.Bundle{{Name: "op1.v3", Package: "op1"}}, remaining.Bundles)
+		assert.Equal(t, []declcfg.Channel{{Name: "fast", Package: "op1", Entries: []declcfg.ChannelEntry{{Name: "op1.v3"}}}}, remaining.Channels)
+		assert.Equal(t, []declcfg.Package{{Name: "op1", DefaultChannel: "fast"}}, remaining.Packages)
+		assert.Equal(t, []declcfg.Deprecation{{Package: "op1"}}, remaining.Deprecations)
+	})
+
+	t.Run("Testing RemainingCatalog - middle bundle deleted : should rewire the channel to a single head", func(t *testing.T) {
+		catalog := declcfg.DeclarativeConfig{
+			Packages: []declcfg.Package{{Name: "op1", DefaultChannel: "stable"}},
+			Channels: []declcfg.Channel{{Name: "stable", Package: "op1", Entries: []declcfg.ChannelEntry{
+				{Name: "op1.v1"},
+				{Name: "op1.v2", Replaces: "op1.v1"},
+				{Name: "op1.v3", Replaces: "op1.v2"},
+			}}},
+			Bundles: []declcfg.Bundle{
+				{Name: "op1.v1", Package: "op1"},
+				{Name: "op1.v2", Package: "op1"},
+				{Name: "op1.v3", Package: "op1"},
+			},
+		}
+		deleted := declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{{Name: "op1.v2", Package: "op1"}}}
+		remaining := RemainingCatalog(catalog, deleted)
+		assert.Equal(t, []declcfg.ChannelEntry{
+			{Name: "op1.v1"},
+			{Name: "op1.v3", Replaces: "op1.v1", Skips: []string{"op1.v2"}},
+		}, remaining.Channels[0].Entries)
+		assert.Equal(t, []declcfg.ChannelEntry{{Name: "op1.v1"}, {Name: "op1.v2", Replaces: "op1.v1"}, {Name: "op1.v3", Replaces: "op1.v2"}}, catalog.Channels[0].Entries)
+	})
+
+	t.Run("Testing RemainingCatalog - all bundles deleted : should be empty", func(t *testing.T) {
+		remaining := RemainingCatalog(current, current)
+		assert.Empty(t, remaining.Bundles)
+		assert.Empty(t, remaining.Channels)
+		assert.Empty(t, remaining.Packages)
+	})
+
+	t.Run("Testing RemainingCatalogTag : should only depend on the bundles", func(t *testing.T) {
+		reordered := declcfg.DeclarativeConfig{Bundles: []declcfg.Bundle{current.Bundles[3], current.Bundles[0], current.Bundles[2], current.Bundles[1]}}
+		assert.Equal(t, RemainingCatalogTag(current), RemainingCatalogTag(reordered))
+		assert.Len(t, RemainingCatalogTag(current), 32)
+		assert.NotEqual(t, RemainingCatalogTag(current), RemainingCatalogTag(RemainingCatalog(current, reordered)))
+	})
+}
+
+func TestRebuiltCatalogs(t *testing.T) {
+	t.Run("Testing RebuiltCatalogs : should return the digests of the rebuilt catalogs by tag", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "4d3a1c0e9f5b7e2d6c8a0b1f3e5d7c9a", "catalog-config"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "4d3a1c0e9f5b7e2d6c8a0b1f3e5d7c9a", "digest"), []byte("abcdef"), 0644))
+		// filtered, but never rebuilt
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b", "catalog-config"), 0755))
+
+		rebuilt, err := RebuiltCatalogs(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"4d3a1c0e9f5b7e2d6c8a0b1f3e5d7c9a": "abcdef"}, rebuilt)
+	})
+
+	t.Run("Testing RebuiltCatalogs - no filtered catalog : should return no catalog", func(t *testing.T) {
+		rebuilt, err := RebuiltCatalogs(filepath.Join(t.TempDir(), "filtered-catalogs"))
+		assert.NoError(t, err)
+		assert.Empty(t, rebuilt)
+	})
+}
+
+func TestDeleteRegistryImagesWithCatalogs(t *testing.T) {
+	log := clog.New("trace")
+	list := v2alpha1.DeleteImageList{
+		Catalogs: []v2alpha1.DeleteCatalog{
+			{ImageName: "docker://registry.redhat.io/redhat/redhat-operator-index:v4.16", ImageReference: "docker://localhost:5000/myregistry/redhat/redhat-operator-index:v4.16", ReplacementTag: "0f1e2d3c4b5a69788796a5b4c3d2e1f0"},
+			{ImageName: "docker://registry.redhat.io/redhat/certified-operator-index:v4.16", ImageReference: "docker://localhost:5000/myregistry/redhat/certified-operator-index:v4.16", CacheTags: []string{"4d3a1c0e9f5b7e2d6c8a0b1f3e5d7c9a"}},
+		},
+	}
+
+	global := &mirror.GlobalOptions{DeleteDestination: "docker://localhost:5000/myregistry", ForceCacheDelete: true}
+	_, sharedOpts := mirror.SharedImageFlags()
+	_, deprecatedTLSVerifyOpt := mirror.DeprecatedTLSVerifyFlags()
+	_, srcOpts := mirror.ImageSrcFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "src-", "screds")
+
+	t.Run("Testing DeleteRegistryImages - catalogs : should replace the catalogs before deleting the emptied ones", func(t *testing.T) {
+		batch := &recordingBatch{}
+		srcOpts.TlsVerify = true
+		opts := mirror.CopyOptions{
+			Global:           global,
+			SrcImage:         srcOpts,
+			Function:         string(mirror.DeleteMode),
+			LocalStorageFQDN: "localhost:55000",
+		}
+		di := New(log, opts, batch, &mockBlobs{}, v2alpha1.ImageSetConfiguration{}, &mockManifest{}, "/tmp")
+		assert.NoError(t, di.DeleteRegistryImages(list))
+
+		assert.Len(t, batch.calls, 2)
+		assert.Equal(t, string(mirror.CopyMode), batch.calls[0].function)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{{
+			Origin:      "docker://registry.redhat.io/redhat/redhat-operator-index:v4.16",
+			Source:      "docker://localhost:55000/redhat/redhat-operator-index:0f1e2d3c4b5a69788796a5b4c3d2e1f0",
+			Destination: "docker://localhost:5000/myregistry/redhat/redhat-operator-index:v4.16",
+			Type:        v2alpha1.TypeOperatorCatalog,
+		}}, batch.calls[0].images)
+		assert.False(t, batch.calls[0].tlsVerify)
+		assert.Equal(t, string(mirror.DeleteMode), batch.calls[1].function)
+		assert.True(t, batch.calls[1].tlsVerify)
+		assert.True(t, srcOpts.TlsVerify)
+		var deleted []string
+		for _, img := range batch.calls[1].images {
+			deleted = append(deleted, img.Destination)
+		}
+		assert.Equal(t, []string{
+			"docker://localhost:5000/myregistry/redhat/certified-operator-index:v4.16",
+			"docker://localhost:55000/redhat/certified-operator-index:v4.16",
+			"docker://localhost:55000/redhat/certified-operator-index:4d3a1c0e9f5b7e2d6c8a0b1f3e5d7c9a",
+		}, deleted)
+	})
+
+	t.Run("Testing DeleteRegistryImages - catalog of another destination : should fail", func(t *testing.T) {
+		opts := mirror.CopyOptions{
+			Global:           &mirror.GlobalOptions{DeleteDestination: "docker://localhost:5000/other"},
+			SrcImage:         srcOpts,
+			Function:         string(mirror.DeleteMode),
+			LocalStorageFQDN: "localhost:55000",
+		}
+		di := New(log, opts, &recordingBatch{}, &mockBlobs{}, v2alpha1.ImageSetConfiguration{}, &mockManifest{}, "/tmp")
+		assert.ErrorContains(t, di.DeleteRegistryImages(list), "delete destination docker://localhost:5000/other does not match the catalog")
+	})
+}
+
+type batchCall struct {
+	function  string
+	tlsVerify bool
+	images    []v2alpha1.CopyImageSchema
+}
+
+// recordingBatch records the images of each call to Worker
+type recordingBatch struct {
+	calls []batchCall
+}
+
+func (o *recordingBatch) Worker(ctx context.Context, collectorSchema v2alpha1.CollectorSchema, opts mirror.CopyOptions) (v2alpha1.CollectorSchema, error) {
+	o.calls = append(o.calls, batchCall{function: opts.Function, tlsVerify: opts.SrcImage.TlsVerify, images: collectorSchema.AllImages})
+	return collectorSchema, nil
+}
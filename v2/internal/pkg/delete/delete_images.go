@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/containers/image/v5/types"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/archive"
 	"github.com/openshift/oc-mirror/v2/internal/pkg/batch"
@@ -35,8 +36,9 @@ type DeleteImages struct {
 	LocalStorageFQDN string
 }
 
-// WriteDeleteMetaData
-func (o DeleteImages) WriteDeleteMetaData(images []v2alpha1.CopyImageSchema) error {
+// WriteDeleteMetaData writes the delete file listing images, except the ones still referenced by
+// the kept images, which are listed as protected, and the catalogs the deleted operators are removed from
+func (o DeleteImages) WriteDeleteMetaData(images []v2alpha1.CopyImageSchema, kept []v2alpha1.CopyImageSchema, catalogs []v2alpha1.DeleteCatalog) error {
 	o.Log.Info(emoji.PageFacingUp + " Generating delete file...")
 	o.Log.Info("%s file created", o.Opts.Global.WorkingDir+deleteDir)
 
@@ -54,31 +56,18 @@ func (o DeleteImages) WriteDeleteMetaData(images []v2alpha1.CopyImageSchema) err
 		o.Log.Error("%v ", err)
 	}
 
-	duplicates := []string{}
-	var items []v2alpha1.DeleteItem
-	for _, img := range images {
-		if slices.Contains(duplicates, img.Origin) {
-			o.Log.Debug("duplicate image found %s", img.Origin)
-		} else {
-			duplicates = append(duplicates, img.Origin)
-			item := v2alpha1.DeleteItem{
-				ImageName:      img.Origin,
-				ImageReference: img.Destination,
-				Type:           img.Type,
-			}
-			items = append(items, item)
-		}
+	toDelete, protected := o.splitKeptImages(context.Background(), images, kept)
+	for _, img := range protected {
+		o.Log.Info("%s is still referenced by a kept image set: not deleted", img.Destination)
 	}
 
-	// sort the items
-	sort.SliceStable(items, func(i, j int) bool {
-		return items[i].ImageReference < items[j].ImageReference
-	})
 	// marshal to yaml and write to file
 	deleteImageList := v2alpha1.DeleteImageList{
 		Kind:       "DeleteImageList",
 		APIVersion: "mirror.openshift.io/v2alpha1",
-		Items:      items,
+		Items:      o.deleteItems(toDelete),
+		Protected:  o.deleteItems(protected),
+		Catalogs:   catalogs,
 	}
 	ymlData, err := yaml.Marshal(deleteImageList)
 	if err != nil {
@@ -114,6 +103,98 @@ func (o DeleteImages) WriteDeleteMetaData(images []v2alpha1.CopyImageSchema) err
 	return nil
 }
 
+// deleteItems returns the sorted delete items of images, without duplicates
+func (o DeleteImages) deleteItems(images []v2alpha1.CopyImageSchema) []v2alpha1.DeleteItem {
+	duplicates := []string{}
+	var items []v2alpha1.DeleteItem
+	for _, img := range images {
+		if slices.Contains(duplicates, img.Origin) {
+			o.Log.Debug("duplicate image found %s", img.Origin)
+		} else {
+			duplicates = append(duplicates, img.Origin)
+			item := v2alpha1.DeleteItem{
+				ImageName:      img.Origin,
+				ImageReference: img.Destination,
+				Type:           img.Type,
+			}
+			items = append(items, item)
+		}
+	}
+
+	// sort the items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ImageReference < items[j].ImageReference
+	})
+	return items
+}
+
+// splitKeptImages splits images between the images to delete and the images still referenced by kept.
+// An image is still referenced when a kept image has the same destination, or when a kept image of the same
+// repository has the same manifest: deleting a manifest deletes all the tags referencing it.
+// The blobs shared with kept images are only removed by the garbage collection of the registry,
+// once no manifest references them.
+func (o DeleteImages) splitKeptImages(ctx context.Context, images, kept []v2alpha1.CopyImageSchema) ([]v2alpha1.CopyImageSchema, []v2alpha1.CopyImageSchema) {
+	if len(kept) == 0 {
+		return images, nil
+	}
+	keptDestinations := map[string]bool{}
+	keptByRepository := map[string][]v2alpha1.CopyImageSchema{}
+	for _, img := range kept {
+		keptDestinations[img.Destination] = true
+		if imgSpec, err := image.ParseRef(img.Destination); err == nil {
+			keptByRepository[imgSpec.Name] = append(keptByRepository[imgSpec.Name], img)
+		}
+	}
+
+	digests := map[string]string{}
+	var toDelete, protected []v2alpha1.CopyImageSchema
+	for _, img := range images {
+		if keptDestinations[img.Destination] {
+			protected = append(protected, img)
+			continue
+		}
+		imgSpec, err := image.ParseRef(img.Destination)
+		if err != nil || len(keptByRepository[imgSpec.Name]) == 0 {
+			toDelete = append(toDelete, img)
+			continue
+		}
+		imgDigest := o.manifestDigest(ctx, img, digests)
+		if imgDigest != "" && slices.ContainsFunc(keptByRepository[imgSpec.Name], func(k v2alpha1.CopyImageSchema) bool {
+			return o.manifestDigest(ctx, k, digests) == imgDigest
+		}) {
+			protected = append(protected, img)
+			continue
+		}
+		toDelete = append(toDelete, img)
+	}
+	return toDelete, protected
+}
+
+// manifestDigest returns the digest of the manifest of img, read from its reference in the cache,
+// or "" when it cannot be determined
+func (o DeleteImages) manifestDigest(ctx context.Context, img v2alpha1.CopyImageSchema, digests map[string]string) string {
+	if d, ok := digests[img.Source]; ok {
+		return d
+	}
+	d := ""
+	if imgSpec, err := image.ParseRef(img.Source); err == nil && imgSpec.IsImageByDigestOnly() {
+		d = imgSpec.Digest
+	} else if err == nil {
+		sourceCtx, err := o.Opts.SrcImage.NewSystemContext()
+		if err == nil {
+			if strings.Contains(img.Source, o.LocalStorageFQDN) {
+				sourceCtx.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+			}
+			if d, err = o.Manifest.GetDigest(ctx, sourceCtx, imgSpec.ReferenceWithTransport); err != nil {
+				o.Log.Debug("unable to read the digest of %s: %v", img.Source, err)
+				d = ""
+			}
+		}
+	}
+	digests[img.Source] = d
+	return d
+}
+
 // DeleteRegistryImages - deletes both remote and local registries
 func (o DeleteImages) DeleteRegistryImages(deleteImageList v2alpha1.DeleteImageList) error {
 	o.Log.Debug("deleting images from remote registry")
@@ -191,8 +272,59 @@ func (o DeleteImages) DeleteRegistryImages(deleteImageList v2alpha1.DeleteImageL
 		}
 	}
 
+	replacements := v2alpha1.CollectorSchema{AllImages: []v2alpha1.CopyImageSchema{}}
+	for _, ctlg := range deleteImageList.Catalogs {
+		if !strings.HasPrefix(ctlg.ImageReference, o.Opts.Global.DeleteDestination+"/") {
+			allErrs = append(allErrs, fmt.Errorf("delete destination %s does not match the catalog %s found in the delete-images yaml file (please verify full name)", o.Opts.Global.DeleteDestination, ctlg.ImageReference))
+			continue
+		}
+		cacheSpec, err := image.ParseRef(strings.Replace(ctlg.ImageReference, o.Opts.Global.DeleteDestination, dockerProtocol+o.LocalStorageFQDN, 1))
+		if err != nil {
+			allErrs = append(allErrs, fmt.Errorf("parse catalog ref %q: %w", ctlg.ImageReference, err))
+			continue
+		}
+		// the catalog rebuilt without the deleted operators replaces the catalog of the destination
+		if len(ctlg.ReplacementTag) > 0 {
+			o.Log.Debug("replacing catalog %s", ctlg.ImageReference)
+			replacements.AllImages = append(replacements.AllImages, v2alpha1.CopyImageSchema{
+				Origin:      ctlg.ImageName,
+				Source:      dockerProtocol + cacheSpec.Name + ":" + ctlg.ReplacementTag,
+				Destination: ctlg.ImageReference,
+				Type:        v2alpha1.TypeOperatorCatalog,
+			})
+			replacements.TotalOperatorImages++
+			continue
+		}
+		o.Log.Debug("deleting catalog %v", ctlg.ImageReference)
+		collectorSchema.AllImages = append(collectorSchema.AllImages, v2alpha1.CopyImageSchema{Origin: ctlg.ImageName, Destination: ctlg.ImageReference, Type: v2alpha1.TypeOperatorCatalog})
+		collectorSchema.TotalOperatorImages++
+		if o.Opts.Global.ForceCacheDelete {
+			cacheRefs := []string{cacheSpec.ReferenceWithTransport}
+			for _, tag := range ctlg.CacheTags {
+				cacheRefs = append(cacheRefs, dockerProtocol+cacheSpec.Name+":"+tag)
+			}
+			for _, ref := range cacheRefs {
+				o.Log.Debug("deleting catalog local cache %v", ref)
+				collectorSchema.AllImages = append(collectorSchema.AllImages, v2alpha1.CopyImageSchema{Origin: ctlg.ImageName, Destination: ref, Type: v2alpha1.TypeOperatorCatalog})
+				collectorSchema.TotalOperatorImages++
+			}
+		}
+	}
+
 	o.Opts.Stdout = io.Discard
 	if !o.Opts.Global.DeleteGenerate && len(o.Opts.Global.DeleteDestination) > 0 {
+		// the catalogs are replaced first, so that they never reference deleted images
+		if len(replacements.AllImages) > 0 {
+			copyOpts := o.Opts
+			copyOpts.Function = string(mirror.CopyMode)
+			// the replacements are pulled from the local cache, without TLS: the deletion of the images
+			// keeps the TLS settings of the user
+			copyOpts.SrcImage = o.Opts.SrcImage.WithTLSVerify(false)
+			if _, err := o.Batch.Worker(context.Background(), replacements, copyOpts); err != nil {
+				o.Log.Warn("error during catalog replacement: %v", err)
+				return errors.Join(append(allErrs, fmt.Errorf("the catalogs could not be replaced, no image deleted: %w", err))...)
+			}
+		}
 		if _, err := o.Batch.Worker(context.Background(), collectorSchema, o.Opts); err != nil {
 			o.Log.Warn("error during registry deletion: %v", err)
 			allErrs = append(allErrs, err)
@@ -147,13 +147,83 @@ func TestWriteMetaData(t *testing.T) {
 				Origin:      "test",
 			},
 		}
-		err := di.WriteDeleteMetaData(cpImages)
+		err := di.WriteDeleteMetaData(cpImages, nil, nil)
 		if err != nil {
 			t.Fatalf("should not fail %v", err)
 		}
 	})
 }
 
+// TestWriteMetaDataWithKeptImages
+func TestWriteMetaDataWithKeptImages(t *testing.T) {
+	log := clog.New("trace")
+
+	tempDir := t.TempDir()
+	global := &mirror.GlobalOptions{WorkingDir: tempDir}
+	_, sharedOpts := mirror.SharedImageFlags()
+	_, deprecatedTLSVerifyOpt := mirror.DeprecatedTLSVerifyFlags()
+	_, srcOpts := mirror.ImageSrcFlags(global, sharedOpts, deprecatedTLSVerifyOpt, "src-", "screds")
+	opts := mirror.CopyOptions{
+		Global:           global,
+		SrcImage:         srcOpts,
+		Mode:             mirror.MirrorToDisk,
+		LocalStorageFQDN: "localhost:8888",
+	}
+	di := New(log, opts, &mockBatch{}, &mockBlobs{}, v2alpha1.ImageSetConfiguration{}, &mockManifest{}, "/tmp").(*DeleteImages)
+
+	sameDestination := v2alpha1.CopyImageSchema{
+		Source:      "docker://localhost:8888/ubi8/ubi:latest",
+		Destination: "docker://myregistry/ubi8/ubi:latest",
+		Origin:      "registry.redhat.io/ubi8/ubi:latest",
+		Type:        v2alpha1.TypeGeneric,
+	}
+	sameManifest := v2alpha1.CopyImageSchema{
+		Source:      "docker://localhost:8888/openshift/release@sha256:c4b775cbe8eec55de2c163919c6008599e2aebe789ed93ada9a307e800e3f1e2",
+		Destination: "docker://myregistry/openshift/release:4.15.12-x86_64-old",
+		Origin:      "quay.io/openshift/release@sha256:c4b775cbe8eec55de2c163919c6008599e2aebe789ed93ada9a307e800e3f1e2",
+		Type:        v2alpha1.TypeGeneric,
+	}
+	unreferenced := v2alpha1.CopyImageSchema{
+		Source:      "docker://localhost:8888/openshift/release@sha256:95ad8395795ee0460baf05458f669d3b865535f213f015519ef9a221a6a08280",
+		Destination: "docker://myregistry/openshift/release:4.14.1-x86_64-old",
+		Origin:      "quay.io/openshift/release@sha256:95ad8395795ee0460baf05458f669d3b865535f213f015519ef9a221a6a08280",
+		Type:        v2alpha1.TypeGeneric,
+	}
+	kept := []v2alpha1.CopyImageSchema{
+		sameDestination,
+		{
+			Source:      "docker://localhost:8888/openshift/release@sha256:c4b775cbe8eec55de2c163919c6008599e2aebe789ed93ada9a307e800e3f1e2",
+			Destination: "docker://myregistry/openshift/release:4.15.12-x86_64-new",
+			Origin:      "quay.io/openshift/release@sha256:c4b775cbe8eec55de2c163919c6008599e2aebe789ed93ada9a307e800e3f1e2",
+			Type:        v2alpha1.TypeGeneric,
+		},
+	}
+
+	t.Run("Testing splitKeptImages - kept images : should protect the images with the same destination or manifest", func(t *testing.T) {
+		toDelete, protected := di.splitKeptImages(context.Background(), []v2alpha1.CopyImageSchema{sameDestination, sameManifest, unreferenced}, kept)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{unreferenced}, toDelete)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{sameDestination, sameManifest}, protected)
+	})
+
+	t.Run("Testing splitKeptImages - no kept image : should delete all images", func(t *testing.T) {
+		toDelete, protected := di.splitKeptImages(context.Background(), []v2alpha1.CopyImageSchema{sameDestination, unreferenced}, nil)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{sameDestination, unreferenced}, toDelete)
+		assert.Empty(t, protected)
+	})
+
+	t.Run("Testing WriteDeleteMetaData - kept images : should list the protected images apart", func(t *testing.T) {
+		err := di.WriteDeleteMetaData([]v2alpha1.CopyImageSchema{sameDestination, sameManifest, unreferenced}, kept, nil)
+		assert.NoError(t, err)
+		data, err := di.ReadDeleteMetaData()
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.DeleteItem{{ImageName: unreferenced.Origin, ImageReference: unreferenced.Destination, Type: v2alpha1.TypeGeneric}}, data.Items)
+		assert.Equal(t, []v2alpha1.DeleteItem{
+			{ImageName: sameManifest.Origin, ImageReference: sameManifest.Destination, Type: v2alpha1.TypeGeneric},
+			{ImageName: sameDestination.Origin, ImageReference: sameDestination.Destination, Type: v2alpha1.TypeGeneric},
+		}, data.Protected)
+	})
+}
+
 // mockBatch
 type mockBatch struct {
 	Fail bool
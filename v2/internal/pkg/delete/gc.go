@@ -0,0 +1,232 @@
+package delete
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/distribution/distribution/v3/registry/storage"
+	"github.com/distribution/distribution/v3/registry/storage/driver/filesystem"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+const (
+	// GCQuay expires the deleted tags of a Quay registry, so that they are not kept by its time machine
+	GCQuay = "quay"
+	// GCHarbor triggers a garbage collection job on a Harbor registry
+	GCHarbor = "harbor"
+	// GCDistribution garbage collects the filesystem storage of a CNCF distribution registry
+	GCDistribution = "distribution"
+	// GCWebhook posts the deleted images to a webhook
+	GCWebhook = "webhook"
+)
+
+// GarbageCollector cleans up the destination registry once the manifests of the deleted images are deleted,
+// so that the blobs they were the only ones to reference are removed
+type GarbageCollector interface {
+	GarbageCollect(ctx context.Context, deleted []v2alpha1.DeleteItem) error
+}
+
+// NewGarbageCollector returns the garbage collector of kind.
+// The endpoint is the URL of the registry or of the webhook, or the root directory of the storage of a
+// distribution registry. The credentials are a token for quay and the webhook, and user:password for harbor.
+func NewGarbageCollector(log clog.PluggableLoggerInterface, kind, endpoint, credentials string, client *http.Client) (GarbageCollector, error) {
+	if len(endpoint) == 0 {
+		return nil, fmt.Errorf("garbage collector %s: the endpoint is mandatory", kind)
+	}
+	switch kind {
+	case GCQuay:
+		return quayGarbageCollector{log: log, endpoint: strings.TrimSuffix(endpoint, "/"), token: credentials, client: client}, nil
+	case GCHarbor:
+		user, password, ok := strings.Cut(credentials, ":")
+		if !ok {
+			return nil, fmt.Errorf("garbage collector %s: the credentials must be user:password", kind)
+		}
+		return harborGarbageCollector{log: log, endpoint: strings.TrimSuffix(endpoint, "/"), user: user, password: password, client: client}, nil
+	case GCDistribution:
+		return distributionGarbageCollector{log: log, rootDirectory: endpoint}, nil
+	case GCWebhook:
+		return webhookGarbageCollector{log: log, endpoint: endpoint, token: credentials, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown garbage collector %q: use one of %s, %s, %s or %s", kind, GCQuay, GCHarbor, GCDistribution, GCWebhook)
+	}
+}
+
+// quayGarbageCollector permanently deletes the deleted tags from the time machine of Quay:
+// otherwise Quay only garbage collects them once the time machine expiration of their namespace is reached.
+type quayGarbageCollector struct {
+	log      clog.PluggableLoggerInterface
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func (o quayGarbageCollector) GarbageCollect(ctx context.Context, deleted []v2alpha1.DeleteItem) error {
+	var errs []error
+	for _, item := range deleted {
+		imgSpec, err := image.ParseRef(item.ImageReference)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(imgSpec.Tag) == 0 {
+			o.log.Debug("quay: %s has no tag to expire", item.ImageReference)
+			continue
+		}
+		manifestDigest, err := o.deletedTagDigest(ctx, imgSpec.PathComponent, imgSpec.Tag)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("quay: %s: %w", item.ImageReference, err))
+			continue
+		}
+		if len(manifestDigest) == 0 {
+			o.log.Debug("quay: %s is not in the time machine", item.ImageReference)
+			continue
+		}
+		expire := map[string]any{"manifest_digest": manifestDigest, "include_submanifests": true, "is_alive": false}
+		if err := doRequest(ctx, o.client, http.MethodPost, o.tagURL(imgSpec.PathComponent, imgSpec.Tag)+"/expire", expire, o.authorize, nil); err != nil {
+			errs = append(errs, fmt.Errorf("quay: %s: %w", item.ImageReference, err))
+			continue
+		}
+		o.log.Debug("quay: %s expired from the time machine", item.ImageReference)
+	}
+	return errors.Join(errs...)
+}
+
+// deletedTagDigest returns the manifest digest of the last deleted occurrence of tag in repository
+func (o quayGarbageCollector) deletedTagDigest(ctx context.Context, repository, tag string) (string, error) {
+	var history struct {
+		Tags []struct {
+			ManifestDigest string `json:"manifest_digest"`
+			EndTS          int64  `json:"end_ts"`
+		} `json:"tags"`
+	}
+	tagsURL := o.endpoint + "/api/v1/repository/" + repository + "/tag/?onlyActiveTags=false&specificTag=" + url.QueryEscape(tag)
+	if err := doRequest(ctx, o.client, http.MethodGet, tagsURL, nil, o.authorize, &history); err != nil {
+		return "", err
+	}
+	manifestDigest := ""
+	var lastEnd int64
+	for _, t := range history.Tags {
+		if t.EndTS > lastEnd {
+			manifestDigest, lastEnd = t.ManifestDigest, t.EndTS
+		}
+	}
+	return manifestDigest, nil
+}
+
+func (o quayGarbageCollector) tagURL(repository, tag string) string {
+	return o.endpoint + "/api/v1/repository/" + repository + "/tag/" + url.PathEscape(tag)
+}
+
+func (o quayGarbageCollector) authorize(req *http.Request) {
+	if len(o.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+o.token)
+	}
+}
+
+// harborGarbageCollector triggers a manual garbage collection job, removing the untagged artifacts
+type harborGarbageCollector struct {
+	log      clog.PluggableLoggerInterface
+	endpoint string
+	user     string
+	password string
+	client   *http.Client
+}
+
+func (o harborGarbageCollector) GarbageCollect(ctx context.Context, deleted []v2alpha1.DeleteItem) error {
+	schedule := map[string]any{
+		"schedule":   map[string]string{"type": "Manual"},
+		"parameters": map[string]any{"delete_untagged": true},
+	}
+	authorize := func(req *http.Request) { req.SetBasicAuth(o.user, o.password) }
+	if err := doRequest(ctx, o.client, http.MethodPost, o.endpoint+"/api/v2.0/system/gc/schedule", schedule, authorize, nil); err != nil {
+		return fmt.Errorf("harbor: %w", err)
+	}
+	o.log.Info("harbor: garbage collection job triggered on %s", o.endpoint)
+	return nil
+}
+
+// distributionGarbageCollector garbage collects the filesystem storage of a distribution registry,
+// as registry garbage-collect does. The registry must be stopped, or in read-only mode, meanwhile.
+type distributionGarbageCollector struct {
+	log           clog.PluggableLoggerInterface
+	rootDirectory string
+}
+
+func (o distributionGarbageCollector) GarbageCollect(ctx context.Context, deleted []v2alpha1.DeleteItem) error {
+	driver := filesystem.New(filesystem.DriverParameters{RootDirectory: o.rootDirectory, MaxThreads: 100})
+	reg, err := storage.NewRegistry(ctx, driver)
+	if err != nil {
+		return fmt.Errorf("distribution: %w", err)
+	}
+	if err := storage.MarkAndSweep(ctx, driver, reg, storage.GCOpts{DryRun: false, RemoveUntagged: true}); err != nil {
+		return fmt.Errorf("distribution: %w", err)
+	}
+	o.log.Info("distribution: garbage collection of %s done", o.rootDirectory)
+	return nil
+}
+
+// webhookGarbageCollector posts the deleted images to a webhook, which takes care of the garbage collection
+type webhookGarbageCollector struct {
+	log      clog.PluggableLoggerInterface
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func (o webhookGarbageCollector) GarbageCollect(ctx context.Context, deleted []v2alpha1.DeleteItem) error {
+	authorize := func(req *http.Request) {
+		if len(o.token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+o.token)
+		}
+	}
+	if err := doRequest(ctx, o.client, http.MethodPost, o.endpoint, map[string]any{"images": deleted}, authorize, nil); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	o.log.Info("webhook: %d deleted images posted to %s", len(deleted), o.endpoint)
+	return nil
+}
+
+// doRequest sends body as json, and decodes the json response into response when not nil
+func doRequest(ctx context.Context, client *http.Client, method, reqURL string, body any, authorize func(*http.Request), response any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	authorize(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", method, reqURL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if response != nil {
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+			return fmt.Errorf("%s %s: %w", method, reqURL, err)
+		}
+	}
+	return nil
+}
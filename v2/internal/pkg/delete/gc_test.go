@@ -0,0 +1,112 @@
+package delete
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+func TestGarbageCollectors(t *testing.T) {
+	log := clog.New("trace")
+	deleted := []v2alpha1.DeleteItem{
+		{ImageName: "registry.redhat.io/ubi8/ubi:latest", ImageReference: "docker://quay.example.com/mirror/ubi8/ubi:latest", Type: v2alpha1.TypeGeneric},
+		{ImageName: "quay.io/foo/bar@sha256:c4b775cbe8eec55de2c163919c6008599e2aebe789ed93ada9a307e800e3f1e2", ImageReference: "docker://quay.example.com/mirror/foo/bar@sha256:c4b775cbe8eec55de2c163919c6008599e2aebe789ed93ada9a307e800e3f1e2", Type: v2alpha1.TypeGeneric},
+	}
+
+	t.Run("Testing quay garbage collector : should expire the deleted tags from the time machine", func(t *testing.T) {
+		var expired map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repository/mirror/ubi8/ubi/tag/":
+				assert.Equal(t, "latest", r.URL.Query().Get("specificTag"))
+				_, _ = w.Write([]byte(`{"tags": [{"manifest_digest": "sha256:old", "end_ts": 100}, {"manifest_digest": "sha256:last", "end_ts": 200}]}`))
+			case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repository/mirror/ubi8/ubi/tag/latest/expire":
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&expired))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		gc, err := NewGarbageCollector(log, GCQuay, server.URL+"/", "secret", server.Client())
+		assert.NoError(t, err)
+		assert.NoError(t, gc.GarbageCollect(context.Background(), deleted))
+		assert.Equal(t, map[string]any{"manifest_digest": "sha256:last", "include_submanifests": true, "is_alive": false}, expired)
+	})
+
+	t.Run("Testing harbor garbage collector : should schedule a manual garbage collection", func(t *testing.T) {
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "admin", user)
+			assert.Equal(t, "Harbor12345", password)
+			assert.Equal(t, "/api/v2.0/system/gc/schedule", r.URL.Path)
+			called = true
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		gc, err := NewGarbageCollector(log, GCHarbor, server.URL, "admin:Harbor12345", server.Client())
+		assert.NoError(t, err)
+		assert.NoError(t, gc.GarbageCollect(context.Background(), deleted))
+		assert.True(t, called)
+	})
+
+	t.Run("Testing harbor garbage collector - unauthorized : should fail", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		gc, err := NewGarbageCollector(log, GCHarbor, server.URL, "admin:wrong", server.Client())
+		assert.NoError(t, err)
+		assert.ErrorContains(t, gc.GarbageCollect(context.Background(), deleted), "harbor: POST "+server.URL+"/api/v2.0/system/gc/schedule: 401 Unauthorized")
+	})
+
+	t.Run("Testing webhook garbage collector : should post the deleted images", func(t *testing.T) {
+		var body struct {
+			Images []v2alpha1.DeleteItem `json:"images"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		}))
+		defer server.Close()
+
+		gc, err := NewGarbageCollector(log, GCWebhook, server.URL, "", server.Client())
+		assert.NoError(t, err)
+		assert.NoError(t, gc.GarbageCollect(context.Background(), deleted))
+		assert.Equal(t, deleted, body.Images)
+	})
+
+	t.Run("Testing distribution garbage collector : should garbage collect the storage", func(t *testing.T) {
+		rootDirectory := t.TempDir()
+		for _, dir := range []string{"repositories", "blobs"} {
+			assert.NoError(t, os.MkdirAll(filepath.Join(rootDirectory, "docker", "registry", "v2", dir), 0755))
+		}
+		gc, err := NewGarbageCollector(log, GCDistribution, rootDirectory, "", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, gc.GarbageCollect(context.Background(), deleted))
+	})
+
+	t.Run("Testing NewGarbageCollector - invalid settings : should fail", func(t *testing.T) {
+		_, err := NewGarbageCollector(log, "artifactory", "https://registry.example.com", "", nil)
+		assert.EqualError(t, err, `unknown garbage collector "artifactory": use one of quay, harbor, distribution or webhook`)
+		_, err = NewGarbageCollector(log, GCHarbor, "https://registry.example.com", "admin", nil)
+		assert.EqualError(t, err, "garbage collector harbor: the credentials must be user:password")
+		_, err = NewGarbageCollector(log, GCQuay, "", "", nil)
+		assert.EqualError(t, err, "garbage collector quay: the endpoint is mandatory")
+	})
+}
@@ -0,0 +1,43 @@
+package delete
+
+import (
+	"strings"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+// GraphImages returns the graph images recorded as mirrored to destination when images delete all the releases
+// recorded as mirrored to destination: the graph image is only used by the update service to upgrade to them.
+// The graph images already in images are not returned.
+func GraphImages(records []v2alpha1.MirroredImage, images []v2alpha1.CopyImageSchema, destination string) []v2alpha1.CopyImageSchema {
+	deleted := map[string]bool{}
+	for _, img := range images {
+		deleted[img.Destination] = true
+	}
+	var graphs []v2alpha1.CopyImageSchema
+	releases := 0
+	for _, r := range records {
+		if !strings.HasPrefix(r.ImageReference, strings.TrimSuffix(destination, "/")+"/") {
+			continue
+		}
+		switch r.Type {
+		case v2alpha1.TypeOCPRelease:
+			if !deleted[r.ImageReference] {
+				return nil
+			}
+			releases++
+		case v2alpha1.TypeCincinnatiGraph:
+			if !deleted[r.ImageReference] {
+				graphs = append(graphs, v2alpha1.CopyImageSchema{
+					Origin:      r.ImageName,
+					Destination: r.ImageReference,
+					Type:        r.Type,
+				})
+			}
+		}
+	}
+	if releases == 0 {
+		return nil
+	}
+	return graphs
+}
@@ -0,0 +1,45 @@
+package delete
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+func TestGraphImages(t *testing.T) {
+	destination := "docker://mirror.example.com/ocp"
+	records := []v2alpha1.MirroredImage{
+		{ImageName: "quay.io/openshift-release-dev/ocp-release:4.15.1-x86_64", ImageReference: destination + "/openshift/release-images:4.15.1-x86_64", Type: v2alpha1.TypeOCPRelease},
+		{ImageName: "quay.io/openshift-release-dev/ocp-release:4.15.2-x86_64", ImageReference: destination + "/openshift/release-images:4.15.2-x86_64", Type: v2alpha1.TypeOCPRelease},
+		{ImageName: "docker://localhost:55000/openshift/graph-image:latest", ImageReference: destination + "/openshift/graph-image:latest", Type: v2alpha1.TypeCincinnatiGraph},
+		// mirrored to another destination
+		{ImageName: "quay.io/openshift-release-dev/ocp-release:4.16.1-x86_64", ImageReference: "docker://other.example.com/openshift/release-images:4.16.1-x86_64", Type: v2alpha1.TypeOCPRelease},
+	}
+	release := func(version string) v2alpha1.CopyImageSchema {
+		return v2alpha1.CopyImageSchema{Destination: destination + "/openshift/release-images:" + version + "-x86_64", Type: v2alpha1.TypeOCPRelease}
+	}
+
+	t.Run("Testing GraphImages - all releases deleted : should delete the graph image", func(t *testing.T) {
+		graphs := GraphImages(records, []v2alpha1.CopyImageSchema{release("4.15.1"), release("4.15.2")}, destination)
+		assert.Equal(t, []v2alpha1.CopyImageSchema{{
+			Origin:      "docker://localhost:55000/openshift/graph-image:latest",
+			Destination: destination + "/openshift/graph-image:latest",
+			Type:        v2alpha1.TypeCincinnatiGraph,
+		}}, graphs)
+	})
+
+	t.Run("Testing GraphImages - releases left : should keep the graph image", func(t *testing.T) {
+		assert.Empty(t, GraphImages(records, []v2alpha1.CopyImageSchema{release("4.15.1")}, destination))
+	})
+
+	t.Run("Testing GraphImages - graph image already deleted : should not add it twice", func(t *testing.T) {
+		graph := v2alpha1.CopyImageSchema{Destination: destination + "/openshift/graph-image:latest", Type: v2alpha1.TypeCincinnatiGraph}
+		assert.Empty(t, GraphImages(records, []v2alpha1.CopyImageSchema{release("4.15.1"), release("4.15.2"), graph}, destination))
+	})
+
+	t.Run("Testing GraphImages - no release recorded : should keep the graph image", func(t *testing.T) {
+		assert.Empty(t, GraphImages(records[2:3], nil, destination))
+	})
+}
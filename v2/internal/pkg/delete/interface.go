@@ -5,7 +5,7 @@ import (
 )
 
 type DeleteInterface interface {
-	WriteDeleteMetaData([]v2alpha1.CopyImageSchema) error
+	WriteDeleteMetaData(images []v2alpha1.CopyImageSchema, kept []v2alpha1.CopyImageSchema, catalogs []v2alpha1.DeleteCatalog) error
 	ReadDeleteMetaData() (v2alpha1.DeleteImageList, error)
 	DeleteRegistryImages(images v2alpha1.DeleteImageList) error
 }
@@ -0,0 +1,198 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+const (
+	clusterVersionKind = "ClusterVersion"
+	podKind            = "Pod"
+	csvKind            = "ClusterServiceVersion"
+)
+
+var (
+	// inUseResources are the resources referencing the images in use, by kind
+	inUseResources = map[string]schema.GroupVersionResource{
+		clusterVersionKind: {Group: "config.openshift.io", Version: "v1", Resource: "clusterversions"},
+		podKind:            {Version: "v1", Resource: "pods"},
+		csvKind:            {Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"},
+	}
+
+	digestRegex = regexp.MustCompile(`sha256:[a-f0-9]{64}`)
+)
+
+// InUseImages finds the images in use by clusters, read from their API servers
+// or from the ClusterVersion, Pod and ClusterServiceVersion resources exported from them
+type InUseImages struct {
+	Log     clog.PluggableLoggerInterface
+	Clients map[string]dynamic.Interface
+	Files   []string
+}
+
+// NewInUseImages returns the images in use by the clusters of kubeconfigs, and by the resources exported in files
+func NewInUseImages(log clog.PluggableLoggerInterface, kubeconfigs, files []string) (*InUseImages, error) {
+	clients := map[string]dynamic.Interface{}
+	for _, kubeconfig := range kubeconfigs {
+		restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load kubeconfig %s: %w", kubeconfig, err)
+		}
+		client, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create kubernetes client for %s: %w", kubeconfig, err)
+		}
+		clients[kubeconfig] = client
+	}
+	return &InUseImages{Log: log, Clients: clients, Files: files}, nil
+}
+
+// Digests returns the digests of the images in use. All the clusters and files must be read:
+// otherwise an image in use could be deleted.
+func (o InUseImages) Digests(ctx context.Context) (map[string]bool, error) {
+	digests := map[string]bool{}
+	for kubeconfig, client := range o.Clients {
+		for kind, gvr := range inUseResources {
+			list, err := client.Resource(gvr).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("unable to list the %s of the cluster of %s: %w", gvr.Resource, kubeconfig, err)
+			}
+			for _, obj := range list.Items {
+				addDigests(digests, imagesOf(kind, obj.Object))
+			}
+		}
+	}
+	for _, file := range o.Files {
+		objs, err := decodeObjects(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			addDigests(digests, imagesOf(fmt.Sprint(obj["kind"]), obj))
+		}
+	}
+	o.Log.Debug("%d image digests in use", len(digests))
+	return digests, nil
+}
+
+// ItemDigest returns the digest of the image of item when its references hold it, or ""
+func ItemDigest(item v2alpha1.DeleteItem) string {
+	if imgSpec, err := image.ParseRef(item.ImageName); err == nil && len(imgSpec.Digest) > 0 {
+		return imgSpec.Algorithm + ":" + imgSpec.Digest
+	}
+	// the images mirrored by digest only are tagged sha256-<digest>
+	if imgSpec, err := image.ParseRef(item.ImageReference); err == nil && strings.HasPrefix(imgSpec.Tag, "sha256-") {
+		return strings.Replace(imgSpec.Tag, "-", ":", 1)
+	}
+	return ""
+}
+
+func addDigests(digests map[string]bool, images []string) {
+	for _, img := range images {
+		for _, d := range digestRegex.FindAllString(img, -1) {
+			digests[d] = true
+		}
+	}
+}
+
+// imagesOf returns the images referenced by a ClusterVersion (the desired release, and the partially applied ones),
+// by a Pod (including the image ids of its containers) or by a ClusterServiceVersion
+func imagesOf(kind string, obj map[string]interface{}) []string {
+	var images []string
+	switch kind {
+	case clusterVersionKind:
+		if img, found, _ := unstructured.NestedString(obj, "status", "desired", "image"); found {
+			images = append(images, img)
+		}
+		history, _, _ := unstructured.NestedSlice(obj, "status", "history")
+		for _, h := range history {
+			if entry, ok := h.(map[string]interface{}); ok && entry["state"] == "Partial" {
+				images = append(images, fmt.Sprint(entry["image"]))
+			}
+		}
+	case podKind:
+		for _, field := range []string{"containers", "initContainers", "ephemeralContainers"} {
+			images = append(images, nestedImages(obj, "image", "spec", field)...)
+		}
+		for _, field := range []string{"containerStatuses", "initContainerStatuses", "ephemeralContainerStatuses"} {
+			images = append(images, nestedImages(obj, "image", "status", field)...)
+			images = append(images, nestedImages(obj, "imageID", "status", field)...)
+		}
+	case csvKind:
+		images = append(images, nestedImages(obj, "image", "spec", "relatedImages")...)
+		deployments, _, _ := unstructured.NestedSlice(obj, "spec", "install", "spec", "deployments")
+		for _, d := range deployments {
+			if deployment, ok := d.(map[string]interface{}); ok {
+				images = append(images, nestedImages(deployment, "image", "spec", "template", "spec", "containers")...)
+				images = append(images, nestedImages(deployment, "image", "spec", "template", "spec", "initContainers")...)
+			}
+		}
+		if img, found, _ := unstructured.NestedString(obj, "metadata", "annotations", "containerImage"); found {
+			images = append(images, img)
+		}
+	}
+	return images
+}
+
+// nestedImages returns the key field of the items of the slice at fields
+func nestedImages(obj map[string]interface{}, key string, fields ...string) []string {
+	var images []string
+	items, _, _ := unstructured.NestedSlice(obj, fields...)
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			if img, ok := m[key].(string); ok {
+				images = append(images, img)
+			}
+		}
+	}
+	return images
+}
+
+// decodeObjects returns the objects of the yaml or json file, with the items of the lists
+func decodeObjects(path string) ([]map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []map[string]interface{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to decode %s: %w", path, err)
+		}
+		u := unstructured.Unstructured{Object: obj}
+		if !u.IsList() {
+			objs = append(objs, obj)
+			continue
+		}
+		items, _, _ := unstructured.NestedSlice(obj, "items")
+		for _, item := range items {
+			if itemObj, ok := item.(map[string]interface{}); ok {
+				objs = append(objs, itemObj)
+			}
+		}
+	}
+	return objs, nil
+}
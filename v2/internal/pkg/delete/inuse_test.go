@@ -0,0 +1,144 @@
+package delete
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+var (
+	releaseDigest  = "sha256:" + strings.Repeat("1", 64)
+	partialDigest  = "sha256:" + strings.Repeat("2", 64)
+	podDigest      = "sha256:" + strings.Repeat("3", 64)
+	imageIDDigest  = "sha256:" + strings.Repeat("4", 64)
+	relatedDigest  = "sha256:" + strings.Repeat("5", 64)
+	operatorDigest = "sha256:" + strings.Repeat("6", 64)
+	previousDigest = "sha256:" + strings.Repeat("7", 64)
+)
+
+const inUseResourcesYaml = `apiVersion: config.openshift.io/v1
+kind: ClusterVersion
+metadata:
+  name: version
+status:
+  desired:
+    image: quay.io/openshift-release-dev/ocp-release@sha256:1111111111111111111111111111111111111111111111111111111111111111
+  history:
+  - state: Partial
+    image: quay.io/openshift-release-dev/ocp-release@sha256:2222222222222222222222222222222222222222222222222222222222222222
+  - state: Completed
+    image: quay.io/openshift-release-dev/ocp-release@sha256:7777777777777777777777777777777777777777777777777777777777777777
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: ubi
+    namespace: default
+  spec:
+    containers:
+    - name: ubi
+      image: registry.redhat.io/ubi8/ubi@sha256:3333333333333333333333333333333333333333333333333333333333333333
+  status:
+    containerStatuses:
+    - name: ubi
+      imageID: registry.redhat.io/ubi8/ubi@sha256:4444444444444444444444444444444444444444444444444444444444444444
+- apiVersion: operators.coreos.com/v1alpha1
+  kind: ClusterServiceVersion
+  metadata:
+    name: op1.v1
+    namespace: openshift-operators
+  spec:
+    relatedImages:
+    - name: operand
+      image: registry.redhat.io/op1/operand@sha256:5555555555555555555555555555555555555555555555555555555555555555
+    install:
+      spec:
+        deployments:
+        - name: op1
+          spec:
+            template:
+              spec:
+                containers:
+                - name: manager
+                  image: registry.redhat.io/op1/operator@sha256:6666666666666666666666666666666666666666666666666666666666666666
+`
+
+// newFakeClusterClient returns a fake dynamic client serving the objects of inUseResourcesYaml
+func newFakeClusterClient(t *testing.T) dynamic.Interface {
+	path := filepath.Join(t.TempDir(), "resources.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(inUseResourcesYaml), 0644))
+	objs, err := decodeObjects(path)
+	assert.NoError(t, err)
+	listKinds := map[schema.GroupVersionResource]string{}
+	var runtimeObjs []runtime.Object
+	for kind, gvr := range inUseResources {
+		listKinds[gvr] = kind + "List"
+	}
+	for _, obj := range objs {
+		runtimeObjs = append(runtimeObjs, &unstructured.Unstructured{Object: obj})
+	}
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, runtimeObjs...)
+}
+
+func TestInUseImages(t *testing.T) {
+	log := clog.New("trace")
+	expected := map[string]bool{releaseDigest: true, partialDigest: true, podDigest: true, imageIDDigest: true, relatedDigest: true, operatorDigest: true}
+
+	t.Run("Testing Digests - cluster : should return the digests of the images in use", func(t *testing.T) {
+		inUse := InUseImages{Log: log, Clients: map[string]dynamic.Interface{"kubeconfig": newFakeClusterClient(t)}}
+		digests, err := inUse.Digests(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, digests)
+		assert.False(t, digests[previousDigest])
+	})
+
+	t.Run("Testing Digests - exported resources : should return the digests of the images in use", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "resources.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(inUseResourcesYaml), 0644))
+		digests, err := InUseImages{Log: log, Files: []string{path}}.Digests(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, digests)
+	})
+
+	t.Run("Testing Digests - missing file : should fail", func(t *testing.T) {
+		_, err := InUseImages{Log: log, Files: []string{filepath.Join(t.TempDir(), "missing.yaml")}}.Digests(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("Testing NewInUseImages - invalid kubeconfig : should fail", func(t *testing.T) {
+		_, err := NewInUseImages(log, []string{filepath.Join(t.TempDir(), "missing")}, nil)
+		assert.ErrorContains(t, err, "unable to load kubeconfig")
+	})
+}
+
+func TestItemDigest(t *testing.T) {
+	t.Run("Testing ItemDigest : should return the digest held by the references", func(t *testing.T) {
+		assert.Equal(t, podDigest, ItemDigest(v2alpha1.DeleteItem{
+			ImageName:      "registry.redhat.io/ubi8/ubi@" + podDigest,
+			ImageReference: "docker://myregistry/ubi8/ubi:sha256-" + strings.Repeat("3", 64),
+		}))
+		assert.Equal(t, podDigest, ItemDigest(v2alpha1.DeleteItem{
+			ImageName:      "docker://localhost:55000/ubi8/ubi:latest",
+			ImageReference: "docker://myregistry/ubi8/ubi:sha256-" + strings.Repeat("3", 64),
+		}))
+		assert.Empty(t, ItemDigest(v2alpha1.DeleteItem{
+			ImageName:      "registry.redhat.io/ubi8/ubi:latest",
+			ImageReference: "docker://myregistry/ubi8/ubi:latest",
+		}))
+	})
+}
@@ -5,5 +5,9 @@ import "github.com/openshift/oc-mirror/v2/internal/pkg/common"
 const (
 	historyPath       = ".history/"
 	historyNamePrefix = ".history-"
-	historyFakePath   = common.TestFolder + ".history-fake/"
+	// mirroredImagesFile records the images mirrored to a registry, with their dates
+	mirroredImagesFile = "mirrored-images.yaml"
+	// snapshotsPath holds the metadata of the history files: the images they added, and whether their archive was delivered
+	snapshotsPath   = "snapshots"
+	historyFakePath = common.TestFolder + ".history-fake/"
 )
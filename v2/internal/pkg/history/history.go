@@ -53,24 +53,7 @@ func (o history) Read() (map[string]struct{}, error) {
 		return nil, err
 	}
 
-	file, err := os.Open(historyFile)
-	if err != nil {
-		return nil, fmt.Errorf("error opening a file %w", err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		blob := scanner.Text()
-		historyMap[blob] = struct{}{}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error non-EOF found %w", err)
-	}
-
-	return historyMap, nil
+	return readBlobFile(historyFile)
 }
 
 func (o history) getHistoryFile(before time.Time) (string, error) {
@@ -5,6 +5,7 @@ import "io"
 type History interface {
 	Read() (map[string]struct{}, error)
 	Append(map[string]struct{}) (map[string]struct{}, error)
+	RecordImages(map[string][]string) error
 }
 
 type FileCreator interface {
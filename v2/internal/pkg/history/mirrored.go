@@ -0,0 +1,100 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+// RecordMirroredImages records that images were mirrored to their destination at mirroredAt,
+// in the record of the images mirrored from workingDir
+func RecordMirroredImages(workingDir string, images []v2alpha1.CopyImageSchema, mirroredAt time.Time) error {
+	records, err := ReadMirroredImages(workingDir)
+	if err != nil {
+		return err
+	}
+	byReference := make(map[string]v2alpha1.MirroredImage, len(records))
+	for _, r := range records {
+		byReference[r.ImageReference] = r
+	}
+	for _, img := range images {
+		// only the images that can be written to a delete file are recorded
+		if img.Type.String() == "" {
+			continue
+		}
+		r, ok := byReference[img.Destination]
+		if !ok {
+			r = v2alpha1.MirroredImage{ImageReference: img.Destination, FirstMirrored: mirroredAt}
+		}
+		r.ImageName = img.Origin
+		r.Type = img.Type
+		r.LastMirrored = mirroredAt
+		byReference[img.Destination] = r
+	}
+	return writeMirroredImages(workingDir, byReference)
+}
+
+// ForgetMirroredImages removes the images deleted from their destination from the record of the images
+// mirrored from workingDir
+func ForgetMirroredImages(workingDir string, references []string) error {
+	records, err := ReadMirroredImages(workingDir)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+	byReference := make(map[string]v2alpha1.MirroredImage, len(records))
+	for _, r := range records {
+		byReference[r.ImageReference] = r
+	}
+	for _, ref := range references {
+		delete(byReference, ref)
+	}
+	return writeMirroredImages(workingDir, byReference)
+}
+
+func writeMirroredImages(workingDir string, byReference map[string]v2alpha1.MirroredImage) error {
+	list := v2alpha1.MirroredImageList{
+		Kind:       "MirroredImageList",
+		APIVersion: "mirror.openshift.io/v2alpha1",
+		Items:      make([]v2alpha1.MirroredImage, 0, len(byReference)),
+	}
+	for _, r := range byReference {
+		list.Items = append(list.Items, r)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].ImageReference < list.Items[j].ImageReference })
+	data, err := yaml.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("error marshaling the mirrored images: %w", err)
+	}
+	historyDir := filepath.Join(workingDir, historyPath)
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return fmt.Errorf("error creating directories %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(historyDir, mirroredImagesFile), data, 0644); err != nil { // nolint:gosec // G306: no sensitive data
+		return fmt.Errorf("error writing the mirrored images: %w", err)
+	}
+	return nil
+}
+
+// ReadMirroredImages returns the images recorded as mirrored from workingDir,
+// or no image when nothing was mirrored from it yet
+func ReadMirroredImages(workingDir string) ([]v2alpha1.MirroredImage, error) {
+	data, err := os.ReadFile(filepath.Join(workingDir, historyPath, mirroredImagesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading the mirrored images: %w", err)
+	}
+	var list v2alpha1.MirroredImageList
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("error parsing the mirrored images: %w", err)
+	}
+	return list.Items, nil
+}
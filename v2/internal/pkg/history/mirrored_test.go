@@ -0,0 +1,63 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+func TestRecordMirroredImages(t *testing.T) {
+	release := v2alpha1.CopyImageSchema{
+		Origin:      "quay.io/openshift-release-dev/ocp-release:4.15.12-x86_64",
+		Destination: "docker://myregistry/openshift/release-images:4.15.12-x86_64",
+		Type:        v2alpha1.TypeOCPRelease,
+	}
+	ubi := v2alpha1.CopyImageSchema{
+		Origin:      "registry.redhat.io/ubi8/ubi:latest",
+		Destination: "docker://myregistry/ubi8/ubi:latest",
+		Type:        v2alpha1.TypeGeneric,
+	}
+	first := time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)
+	second := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	t.Run("Testing ReadMirroredImages - nothing mirrored : should return no image", func(t *testing.T) {
+		records, err := ReadMirroredImages(t.TempDir())
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("Testing RecordMirroredImages - mirrored again : should only update the last mirrored date", func(t *testing.T) {
+		workingDir := t.TempDir()
+		assert.NoError(t, RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{release, ubi}, first))
+		assert.NoError(t, RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{ubi, {Destination: "docker://myregistry/kubevirt:latest"}}, second))
+
+		records, err := ReadMirroredImages(workingDir)
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.MirroredImage{
+			{ImageName: release.Origin, ImageReference: release.Destination, Type: v2alpha1.TypeOCPRelease, FirstMirrored: first, LastMirrored: first},
+			{ImageName: ubi.Origin, ImageReference: ubi.Destination, Type: v2alpha1.TypeGeneric, FirstMirrored: first, LastMirrored: second},
+		}, records)
+	})
+
+	t.Run("Testing ForgetMirroredImages - deleted image : should remove its record", func(t *testing.T) {
+		workingDir := t.TempDir()
+		assert.NoError(t, RecordMirroredImages(workingDir, []v2alpha1.CopyImageSchema{release, ubi}, first))
+		assert.NoError(t, ForgetMirroredImages(workingDir, []string{release.Destination, "docker://myregistry/unknown:latest"}))
+
+		records, err := ReadMirroredImages(workingDir)
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.MirroredImage{
+			{ImageName: ubi.Origin, ImageReference: ubi.Destination, Type: v2alpha1.TypeGeneric, FirstMirrored: first, LastMirrored: first},
+		}, records)
+	})
+
+	t.Run("Testing ForgetMirroredImages - nothing mirrored : should not record anything", func(t *testing.T) {
+		workingDir := t.TempDir()
+		assert.NoError(t, ForgetMirroredImages(workingDir, []string{release.Destination}))
+		assert.NoDirExists(t, filepath.Join(workingDir, historyPath))
+	})
+}
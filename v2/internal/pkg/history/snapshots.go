@@ -0,0 +1,326 @@
+package history
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Snapshot is a history file: the blobs included in the archives built from a working-dir, up to its date
+type Snapshot struct {
+	// Name is the date of the history file, as written in its name
+	Name  string    `json:"name"`
+	Date  time.Time `json:"date"`
+	Blobs int       `json:"blobs"`
+	// AddedBlobs is the number of blobs added to the previous snapshot: the blobs of the archive built at its date
+	AddedBlobs int `json:"addedBlobs"`
+	// Images is the number of images of the archive with added blobs, when recorded
+	Images       int  `json:"images"`
+	NotDelivered bool `json:"notDelivered,omitempty"`
+}
+
+// SnapshotDiff is what a snapshot added to the previous one
+type SnapshotDiff struct {
+	Snapshot string   `json:"snapshot"`
+	Blobs    []string `json:"blobs"`
+	// Images are the blobs added by image, when recorded
+	Images       map[string][]string `json:"images,omitempty"`
+	NotDelivered bool                `json:"notDelivered,omitempty"`
+}
+
+// snapshotMetadata is recorded alongside a history file, under the snapshots directory
+type snapshotMetadata struct {
+	Images       map[string][]string `json:"images,omitempty"`
+	NotDelivered bool                `json:"notDelivered,omitempty"`
+	// NotDeliveredBlobs are the blobs the snapshot added, removed from it and from the next snapshots
+	// when its archive was marked as not delivered
+	NotDeliveredBlobs []string `json:"notDeliveredBlobs,omitempty"`
+}
+
+type snapshotFile struct {
+	name string
+	date time.Time
+	path string
+}
+
+// RecordImages records the blobs added by each image in the latest snapshot, so that they can be shown later
+func (o history) RecordImages(images map[string][]string) error {
+	files, err := snapshotFiles(o.historyDir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return EmptyHistoryErrorf("no history metadata found under %s", filepath.Dir(o.historyDir))
+	}
+	latest := files[len(files)-1]
+	metadata, err := readSnapshotMetadata(o.historyDir, latest.name)
+	if err != nil {
+		return err
+	}
+	metadata.Images = images
+	return writeSnapshotMetadata(o.historyDir, latest.name, metadata)
+}
+
+// ListSnapshots returns the snapshots of the history of workingDir, from the oldest to the latest
+func ListSnapshots(workingDir string) ([]Snapshot, error) {
+	historyDir := filepath.Join(workingDir, historyPath)
+	files, err := snapshotFiles(historyDir)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0, len(files))
+	previous := map[string]struct{}{}
+	for _, f := range files {
+		blobs, err := readBlobFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := readSnapshotMetadata(historyDir, f.name)
+		if err != nil {
+			return nil, err
+		}
+		snapshot := Snapshot{
+			Name:         f.name,
+			Date:         f.date,
+			Blobs:        len(blobs),
+			AddedBlobs:   len(addedBlobs(previous, blobs)),
+			Images:       len(metadata.Images),
+			NotDelivered: metadata.NotDelivered,
+		}
+		if metadata.NotDelivered {
+			snapshot.AddedBlobs = len(metadata.NotDeliveredBlobs)
+		}
+		snapshots = append(snapshots, snapshot)
+		previous = blobs
+	}
+	return snapshots, nil
+}
+
+// ShowSnapshot returns the blobs, and the images when recorded, the snapshot name of the history of workingDir added
+func ShowSnapshot(workingDir, name string) (SnapshotDiff, error) {
+	historyDir := filepath.Join(workingDir, historyPath)
+	files, i, err := findSnapshot(historyDir, name)
+	if err != nil {
+		return SnapshotDiff{}, err
+	}
+	previous, current, err := snapshotBlobs(files, i)
+	if err != nil {
+		return SnapshotDiff{}, err
+	}
+	metadata, err := readSnapshotMetadata(historyDir, files[i].name)
+	if err != nil {
+		return SnapshotDiff{}, err
+	}
+	diff := SnapshotDiff{
+		Snapshot:     files[i].name,
+		Blobs:        addedBlobs(previous, current),
+		Images:       metadata.Images,
+		NotDelivered: metadata.NotDelivered,
+	}
+	if metadata.NotDelivered {
+		diff.Blobs = metadata.NotDeliveredBlobs
+	}
+	return diff, nil
+}
+
+// Rollback removes the snapshots of the history of workingDir after the snapshot name, and returns their names:
+// the next archive only leaves out the blobs of the archives built up to the snapshot name.
+func Rollback(workingDir, name string) ([]string, error) {
+	historyDir := filepath.Join(workingDir, historyPath)
+	files, i, err := findSnapshot(historyDir, name)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, f := range files[i+1:] {
+		if err := os.Remove(f.path); err != nil {
+			return removed, fmt.Errorf("error removing the history file %w", err)
+		}
+		if err := os.Remove(snapshotMetadataPath(historyDir, f.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("error removing the history file %w", err)
+		}
+		removed = append(removed, f.name)
+	}
+	return removed, nil
+}
+
+// MarkNotDelivered removes the blobs the snapshot name added from it and from the next snapshots of the history
+// of workingDir, so that the next archive includes them again, and returns them
+func MarkNotDelivered(workingDir, name string) ([]string, error) {
+	historyDir := filepath.Join(workingDir, historyPath)
+	files, i, err := findSnapshot(historyDir, name)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := readSnapshotMetadata(historyDir, files[i].name)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.NotDelivered {
+		return nil, fmt.Errorf("the archive of the history snapshot %s is already marked as not delivered", files[i].name)
+	}
+	previous, current, err := snapshotBlobs(files, i)
+	if err != nil {
+		return nil, err
+	}
+	notDelivered := addedBlobs(previous, current)
+	for _, f := range files[i:] {
+		blobs, err := readBlobFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range notDelivered {
+			delete(blobs, blob)
+		}
+		if err := writeBlobFile(f.path, blobs); err != nil {
+			return nil, err
+		}
+	}
+	metadata.NotDelivered = true
+	metadata.NotDeliveredBlobs = notDelivered
+	if err := writeSnapshotMetadata(historyDir, files[i].name, metadata); err != nil {
+		return nil, err
+	}
+	return notDelivered, nil
+}
+
+// snapshotFiles returns the history files of historyDir, from the oldest to the latest
+func snapshotFiles(historyDir string) ([]snapshotFile, error) {
+	entries, err := os.ReadDir(historyDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading a directory %w", err)
+	}
+	var files []snapshotFile
+	for _, entry := range entries {
+		if !isHistoryFile(entry) {
+			continue
+		}
+		name := strings.TrimPrefix(entry.Name(), historyNamePrefix)
+		date, err := time.Parse(time.RFC3339, name)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing time %w", err)
+		}
+		files = append(files, snapshotFile{name: name, date: date, path: filepath.Join(historyDir, entry.Name())})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].date.Before(files[j].date) })
+	return files, nil
+}
+
+// findSnapshot returns the history files of historyDir, and the index of the snapshot name.
+// The name is the date of the history file, with or without the prefix of the file name.
+func findSnapshot(historyDir, name string) ([]snapshotFile, int, error) {
+	files, err := snapshotFiles(historyDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	name = strings.TrimPrefix(filepath.Base(name), historyNamePrefix)
+	for i, f := range files {
+		if f.name == name {
+			return files, i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("no history snapshot %s found under %s", name, filepath.Dir(historyDir))
+}
+
+// snapshotBlobs returns the blobs of the snapshot before files[i], and of files[i]
+func snapshotBlobs(files []snapshotFile, i int) (map[string]struct{}, map[string]struct{}, error) {
+	previous := map[string]struct{}{}
+	if i > 0 {
+		blobs, err := readBlobFile(files[i-1].path)
+		if err != nil {
+			return nil, nil, err
+		}
+		previous = blobs
+	}
+	current, err := readBlobFile(files[i].path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return previous, current, nil
+}
+
+// addedBlobs returns the blobs of current which are not in previous, sorted
+func addedBlobs(previous, current map[string]struct{}) []string {
+	added := []string{}
+	for blob := range current {
+		if _, ok := previous[blob]; !ok {
+			added = append(added, blob)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+func readBlobFile(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening a file %w", err)
+	}
+	defer file.Close()
+
+	blobs := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		blobs[scanner.Text()] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error non-EOF found %w", err)
+	}
+	return blobs, nil
+}
+
+func writeBlobFile(path string, blobs map[string]struct{}) error {
+	sorted := make([]string, 0, len(blobs))
+	for blob := range blobs {
+		sorted = append(sorted, blob+"\n")
+	}
+	sort.Strings(sorted)
+	if err := os.WriteFile(path, []byte(strings.Join(sorted, "")), 0644); err != nil { // nolint:gosec // G306: no sensitive data
+		return fmt.Errorf("unable to write to history file: %w", err)
+	}
+	return nil
+}
+
+func snapshotMetadataPath(historyDir, name string) string {
+	return filepath.Join(historyDir, snapshotsPath, name+".yaml")
+}
+
+// readSnapshotMetadata returns the metadata of the snapshot name, empty when none was recorded
+func readSnapshotMetadata(historyDir, name string) (snapshotMetadata, error) {
+	var metadata snapshotMetadata
+	data, err := os.ReadFile(snapshotMetadataPath(historyDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return metadata, nil
+	}
+	if err != nil {
+		return metadata, fmt.Errorf("error reading the history snapshot metadata: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return metadata, fmt.Errorf("error parsing the history snapshot metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+func writeSnapshotMetadata(historyDir, name string, metadata snapshotMetadata) error {
+	data, err := yaml.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("error marshaling the history snapshot metadata: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(historyDir, snapshotsPath), 0755); err != nil {
+		return fmt.Errorf("error creating directories %w", err)
+	}
+	if err := os.WriteFile(snapshotMetadataPath(historyDir, name), data, 0644); err != nil { // nolint:gosec // G306: no sensitive data
+		return fmt.Errorf("error writing the history snapshot metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+// newSnapshotsWorkingDir returns a working-dir with the history of 3 archives:
+// the first one with blobs a and b, the second one with c and d, the latest one with e
+func newSnapshotsWorkingDir(t *testing.T) string {
+	workingDir := t.TempDir()
+	historyDir := filepath.Join(workingDir, historyPath)
+	assert.NoError(t, os.MkdirAll(historyDir, 0755))
+	snapshots := map[string]string{
+		"2024-01-01T00:00:00Z": "sha256:a\nsha256:b\n",
+		"2024-02-01T00:00:00Z": "sha256:a\nsha256:b\nsha256:c\nsha256:d\n",
+		"2024-03-01T00:00:00Z": "sha256:a\nsha256:b\nsha256:c\nsha256:d\nsha256:e\n",
+	}
+	for name, blobs := range snapshots {
+		assert.NoError(t, os.WriteFile(filepath.Join(historyDir, historyNamePrefix+name), []byte(blobs), 0644))
+	}
+	return workingDir
+}
+
+func TestListSnapshots(t *testing.T) {
+	t.Run("Testing ListSnapshots : should return the snapshots with their counts", func(t *testing.T) {
+		snapshots, err := ListSnapshots(newSnapshotsWorkingDir(t))
+		assert.NoError(t, err)
+		assert.Equal(t, []Snapshot{
+			{Name: "2024-01-01T00:00:00Z", Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Blobs: 2, AddedBlobs: 2},
+			{Name: "2024-02-01T00:00:00Z", Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Blobs: 4, AddedBlobs: 2},
+			{Name: "2024-03-01T00:00:00Z", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Blobs: 5, AddedBlobs: 1},
+		}, snapshots)
+	})
+
+	t.Run("Testing ListSnapshots - no history : should return no snapshot", func(t *testing.T) {
+		snapshots, err := ListSnapshots(t.TempDir())
+		assert.NoError(t, err)
+		assert.Empty(t, snapshots)
+	})
+}
+
+func TestShowSnapshot(t *testing.T) {
+	t.Run("Testing ShowSnapshot : should return the blobs and images the snapshot added", func(t *testing.T) {
+		workingDir := newSnapshotsWorkingDir(t)
+		h, err := NewHistory(workingDir, time.Time{}, clog.New("trace"), OSFileCreator{})
+		assert.NoError(t, err)
+		assert.NoError(t, h.RecordImages(map[string][]string{"docker://registry.redhat.io/ubi8/ubi:latest": {"sha256:e"}}))
+
+		diff, err := ShowSnapshot(workingDir, "2024-02-01T00:00:00Z")
+		assert.NoError(t, err)
+		assert.Equal(t, SnapshotDiff{Snapshot: "2024-02-01T00:00:00Z", Blobs: []string{"sha256:c", "sha256:d"}}, diff)
+
+		diff, err = ShowSnapshot(workingDir, historyNamePrefix+"2024-03-01T00:00:00Z")
+		assert.NoError(t, err)
+		assert.Equal(t, SnapshotDiff{
+			Snapshot: "2024-03-01T00:00:00Z",
+			Blobs:    []string{"sha256:e"},
+			Images:   map[string][]string{"docker://registry.redhat.io/ubi8/ubi:latest": {"sha256:e"}},
+		}, diff)
+
+		snapshots, err := ListSnapshots(workingDir)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, snapshots[2].Images)
+	})
+
+	t.Run("Testing ShowSnapshot - unknown snapshot : should fail", func(t *testing.T) {
+		_, err := ShowSnapshot(newSnapshotsWorkingDir(t), "2024-04-01T00:00:00Z")
+		assert.ErrorContains(t, err, "no history snapshot 2024-04-01T00:00:00Z found")
+	})
+}
+
+func TestRollback(t *testing.T) {
+	t.Run("Testing Rollback : should remove the next snapshots", func(t *testing.T) {
+		workingDir := newSnapshotsWorkingDir(t)
+		removed, err := Rollback(workingDir, "2024-01-01T00:00:00Z")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2024-02-01T00:00:00Z", "2024-03-01T00:00:00Z"}, removed)
+
+		h, err := NewHistory(workingDir, time.Time{}, clog.New("trace"), OSFileCreator{})
+		assert.NoError(t, err)
+		blobs, err := h.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:a": {}, "sha256:b": {}}, blobs)
+	})
+
+	t.Run("Testing Rollback - latest snapshot : should remove nothing", func(t *testing.T) {
+		removed, err := Rollback(newSnapshotsWorkingDir(t), "2024-03-01T00:00:00Z")
+		assert.NoError(t, err)
+		assert.Empty(t, removed)
+	})
+}
+
+func TestMarkNotDelivered(t *testing.T) {
+	t.Run("Testing MarkNotDelivered : should remove the blobs of the archive from the history", func(t *testing.T) {
+		workingDir := newSnapshotsWorkingDir(t)
+		notDelivered, err := MarkNotDelivered(workingDir, "2024-02-01T00:00:00Z")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sha256:c", "sha256:d"}, notDelivered)
+
+		h, err := NewHistory(workingDir, time.Time{}, clog.New("trace"), OSFileCreator{})
+		assert.NoError(t, err)
+		blobs, err := h.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"sha256:a": {}, "sha256:b": {}, "sha256:e": {}}, blobs)
+
+		snapshots, err := ListSnapshots(workingDir)
+		assert.NoError(t, err)
+		assert.True(t, snapshots[1].NotDelivered)
+		assert.Equal(t, 2, snapshots[1].AddedBlobs)
+		assert.Equal(t, 1, snapshots[2].AddedBlobs)
+
+		diff, err := ShowSnapshot(workingDir, "2024-02-01T00:00:00Z")
+		assert.NoError(t, err)
+		assert.True(t, diff.NotDelivered)
+		assert.Equal(t, []string{"sha256:c", "sha256:d"}, diff.Blobs)
+	})
+
+	t.Run("Testing MarkNotDelivered - already marked : should fail", func(t *testing.T) {
+		workingDir := newSnapshotsWorkingDir(t)
+		_, err := MarkNotDelivered(workingDir, "2024-03-01T00:00:00Z")
+		assert.NoError(t, err)
+		_, err = MarkNotDelivered(workingDir, "2024-03-01T00:00:00Z")
+		assert.ErrorContains(t, err, "already marked as not delivered")
+	})
+}
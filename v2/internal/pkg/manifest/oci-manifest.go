@@ -232,3 +232,58 @@ func (o Manifest) GetDigest(ctx context.Context, sourceCtx *types.SystemContext,
 
 	return digest.Encoded(), nil
 }
+
+// ImageBlobSizes returns the compressed size of each blob (layers and config) of imgRef, by digest.
+// For a manifest list, the blobs of all the instances are returned.
+func ImageBlobSizes(ctx context.Context, sourceCtx *types.SystemContext, imgRef string) (map[string]int64, error) {
+	if err := mirror.ReexecIfNecessaryForImages(imgRef); err != nil {
+		return nil, fmt.Errorf("reexec mirror: %w", err)
+	}
+
+	srcRef, err := alltransports.ParseImageName(imgRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source name %s: %w", imgRef, err)
+	}
+
+	img, err := srcRef.NewImageSource(ctx, sourceCtx)
+	if err != nil {
+		return nil, fmt.Errorf("new image source: %w", err)
+	}
+	defer img.Close()
+
+	manifestBytes, mimeType, err := img.GetManifest(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest: %w", err)
+	}
+
+	sizes := map[string]int64{}
+	if !manifest.MIMETypeIsMultiImage(mimeType) {
+		return sizes, addBlobSizes(sizes, manifestBytes, mimeType)
+	}
+	list, err := manifest.ListFromBlob(manifestBytes, mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest list: %w", err)
+	}
+	for _, instance := range list.Instances() {
+		instanceBytes, instanceMimeType, err := img.GetManifest(ctx, &instance)
+		if err != nil {
+			return nil, fmt.Errorf("get manifest %s: %w", instance, err)
+		}
+		if err := addBlobSizes(sizes, instanceBytes, instanceMimeType); err != nil {
+			return nil, err
+		}
+	}
+	return sizes, nil
+}
+
+func addBlobSizes(sizes map[string]int64, manifestBytes []byte, mimeType string) error {
+	m, err := manifest.FromBlob(manifestBytes, mimeType)
+	if err != nil {
+		return fmt.Errorf("parse manifest: %w", err)
+	}
+	for _, layer := range m.LayerInfos() {
+		sizes[layer.Digest.String()] = layer.Size
+	}
+	sizes[m.ConfigInfo().Digest.String()] = m.ConfigInfo().Size
+	return nil
+}
@@ -0,0 +1,230 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	regimage "github.com/operator-framework/operator-registry/pkg/image"
+	"github.com/operator-framework/operator-registry/pkg/registry"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+// additionalBundle is a bundle added to a catalog, with its entry in the channels of its package
+type additionalBundle struct {
+	Bundle         declcfg.Bundle
+	Channels       []string
+	DefaultChannel string
+	Entry          declcfg.ChannelEntry
+}
+
+// renderAdditionalBundles renders the additional bundles of op, from their directory or from their image
+func (o FilterCollector) renderAdditionalBundles(ctx context.Context, op v2alpha1.Operator) ([]additionalBundle, error) {
+	bundles := make([]additionalBundle, 0, len(op.AdditionalBundles))
+	for _, ab := range op.AdditionalBundles {
+		dir := ab.Path
+		if dir == "" {
+			var err error
+			dir, err = o.pullBundleImage(ctx, ab.Image)
+			if err != nil {
+				return nil, fmt.Errorf("additional bundle %s: %w", ab.Image, err)
+			}
+		}
+		b, err := renderBundleDir(dir, ab.Image)
+		if err != nil {
+			return nil, fmt.Errorf("additional bundle %s: %w", ab.Image, err)
+		}
+		o.Log.Debug("catalog %s: additional bundle %s of package %s rendered from %s", op.Catalog, b.Bundle.Name, b.Bundle.Package, dir)
+		bundles = append(bundles, b)
+	}
+	return bundles, nil
+}
+
+// pullBundleImage copies the bundle image to the working directory, and returns the directory
+// its manifests and metadata are extracted to
+func (o FilterCollector) pullBundleImage(ctx context.Context, img string) (string, error) {
+	imgSpec, err := image.ParseRef(img)
+	if err != nil {
+		return "", err
+	}
+	srcCtx, err := o.Opts.SrcImage.NewSystemContext()
+	if err != nil {
+		return "", err
+	}
+	bundleDigest, err := o.Manifest.GetDigest(ctx, srcCtx, imgSpec.ReferenceWithTransport)
+	if err != nil {
+		return "", err
+	}
+
+	bundleDir := filepath.Join(o.Opts.Global.WorkingDir, operatorBundlesDir, imgSpec.ComponentName(), bundleDigest)
+	imageDir := filepath.Join(bundleDir, operatorBundleImageDir)
+	contentDir := filepath.Join(bundleDir, operatorBundleContentDir)
+	if _, err := os.Stat(filepath.Join(imageDir, "index.json")); err != nil {
+		if err := createFolders([]string{imageDir}); err != nil {
+			return "", err
+		}
+		opts := o.Opts
+		opts.Stdout = io.Discard
+		opts.RemoveSignatures = true
+		if err := o.Mirror.Run(ctx, imgSpec.ReferenceWithTransport, ociProtocolTrimmed+imageDir, mirror.CopyMode, &opts); err != nil {
+			return "", err
+		}
+	}
+
+	index, err := o.Manifest.GetImageIndex(imageDir)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := o.firstImageManifest(img, imageDir, index)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range []string{"manifests", "metadata"} {
+		if err := o.Manifest.ExtractLayersOCI(filepath.Join(imageDir, blobsDir), contentDir, dir, manifest); err != nil {
+			return "", err
+		}
+	}
+	return contentDir, nil
+}
+
+// renderBundleDir renders the bundle directory dir, published as the bundle image img
+func renderBundleDir(dir, img string) (additionalBundle, error) {
+	input, err := registry.NewImageInput(regimage.SimpleReference(img), dir)
+	if err != nil {
+		return additionalBundle{}, err
+	}
+	b, err := bundleToDeclcfg(input.Bundle)
+	if err != nil {
+		return additionalBundle{}, err
+	}
+
+	replaces, err := input.Bundle.Replaces()
+	if err != nil {
+		return additionalBundle{}, err
+	}
+	skips, err := input.Bundle.Skips()
+	if err != nil {
+		return additionalBundle{}, err
+	}
+	skipRange, err := input.Bundle.SkipRange()
+	if err != nil {
+		return additionalBundle{}, err
+	}
+
+	channels := []string{}
+	defaultChannel := ""
+	if input.Bundle.Annotations != nil {
+		for _, ch := range strings.Split(input.Bundle.Annotations.Channels, ",") {
+			if ch = strings.TrimSpace(ch); ch != "" {
+				channels = append(channels, ch)
+			}
+		}
+		defaultChannel = input.Bundle.Annotations.DefaultChannelName
+	}
+	if len(channels) == 0 {
+		return additionalBundle{}, fmt.Errorf("bundle %s declares no channel in its annotations", b.Name)
+	}
+	if defaultChannel == "" {
+		defaultChannel = channels[0]
+	}
+
+	return additionalBundle{
+		Bundle:         b,
+		Channels:       channels,
+		DefaultChannel: defaultChannel,
+		Entry:          declcfg.ChannelEntry{Name: b.Name, Replaces: replaces, Skips: skips, SkipRange: skipRange},
+	}, nil
+}
+
+// bundleToDeclcfg returns the olm.bundle of bundle, as rendered by opm
+func bundleToDeclcfg(bundle *registry.Bundle) (declcfg.Bundle, error) {
+	objs, props, err := registry.ObjectsAndPropertiesFromBundle(bundle)
+	if err != nil {
+		return declcfg.Bundle{}, fmt.Errorf("get properties for bundle %q: %w", bundle.Name, err)
+	}
+	csv, err := bundle.ClusterServiceVersion()
+	if err != nil {
+		return declcfg.Bundle{}, fmt.Errorf("get CSV of bundle %q: %w", bundle.Name, err)
+	}
+	var spec struct {
+		RelatedImages []declcfg.RelatedImage `json:"relatedImages"`
+	}
+	if err := json.Unmarshal(csv.Spec, &spec); err != nil {
+		return declcfg.Bundle{}, fmt.Errorf("get related images of bundle %q: %w", bundle.Name, err)
+	}
+	csvJSON, err := json.Marshal(csv)
+	if err != nil {
+		return declcfg.Bundle{}, fmt.Errorf("marshal CSV of bundle %q: %w", bundle.Name, err)
+	}
+
+	// like opm, the bundle image and the operator images are related images
+	operatorImages, err := csv.GetOperatorImages()
+	if err != nil {
+		return declcfg.Bundle{}, fmt.Errorf("get operator images of bundle %q: %w", bundle.Name, err)
+	}
+	relatedImages := spec.RelatedImages
+	for _, img := range append([]string{bundle.BundleImage}, slices.Sorted(maps.Keys(operatorImages))...) {
+		if img != "" && !slices.ContainsFunc(relatedImages, func(ri declcfg.RelatedImage) bool { return ri.Image == img }) {
+			relatedImages = append(relatedImages, declcfg.RelatedImage{Image: img})
+		}
+	}
+	sort.Slice(relatedImages, func(i, j int) bool { return relatedImages[i].Image < relatedImages[j].Image })
+
+	return declcfg.Bundle{
+		Schema:        declcfg.SchemaBundle,
+		Name:          bundle.Name,
+		Package:       bundle.Package,
+		Image:         bundle.BundleImage,
+		Properties:    props,
+		RelatedImages: relatedImages,
+		Objects:       objs,
+		CsvJSON:       string(csvJSON),
+	}, nil
+}
+
+// addBundles adds the bundles to dc, creating their package and their channels when missing.
+// A bundle already in dc with the same image is left as is.
+// Like the dependencies, an added entry replaces its bundle only when it is in the channel,
+// and the newest head of the channel skips the other heads.
+func addBundles(dc declcfg.DeclarativeConfig, bundles []additionalBundle) (declcfg.DeclarativeConfig, error) {
+	dc.Channels = slices.Clone(dc.Channels)
+	for _, ab := range bundles {
+		if i := slices.IndexFunc(dc.Bundles, func(b declcfg.Bundle) bool { return b.Package == ab.Bundle.Package && b.Name == ab.Bundle.Name }); i >= 0 {
+			if dc.Bundles[i].Image == ab.Bundle.Image {
+				continue
+			}
+			return declcfg.DeclarativeConfig{}, fmt.Errorf("bundle %s of package %s is already in the catalog, with image %s", ab.Bundle.Name, ab.Bundle.Package, dc.Bundles[i].Image)
+		}
+		if !slices.ContainsFunc(dc.Packages, func(pkg declcfg.Package) bool { return pkg.Name == ab.Bundle.Package }) {
+			dc.Packages = append(dc.Packages, declcfg.Package{Schema: declcfg.SchemaPackage, Name: ab.Bundle.Package, DefaultChannel: ab.DefaultChannel})
+		}
+		for _, channel := range ab.Channels {
+			i := slices.IndexFunc(dc.Channels, func(ch declcfg.Channel) bool { return ch.Package == ab.Bundle.Package && ch.Name == channel })
+			if i < 0 {
+				dc.Channels = append(dc.Channels, declcfg.Channel{Schema: declcfg.SchemaChannel, Package: ab.Bundle.Package, Name: channel})
+				i = len(dc.Channels) - 1
+			}
+			ch := &dc.Channels[i]
+			entry := ab.Entry
+			entry.Skips = slices.Clone(entry.Skips)
+			if !slices.ContainsFunc(ch.Entries, func(e declcfg.ChannelEntry) bool { return e.Name == entry.Replaces }) {
+				entry.Replaces = ""
+			}
+			ch.Entries = append(slices.Clone(ch.Entries), entry)
+			keepSingleHead(ch, append(dc.Bundles, ab.Bundle))
+		}
+		dc.Bundles = append(dc.Bundles, ab.Bundle)
+	}
+	return dc, nil
+}
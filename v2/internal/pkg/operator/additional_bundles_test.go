@@ -0,0 +1,222 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/common"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+const testBundleCSV = `apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: inhouse-operator.v1.1.0
+  annotations:
+    olm.skipRange: '>=1.0.0 <1.1.0'
+spec:
+  version: 1.1.0
+  replaces: inhouse-operator.v1.0.0
+  relatedImages:
+  - name: operand
+    image: registry.example.com/inhouse/operand:v1.1.0
+  install:
+    strategy: deployment
+    spec:
+      deployments:
+      - name: inhouse-operator
+        spec:
+          template:
+            spec:
+              containers:
+              - name: manager
+                image: registry.example.com/inhouse/operator:v1.1.0
+`
+
+const testBundleAnnotations = `annotations:
+  operators.operatorframework.io.bundle.mediatype.v1: registry+v1
+  operators.operatorframework.io.bundle.manifests.v1: manifests/
+  operators.operatorframework.io.bundle.metadata.v1: metadata/
+  operators.operatorframework.io.bundle.package.v1: inhouse-operator
+  operators.operatorframework.io.bundle.channels.v1: stable,fast
+  operators.operatorframework.io.bundle.channel.default.v1: stable
+`
+
+func writeTestBundle(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "manifests"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "metadata"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifests", "inhouse-operator.clusterserviceversion.yaml"), []byte(testBundleCSV), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "metadata", "annotations.yaml"), []byte(testBundleAnnotations), 0644))
+	return dir
+}
+
+func TestRenderBundleDir(t *testing.T) {
+	t.Run("Testing renderBundleDir - bundle directory : should render the bundle and its channel entry", func(t *testing.T) {
+		ab, err := renderBundleDir(writeTestBundle(t), "registry.example.com/inhouse/bundle:v1.1.0")
+		assert.NoError(t, err)
+		assert.Equal(t, "inhouse-operator.v1.1.0", ab.Bundle.Name)
+		assert.Equal(t, "inhouse-operator", ab.Bundle.Package)
+		assert.Equal(t, "registry.example.com/inhouse/bundle:v1.1.0", ab.Bundle.Image)
+		assert.Equal(t, []string{"stable", "fast"}, ab.Channels)
+		assert.Equal(t, "stable", ab.DefaultChannel)
+		assert.Equal(t, declcfg.ChannelEntry{Name: "inhouse-operator.v1.1.0", Replaces: "inhouse-operator.v1.0.0", SkipRange: ">=1.0.0 <1.1.0"}, ab.Entry)
+		images := []string{}
+		for _, ri := range ab.Bundle.RelatedImages {
+			images = append(images, ri.Image)
+		}
+		assert.ElementsMatch(t, []string{
+			"registry.example.com/inhouse/bundle:v1.1.0",
+			"registry.example.com/inhouse/operand:v1.1.0",
+			"registry.example.com/inhouse/operator:v1.1.0",
+		}, images)
+	})
+
+	t.Run("Testing renderBundleDir - not a bundle directory : should fail", func(t *testing.T) {
+		_, err := renderBundleDir(t.TempDir(), "registry.example.com/inhouse/bundle:v1.1.0")
+		assert.Error(t, err)
+	})
+}
+
+func TestAddBundles(t *testing.T) {
+	upstream := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: declcfg.SchemaPackage, Name: "foo", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "foo.v1.0.0"}}}},
+		Bundles:  []declcfg.Bundle{{Schema: declcfg.SchemaBundle, Package: "foo", Name: "foo.v1.0.0", Image: "quay.io/foo/bundle:v1.0.0"}},
+	}
+	inhouse := additionalBundle{
+		Bundle:         declcfg.Bundle{Schema: declcfg.SchemaBundle, Package: "inhouse-operator", Name: "inhouse-operator.v1.1.0", Image: "registry.example.com/inhouse/bundle:v1.1.0"},
+		Channels:       []string{"stable", "fast"},
+		DefaultChannel: "stable",
+		Entry:          declcfg.ChannelEntry{Name: "inhouse-operator.v1.1.0", Replaces: "inhouse-operator.v1.0.0"},
+	}
+
+	t.Run("Testing addBundles - new package : should add the package, its channels and the bundle", func(t *testing.T) {
+		dc, err := addBundles(upstream, []additionalBundle{inhouse})
+		assert.NoError(t, err)
+		assert.Equal(t, []declcfg.Package{upstream.Packages[0], {Schema: declcfg.SchemaPackage, Name: "inhouse-operator", DefaultChannel: "stable"}}, dc.Packages)
+		assert.Len(t, dc.Channels, 3)
+		// the replaced bundle is not in the channel
+		assert.Equal(t, declcfg.Channel{Schema: declcfg.SchemaChannel, Package: "inhouse-operator", Name: "fast", Entries: []declcfg.ChannelEntry{{Name: "inhouse-operator.v1.1.0"}}}, dc.Channels[2])
+		assert.Equal(t, inhouse.Bundle, dc.Bundles[1])
+		assert.Len(t, upstream.Bundles, 1)
+	})
+
+	t.Run("Testing addBundles - existing channel : should add the bundle to the channel", func(t *testing.T) {
+		foo := additionalBundle{
+			Bundle:   declcfg.Bundle{Schema: declcfg.SchemaBundle, Package: "foo", Name: "foo.v1.1.0-custom", Image: "registry.example.com/inhouse/foo:v1.1.0"},
+			Channels: []string{"stable"},
+			Entry:    declcfg.ChannelEntry{Name: "foo.v1.1.0-custom", Replaces: "foo.v1.0.0"},
+		}
+		dc, err := addBundles(upstream, []additionalBundle{foo})
+		assert.NoError(t, err)
+		assert.Len(t, dc.Packages, 1)
+		assert.Equal(t, []declcfg.ChannelEntry{{Name: "foo.v1.0.0"}, foo.Entry}, dc.Channels[0].Entries)
+	})
+
+	t.Run("Testing addBundles - bundle not replacing the channel head : should keep a single head", func(t *testing.T) {
+		catalog := declcfg.DeclarativeConfig{
+			Packages: upstream.Packages,
+			Channels: []declcfg.Channel{{Schema: declcfg.SchemaChannel, Package: "foo", Name: "stable", Entries: []declcfg.ChannelEntry{{Name: "foo.v1.0.0"}, {Name: "foo.v1.1.0", Replaces: "foo.v1.0.0"}}}},
+			Bundles:  []declcfg.Bundle{testDependencyBundle("foo", "1.0.0"), testDependencyBundle("foo", "1.1.0")},
+		}
+		foo := additionalBundle{
+			Bundle:   testDependencyBundle("foo", "1.2.0"),
+			Channels: []string{"stable"},
+			Entry:    declcfg.ChannelEntry{Name: "foo.v1.2.0", Replaces: "foo.v1.0.0"},
+		}
+		dc, err := addBundles(catalog, []additionalBundle{foo})
+		assert.NoError(t, err)
+		assert.Equal(t, []declcfg.ChannelEntry{
+			{Name: "foo.v1.0.0"},
+			{Name: "foo.v1.1.0", Replaces: "foo.v1.0.0"},
+			{Name: "foo.v1.2.0", Replaces: "foo.v1.0.0", Skips: []string{"foo.v1.1.0"}},
+		}, dc.Channels[0].Entries)
+		assert.Len(t, catalog.Channels[0].Entries, 2)
+	})
+
+	t.Run("Testing addBundles - bundle already added : should leave the catalog as is", func(t *testing.T) {
+		dc, err := addBundles(upstream, []additionalBundle{inhouse})
+		assert.NoError(t, err)
+		again, err := addBundles(dc, []additionalBundle{inhouse})
+		assert.NoError(t, err)
+		assert.Equal(t, dc, again)
+	})
+
+	t.Run("Testing addBundles - bundle name already in the catalog with another image : should fail", func(t *testing.T) {
+		conflict := inhouse
+		conflict.Bundle = declcfg.Bundle{Package: "foo", Name: "foo.v1.0.0", Image: "registry.example.com/inhouse/foo:v1.0.0"}
+		_, err := addBundles(upstream, []additionalBundle{conflict})
+		assert.EqualError(t, err, "bundle foo.v1.0.0 of package foo is already in the catalog, with image quay.io/foo/bundle:v1.0.0")
+	})
+}
+
+func TestAdditionalBundlesMirrorToDiskThenDiskToMirror(t *testing.T) {
+	log := clog.New("trace")
+	ctx := context.Background()
+
+	config := v2alpha1.ImageSetConfiguration{
+		ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+			Mirror: v2alpha1.Mirror{
+				Operators: []v2alpha1.Operator{
+					{
+						Catalog:       "oci://" + common.TestFolder + "simple-test-bundle",
+						IncludeConfig: v2alpha1.IncludeConfig{Packages: []v2alpha1.IncludePackage{{Name: "op1"}}},
+						AdditionalBundles: []v2alpha1.AdditionalBundle{
+							{Image: "registry.example.com/inhouse/bundle:v1.1.0", Path: writeTestBundle(t)},
+						},
+					},
+				},
+			},
+		},
+	}
+	catalogOf := func(images []v2alpha1.CopyImageSchema) v2alpha1.CopyImageSchema {
+		for _, img := range images {
+			if img.Type == v2alpha1.TypeOperatorCatalog {
+				return img
+			}
+		}
+		t.Fatal("no catalog collected")
+		return v2alpha1.CopyImageSchema{}
+	}
+
+	t.Run("Testing OperatorImageCollector - additional bundles : diskToMirror should copy the catalog rebuilt by mirrorToDisk", func(t *testing.T) {
+		tempDir := t.TempDir()
+
+		m2d := setupFilterCollector_MirrorToDisk(tempDir, log, &MockManifest{Log: log}).withConfig(config)
+		m2dRes, err := m2d.OperatorImageCollector(ctx)
+		assert.NoError(t, err)
+		m2dCatalog := catalogOf(m2dRes.AllImages)
+		assert.NotEmpty(t, m2dCatalog.RebuiltTag)
+		filterDigest, err := digestOfFilter(config.Mirror.Operators[0])
+		assert.NoError(t, err)
+		assert.Equal(t, filterDigest, m2dCatalog.RebuiltTag)
+
+		// the filtered catalog, with its additional bundle, is in the working-dir shipped in the archive
+		configDirs, err := filepath.Glob(filepath.Join(tempDir, "working-dir", operatorCatalogsDir, "*", "*", operatorCatalogFilteredDir, m2dCatalog.RebuiltTag, operatorCatalogConfigDir))
+		assert.NoError(t, err)
+		assert.Len(t, configDirs, 1)
+		content, err := os.ReadFile(filepath.Join(configDirs[0], "inhouse-operator", "catalog.json"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"name": "inhouse-operator.v1.1.0"`)
+
+		d2m := setupFilterCollector_DiskToMirror(tempDir, log).withConfig(config)
+		d2mRes, err := d2m.OperatorImageCollector(ctx)
+		assert.NoError(t, err)
+		d2mCatalog := catalogOf(d2mRes.AllImages)
+		assert.Equal(t, m2dCatalog.RebuiltTag, d2mCatalog.RebuiltTag)
+		assert.Equal(t, "docker://localhost:9999/simple-test-bundle:"+m2dCatalog.RebuiltTag, d2mCatalog.Source)
+	})
+
+	t.Run("Testing OperatorImageCollector - additional bundles not filtered by mirrorToDisk : diskToMirror should fail", func(t *testing.T) {
+		d2m := setupFilterCollector_DiskToMirror(t.TempDir(), log).withConfig(config)
+		_, err := d2m.OperatorImageCollector(ctx)
+		assert.ErrorContains(t, err, "with its additional bundles was not filtered by mirrorToDisk")
+	})
+}
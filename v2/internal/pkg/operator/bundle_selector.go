@@ -0,0 +1,300 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+const (
+	propertyMaxOpenShiftVersion = "olm.maxOpenShiftVersion"
+	csvPropertiesAnnotation     = "olm.properties"
+	csvArchLabelPrefix          = "operatorframework.io/arch."
+	csvArchSupported            = "supported"
+	defaultCSVArch              = "amd64"
+)
+
+// bundleMetadata is the subset of a bundle's properties and CSV metadata used by the BundleSelector
+type bundleMetadata struct {
+	maxOpenShiftVersion string
+	minKubeVersion      string
+	annotations         map[string]string
+	labels              map[string]string
+}
+
+// hasBundleSelector returns true when the catalog, or one of its packages, has a BundleSelector
+func hasBundleSelector(op v2alpha1.Operator) bool {
+	if op.BundleSelector != nil {
+		return true
+	}
+	return slices.ContainsFunc(op.Packages, func(pkg v2alpha1.IncludePackage) bool {
+		return pkg.BundleSelector != nil
+	})
+}
+
+// bundleSelectorOf returns the BundleSelector of the package, or of the catalog when the package has none
+func bundleSelectorOf(op v2alpha1.Operator, pkg string) *v2alpha1.BundleSelector {
+	for _, p := range op.Packages {
+		if p.Name == pkg && p.BundleSelector != nil {
+			return p.BundleSelector
+		}
+	}
+	return op.BundleSelector
+}
+
+// excludesDeprecated returns true when the BundleSelector of the package excludes the deprecated content
+func excludesDeprecated(op v2alpha1.Operator, pkg string) bool {
+	selector := bundleSelectorOf(op, pkg)
+	return selector != nil && selector.ExcludeDeprecated
+}
+
+// selectBundles removes from dc the bundles that do not match the BundleSelector
+// of their package (or of the catalog), before the catalog is filtered.
+// The deprecated content is excluded beforehand by skipDeprecatedContent.
+func selectBundles(dc declcfg.DeclarativeConfig, op v2alpha1.Operator) (declcfg.DeclarativeConfig, error) {
+	if !hasBundleSelector(op) {
+		return dc, nil
+	}
+	removed := map[string]map[string]bool{}
+	for _, b := range dc.Bundles {
+		selected := true
+		if selector := bundleSelectorOf(op, b.Package); selector != nil {
+			var err error
+			selected, err = isBundleSelected(b, *selector)
+			if err != nil {
+				return dc, fmt.Errorf("bundle %s: %w", b.Name, err)
+			}
+		}
+		if !selected {
+			if internalLog != nil {
+				internalLog.Debug("bundle %s of package %s does not match the bundleSelector: SKIPPING", b.Name, b.Package)
+			}
+			if removed[b.Package] == nil {
+				removed[b.Package] = map[string]bool{}
+			}
+			removed[b.Package][b.Name] = true
+		}
+	}
+	return removeBundles(dc, removed, "the bundleSelector"), nil
+}
+
+// removeBundles removes the removed bundles, per package, from dc.
+// The channels are rewired so that their upgrade graph stays consistent:
+// an entry replacing a removed bundle replaces the first bundle kept down the chain.
+// Channels and packages without any bundle left are removed, and packages whose
+// default channel was removed get a new one. reason is used for logging.
+func removeBundles(dc declcfg.DeclarativeConfig, removed map[string]map[string]bool, reason string) declcfg.DeclarativeConfig {
+	if len(removed) == 0 {
+		return dc
+	}
+	bundles := slices.DeleteFunc(slices.Clone(dc.Bundles), func(b declcfg.Bundle) bool {
+		return removed[b.Package][b.Name]
+	})
+	keptPackages := map[string]bool{}
+	for _, b := range bundles {
+		keptPackages[b.Package] = true
+	}
+
+	channels := []declcfg.Channel{}
+	keptChannels := map[string][]string{}
+	for _, ch := range dc.Channels {
+		if !keptPackages[ch.Package] {
+			continue
+		}
+		ch.Entries = RewireChannelEntries(ch.Entries, removed[ch.Package])
+		if len(ch.Entries) == 0 {
+			continue
+		}
+		channels = append(channels, ch)
+		keptChannels[ch.Package] = append(keptChannels[ch.Package], ch.Name)
+	}
+
+	packages := []declcfg.Package{}
+	for _, pkg := range dc.Packages {
+		if !keptPackages[pkg.Name] || len(keptChannels[pkg.Name]) == 0 {
+			if internalLog != nil {
+				internalLog.Warn("no bundle of package %s left after applying %s: SKIPPING", pkg.Name, reason)
+			}
+			continue
+		}
+		if !slices.Contains(keptChannels[pkg.Name], pkg.DefaultChannel) {
+			sort.Strings(keptChannels[pkg.Name])
+			if internalLog != nil {
+				internalLog.Warn("no bundle of the default channel %s of package %s left after applying %s: using channel %s as default", pkg.DefaultChannel, pkg.Name, reason, keptChannels[pkg.Name][0])
+			}
+			pkg.DefaultChannel = keptChannels[pkg.Name][0]
+		}
+		packages = append(packages, pkg)
+	}
+
+	pruned := dc
+	pruned.Packages = packages
+	pruned.Channels = channels
+	pruned.Bundles = bundles
+	pruned.Deprecations = slices.DeleteFunc(slices.Clone(dc.Deprecations), func(d declcfg.Deprecation) bool {
+		return !keptPackages[d.Package]
+	})
+	return pruned
+}
+
+// RewireChannelEntries removes the entries of the removed bundles.
+// Entries replacing a removed bundle are made to replace the first kept bundle
+// down the replaces chain, and to skip the removed ones.
+func RewireChannelEntries(entries []declcfg.ChannelEntry, removed map[string]bool) []declcfg.ChannelEntry {
+	if len(removed) == 0 {
+		return entries
+	}
+	replaces := map[string]string{}
+	for _, e := range entries {
+		replaces[e.Name] = e.Replaces
+	}
+	kept := []declcfg.ChannelEntry{}
+	for _, e := range entries {
+		if removed[e.Name] {
+			continue
+		}
+		skips := slices.Clone(e.Skips)
+		// guard against cycles in the replaces chain
+		for seen := map[string]bool{}; removed[e.Replaces] && !seen[e.Replaces]; {
+			seen[e.Replaces] = true
+			if !slices.Contains(skips, e.Replaces) {
+				skips = append(skips, e.Replaces)
+			}
+			e.Replaces = replaces[e.Replaces]
+		}
+		if removed[e.Replaces] {
+			e.Replaces = ""
+		}
+		e.Skips = skips
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// isBundleSelected returns true when the bundle matches all the criteria of the selector
+func isBundleSelected(b declcfg.Bundle, selector v2alpha1.BundleSelector) (bool, error) {
+	metadata, err := getBundleMetadata(b)
+	if err != nil {
+		return false, err
+	}
+	if selector.OpenShiftVersion != "" && metadata.maxOpenShiftVersion != "" {
+		compatible, err := isOpenShiftVersionCompatible(selector.OpenShiftVersion, metadata.maxOpenShiftVersion)
+		if err != nil {
+			return false, err
+		}
+		if !compatible {
+			return false, nil
+		}
+	}
+	for key, value := range selector.Annotations {
+		if metadata.annotations[key] != value {
+			return false, nil
+		}
+	}
+	if len(selector.Architectures) > 0 {
+		supported := supportedArchitectures(metadata.labels)
+		for _, arch := range selector.Architectures {
+			if !slices.Contains(supported, arch) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// isOpenShiftVersionCompatible compares the major and minor versions only:
+// a bundle with olm.maxOpenShiftVersion 4.15 can be installed on any 4.15.z cluster
+func isOpenShiftVersionCompatible(openShiftVersion, maxOpenShiftVersion string) (bool, error) {
+	target, err := semver.ParseTolerant(openShiftVersion)
+	if err != nil {
+		return false, err
+	}
+	max, err := semver.ParseTolerant(maxOpenShiftVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: %w", propertyMaxOpenShiftVersion, maxOpenShiftVersion, err)
+	}
+	target.Patch, target.Pre, target.Build = 0, nil, nil
+	max.Patch, max.Pre, max.Build = 0, nil, nil
+	return target.LTE(max), nil
+}
+
+func supportedArchitectures(labels map[string]string) []string {
+	archs := []string{}
+	for key, value := range labels {
+		if strings.HasPrefix(key, csvArchLabelPrefix) && value == csvArchSupported {
+			archs = append(archs, strings.TrimPrefix(key, csvArchLabelPrefix))
+		}
+	}
+	if len(archs) == 0 {
+		archs = append(archs, defaultCSVArch)
+	}
+	return archs
+}
+
+// getBundleMetadata reads the CSV metadata from the olm.csv.metadata property, or from the
+// CSV in the olm.bundle.object properties for older catalogs.
+// olm.maxOpenShiftVersion is read from the bundle properties, or from the olm.properties CSV annotation.
+func getBundleMetadata(b declcfg.Bundle) (bundleMetadata, error) {
+	metadata := bundleMetadata{}
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return metadata, err
+	}
+	if len(props.CSVMetadatas) > 0 {
+		metadata.annotations = props.CSVMetadatas[0].Annotations
+		metadata.labels = props.CSVMetadatas[0].Labels
+		metadata.minKubeVersion = props.CSVMetadatas[0].MinKubeVersion
+	} else {
+		for _, obj := range props.BundleObjects {
+			var csv struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+					Labels      map[string]string `json:"labels"`
+				} `json:"metadata"`
+				Spec struct {
+					MinKubeVersion string `json:"minKubeVersion"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal(obj.Data, &csv); err != nil {
+				return metadata, err
+			}
+			if csv.Kind == "ClusterServiceVersion" {
+				metadata.annotations = csv.Metadata.Annotations
+				metadata.labels = csv.Metadata.Labels
+				metadata.minKubeVersion = csv.Spec.MinKubeVersion
+				break
+			}
+		}
+	}
+
+	others := props.Others
+	if csvProperties, ok := metadata.annotations[csvPropertiesAnnotation]; ok {
+		var annotationProps []property.Property
+		if err := json.Unmarshal([]byte(csvProperties), &annotationProps); err == nil {
+			others = append(others, annotationProps...)
+		}
+	}
+	for _, p := range others {
+		if p.Type != propertyMaxOpenShiftVersion {
+			continue
+		}
+		// the value is either a string or a number: numbers are kept as written, so that 4.10 is not read as 4.1
+		var value string
+		if err := json.Unmarshal(p.Value, &value); err != nil {
+			value = string(p.Value)
+		}
+		metadata.maxOpenShiftVersion = strings.TrimSpace(value)
+		break
+	}
+	return metadata, nil
+}
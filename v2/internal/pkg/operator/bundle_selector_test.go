@@ -0,0 +1,210 @@
+package operator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+func testSelectorBundle(name, maxOpenShiftVersion string, annotations, labels map[string]string) declcfg.Bundle {
+	props := []property.Property{
+		property.MustBuildPackage("foo", name[len("foo.v"):]),
+		property.MustBuild(&property.CSVMetadata{Annotations: annotations, Labels: labels}),
+	}
+	if maxOpenShiftVersion != "" {
+		value, _ := json.Marshal(maxOpenShiftVersion)
+		props = append(props, property.Property{Type: propertyMaxOpenShiftVersion, Value: value})
+	}
+	return declcfg.Bundle{Schema: "olm.bundle", Name: name, Package: "foo", Properties: props}
+}
+
+func testSelectorCatalog() declcfg.DeclarativeConfig {
+	disconnected := map[string]string{"features.operators.openshift.io/disconnected": "true"}
+	multiArch := map[string]string{
+		"operatorframework.io/arch.amd64": "supported",
+		"operatorframework.io/arch.arm64": "supported",
+	}
+	return declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Schema: "olm.package", Name: "foo", DefaultChannel: "stable"}},
+		Channels: []declcfg.Channel{
+			{Schema: "olm.channel", Name: "stable", Package: "foo", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v1.0.0"},
+				{Name: "foo.v1.1.0", Replaces: "foo.v1.0.0"},
+				{Name: "foo.v1.2.0", Replaces: "foo.v1.1.0"},
+				{Name: "foo.v1.3.0", Replaces: "foo.v1.2.0"},
+			}},
+			{Schema: "olm.channel", Name: "fast", Package: "foo", Entries: []declcfg.ChannelEntry{
+				{Name: "foo.v2.0.0"},
+			}},
+		},
+		Bundles: []declcfg.Bundle{
+			testSelectorBundle("foo.v1.0.0", "4.14", disconnected, multiArch),
+			testSelectorBundle("foo.v1.1.0", "", nil, nil),
+			testSelectorBundle("foo.v1.2.0", "4.16", disconnected, nil),
+			testSelectorBundle("foo.v1.3.0", "4.17", disconnected, multiArch),
+			testSelectorBundle("foo.v2.0.0", "4.18", disconnected, multiArch),
+		},
+		Deprecations: []declcfg.Deprecation{
+			{Schema: "olm.deprecations", Package: "foo", Entries: []declcfg.DeprecationEntry{
+				{Reference: declcfg.PackageScopedReference{Schema: deprecationSchemaBundle, Name: "foo.v1.3.0"}, Message: "deprecated"},
+			}},
+		},
+	}
+}
+
+func TestSelectBundles(t *testing.T) {
+	type testCase struct {
+		caseName               string
+		op                     v2alpha1.Operator
+		expectedBundles        []string
+		expectedEntries        map[string][]declcfg.ChannelEntry
+		expectedDefaultChannel string
+		expectedError          string
+	}
+
+	testCases := []testCase{
+		{
+			caseName:               "no bundleSelector - should keep the catalog untouched",
+			op:                     v2alpha1.Operator{},
+			expectedBundles:        []string{"foo.v1.0.0", "foo.v1.1.0", "foo.v1.2.0", "foo.v1.3.0", "foo.v2.0.0"},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName: "catalog bundleSelector on openShiftVersion - should remove incompatible bundles and rewire the channels",
+			op: v2alpha1.Operator{
+				BundleSelector: &v2alpha1.BundleSelector{OpenShiftVersion: "4.16.3"},
+			},
+			expectedBundles: []string{"foo.v1.1.0", "foo.v1.2.0", "foo.v1.3.0", "foo.v2.0.0"},
+			expectedEntries: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v1.1.0", Skips: []string{"foo.v1.0.0"}},
+					{Name: "foo.v1.2.0", Replaces: "foo.v1.1.0"},
+					{Name: "foo.v1.3.0", Replaces: "foo.v1.2.0"},
+				},
+			},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName: "package bundleSelector on annotations - should skip removed bundles down the replaces chain",
+			op: v2alpha1.Operator{
+				IncludeConfig: v2alpha1.IncludeConfig{
+					Packages: []v2alpha1.IncludePackage{{
+						Name: "foo",
+						BundleSelector: &v2alpha1.BundleSelector{
+							Annotations: map[string]string{"features.operators.openshift.io/disconnected": "true"},
+						},
+					}},
+				},
+			},
+			expectedBundles: []string{"foo.v1.0.0", "foo.v1.2.0", "foo.v1.3.0", "foo.v2.0.0"},
+			expectedEntries: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v1.0.0"},
+					{Name: "foo.v1.2.0", Replaces: "foo.v1.0.0", Skips: []string{"foo.v1.1.0"}},
+					{Name: "foo.v1.3.0", Replaces: "foo.v1.2.0"},
+				},
+			},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName: "package bundleSelector overrides the catalog one - should select on architectures and deprecation",
+			op: v2alpha1.Operator{
+				BundleSelector: &v2alpha1.BundleSelector{OpenShiftVersion: "4.19"},
+				IncludeConfig: v2alpha1.IncludeConfig{
+					Packages: []v2alpha1.IncludePackage{{
+						Name: "foo",
+						BundleSelector: &v2alpha1.BundleSelector{
+							Architectures:     []string{"arm64"},
+							ExcludeDeprecated: true,
+						},
+					}},
+				},
+			},
+			expectedBundles: []string{"foo.v1.0.0", "foo.v2.0.0"},
+			expectedEntries: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v1.0.0"},
+				},
+			},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName: "no bundle of the default channel selected - should use another channel as default",
+			op: v2alpha1.Operator{
+				BundleSelector: &v2alpha1.BundleSelector{
+					OpenShiftVersion:  "4.18",
+					Architectures:     []string{"arm64"},
+					ExcludeDeprecated: true,
+				},
+			},
+			expectedBundles:        []string{"foo.v2.0.0"},
+			expectedDefaultChannel: "fast",
+		},
+		{
+			caseName: "invalid openShiftVersion - should fail",
+			op: v2alpha1.Operator{
+				BundleSelector: &v2alpha1.BundleSelector{OpenShiftVersion: "latest"},
+			},
+			expectedError: "bundle foo.v1.0.0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(t *testing.T) {
+			selected, err := selectBundles(skipDeprecatedContent(testSelectorCatalog(), testCase.op), testCase.op)
+			if testCase.expectedError != "" {
+				assert.ErrorContains(t, err, testCase.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+
+			bundles := []string{}
+			for _, b := range selected.Bundles {
+				bundles = append(bundles, b.Name)
+			}
+			assert.Equal(t, testCase.expectedBundles, bundles)
+			for _, ch := range selected.Channels {
+				if expected, ok := testCase.expectedEntries[ch.Name]; ok {
+					assert.Equal(t, expected, ch.Entries)
+				}
+			}
+			assert.Len(t, selected.Packages, 1)
+			assert.Equal(t, testCase.expectedDefaultChannel, selected.Packages[0].DefaultChannel)
+		})
+	}
+}
+
+func TestIsOpenShiftVersionCompatible(t *testing.T) {
+	compatible, err := isOpenShiftVersionCompatible("4.15.12", "4.15")
+	assert.NoError(t, err)
+	assert.True(t, compatible)
+
+	compatible, err = isOpenShiftVersionCompatible("4.16", "4.15")
+	assert.NoError(t, err)
+	assert.False(t, compatible)
+
+	_, err = isOpenShiftVersionCompatible("4.16", "four")
+	assert.ErrorContains(t, err, "invalid olm.maxOpenShiftVersion")
+}
+
+func TestGetBundleMetadata(t *testing.T) {
+	t.Run("maxOpenShiftVersion as a number in the olm.properties annotation - should keep the minor version as written", func(t *testing.T) {
+		b := declcfg.Bundle{
+			Name: "foo.v1.0.0",
+			Properties: []property.Property{
+				property.MustBuild(&property.CSVMetadata{Annotations: map[string]string{
+					csvPropertiesAnnotation: `[{"type":"olm.maxOpenShiftVersion","value":4.10}]`,
+				}}),
+			},
+		}
+		metadata, err := getBundleMetadata(b)
+		assert.NoError(t, err)
+		assert.Equal(t, "4.10", metadata.maxOpenShiftVersion)
+		assert.Equal(t, []string{defaultCSVArch}, supportedArchitectures(metadata.labels))
+	})
+}
@@ -105,8 +105,43 @@ func filterCatalog(ctx context.Context, operatorCatalog declcfg.DeclarativeConfi
 	if err != nil {
 		return nil, err
 	}
+	// excluded content, skipped deprecated content, incompatible bundles and bundles not matching
+	// the bundleSelector are removed first, so that the channel heads are chosen among the remaining bundles
+	excludedCatalog := excludeFromCatalog(operatorCatalog, iscCatalogFilter)
+	selectedCatalog := skipDeprecatedContent(excludedCatalog, iscCatalogFilter)
+	selectedCatalog, err = excludeIncompatibleBundles(selectedCatalog, iscCatalogFilter)
+	if err != nil {
+		return nil, err
+	}
+	selectedCatalog, err = selectBundles(selectedCatalog, iscCatalogFilter)
+	if err != nil {
+		return nil, err
+	}
 	ctlgFilter := filter.NewMirrorFilter(config, []filter.FilterOption{filter.InFull(iscCatalogFilter.Full)}...)
-	return ctlgFilter.FilterCatalog(ctx, &operatorCatalog)
+	filteredCatalog, err := ctlgFilter.FilterCatalog(ctx, &selectedCatalog)
+	if err != nil {
+		return nil, err
+	}
+	if iscCatalogFilter.SkipDependencies {
+		if err := warnExcludedDependencies(operatorCatalog, excludedCatalog, *filteredCatalog, iscCatalogFilter); err != nil {
+			return nil, err
+		}
+		prunedCatalog := pruneDeprecations(*filteredCatalog)
+		return &prunedCatalog, nil
+	}
+
+	resolvedCatalog, missing, err := resolveDependencies(selectedCatalog, *filteredCatalog)
+	if err != nil {
+		return nil, err
+	}
+	// dependencies missing from this catalog are looked for in the other catalogs later on
+	if !iscCatalogFilter.ResolveDependenciesAcrossCatalogs && internalLog != nil {
+		for _, dep := range missing {
+			internalLog.Warn("catalog %s: dependency %s of bundle %s not found", iscCatalogFilter.Catalog, dep, dep.requiredBy)
+		}
+	}
+	resolvedCatalog = pruneDeprecations(resolvedCatalog)
+	return &resolvedCatalog, nil
 }
 
 func (o catalogHandler) getCatalog(filePath string) (OperatorCatalog, error) {
@@ -206,13 +206,10 @@ func (o OperatorCollector) prepareD2MCopyBatch(images map[string][]v2alpha1.Rela
 
 			o.Log.Debug("source %s", src)
 			o.Log.Debug("destination %s", dest)
-			if img.Type == v2alpha1.TypeOperatorCatalog && o.Opts.Function == "delete" {
-				o.Log.Debug("delete mode, catalog index %s : SKIPPED", img.Image)
-			} else {
-				if _, found := alreadyIncluded[img.Image]; !found {
-					result = append(result, v2alpha1.CopyImageSchema{Origin: imgSpec.ReferenceWithTransport, Source: src, Destination: dest, Type: img.Type, RebuiltTag: img.RebuiltTag})
-					alreadyIncluded[img.Image] = struct{}{}
-				}
+			// in delete mode, the catalogs are not deleted as other images: the deleted operators are removed from them
+			if _, found := alreadyIncluded[img.Image]; !found {
+				result = append(result, v2alpha1.CopyImageSchema{Origin: imgSpec.ReferenceWithTransport, Source: src, Destination: dest, Type: img.Type, RebuiltTag: img.RebuiltTag})
+				alreadyIncluded[img.Image] = struct{}{}
 			}
 		}
 	}
@@ -358,39 +355,11 @@ func (o OperatorCollector) extractOCIConfigLayers(catalog string, imgSpec image.
 		}
 	}
 
-	if len(oci.Manifests) == 0 {
-		return "", fmt.Errorf("no manifests found for %s", catalog)
-	}
-
-	validDigest, err := digest.Parse(oci.Manifests[0].Digest)
-	if err != nil {
-		return "", fmt.Errorf("the digests seem to be incorrect for %s: %w", catalog, err)
-	}
-
-	manifest := validDigest.Encoded()
-	o.Log.Debug(collectorPrefix+"manifest %s", manifest)
-	manifestDir := filepath.Join(catalogImageDir, blobsDir, manifest)
-	oci, err = o.Manifest.GetImageManifest(manifestDir)
+	oci, err = o.firstImageManifest(catalog, catalogImageDir, oci)
 	if err != nil {
 		return "", err
 	}
 
-	// we need to check if oci returns multi manifests (from manifest list)
-	// also oci.Config will be nil
-	// we are only interested in the first manifest as all architectures
-	// "configs" will be exactly the same
-	if len(oci.Manifests) > 1 && oci.Config.Size == 0 {
-		subDigest, err := digest.Parse(oci.Manifests[0].Digest)
-		if err != nil {
-			return "", fmt.Errorf("the digests seem to be incorrect for %s: %w", catalog, err)
-		}
-		manifestDir := filepath.Join(catalogImageDir, blobsDir, subDigest.Encoded())
-		oci, err = o.Manifest.GetImageManifest(manifestDir)
-		if err != nil {
-			return "", fmt.Errorf("manifest %s: %w", catalog, err)
-		}
-	}
-
 	// read the config digest to get the detailed manifest
 	// looking for the label to search for a specific folder
 	configDigest, err := digest.Parse(oci.Config.Digest)
@@ -416,6 +385,44 @@ func (o OperatorCollector) extractOCIConfigLayers(catalog string, imgSpec image.
 	return filepath.Join(configsDir, label), nil
 }
 
+// firstImageManifest returns the manifest of the first image of the index of the OCI layout in imageDir.
+// For a manifest list, the manifest of its first image is returned: the configs of all architectures are the same.
+func (o OperatorCollector) firstImageManifest(ref, imageDir string, index *v2alpha1.OCISchema) (*v2alpha1.OCISchema, error) {
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("no manifests found for %s", ref)
+	}
+
+	validDigest, err := digest.Parse(index.Manifests[0].Digest)
+	if err != nil {
+		return nil, fmt.Errorf("the digests seem to be incorrect for %s: %w", ref, err)
+	}
+
+	manifest := validDigest.Encoded()
+	o.Log.Debug(collectorPrefix+"manifest %s", manifest)
+	manifestDir := filepath.Join(imageDir, blobsDir, manifest)
+	oci, err := o.Manifest.GetImageManifest(manifestDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to check if oci returns multi manifests (from manifest list)
+	// also oci.Config will be nil
+	// we are only interested in the first manifest as all architectures
+	// "configs" will be exactly the same
+	if len(oci.Manifests) > 1 && oci.Config.Size == 0 {
+		subDigest, err := digest.Parse(oci.Manifests[0].Digest)
+		if err != nil {
+			return nil, fmt.Errorf("the digests seem to be incorrect for %s: %w", ref, err)
+		}
+		manifestDir := filepath.Join(imageDir, blobsDir, subDigest.Encoded())
+		oci, err = o.Manifest.GetImageManifest(manifestDir)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", ref, err)
+		}
+	}
+	return oci, nil
+}
+
 type OtherImageDispatcher struct {
 	imageDispatcher
 	log                 clog.PluggableLoggerInterface
@@ -0,0 +1,145 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+// OpenShift 4.y ships Kubernetes 1.(y+13)
+const openShiftToKubeMinorOffset = 13
+
+// channelVersionRegex extracts the OpenShift version from a release channel name, such as stable-4.16
+var channelVersionRegex = regexp.MustCompile(`-(\d+\.\d+)$`)
+
+// resolvePlatformCompatibility returns op with the OpenShift version range of its PlatformCompatibility
+// set from the platform channels of cfg, when neither version is set explicitly.
+func resolvePlatformCompatibility(op v2alpha1.Operator, cfg v2alpha1.ImageSetConfiguration) (v2alpha1.Operator, error) {
+	compatibility := op.PlatformCompatibility
+	if compatibility == nil || compatibility.MinOpenShiftVersion != "" || compatibility.MaxOpenShiftVersion != "" {
+		return op, nil
+	}
+	minVersion, maxVersion, err := platformVersionRange(cfg.Mirror.Platform.Channels)
+	if err != nil {
+		return op, fmt.Errorf("catalog %s: platformCompatibility: %w", op.Catalog, err)
+	}
+	op.PlatformCompatibility = &v2alpha1.PlatformCompatibility{
+		MinOpenShiftVersion: minVersion,
+		MaxOpenShiftVersion: maxVersion,
+	}
+	return op, nil
+}
+
+// platformVersionRange returns the range of OpenShift versions covered by channels.
+// Each channel covers its minVersion to its maxVersion, the version in its name being used for the unset ones.
+func platformVersionRange(channels []v2alpha1.ReleaseChannel) (string, string, error) {
+	var minVersion, maxVersion *semver.Version
+	for _, ch := range channels {
+		nameVersion := ""
+		if matches := channelVersionRegex.FindStringSubmatch(ch.Name); matches != nil {
+			nameVersion = matches[1]
+		}
+		chMin, chMax := ch.MinVersion, ch.MaxVersion
+		if chMin == "" {
+			chMin = nameVersion
+		}
+		if chMax == "" {
+			chMax = nameVersion
+		}
+		if chMin != "" {
+			version, err := semver.ParseTolerant(chMin)
+			if err != nil {
+				return "", "", fmt.Errorf("channel %s: %w", ch.Name, err)
+			}
+			if minVersion == nil || version.LT(*minVersion) {
+				minVersion = &version
+			}
+		}
+		if chMax != "" {
+			version, err := semver.ParseTolerant(chMax)
+			if err != nil {
+				return "", "", fmt.Errorf("channel %s: %w", ch.Name, err)
+			}
+			if maxVersion == nil || version.GT(*maxVersion) {
+				maxVersion = &version
+			}
+		}
+	}
+	if minVersion == nil && maxVersion == nil {
+		return "", "", errors.New("no OpenShift version found in the platform channels: set minOpenShiftVersion and/or maxOpenShiftVersion")
+	}
+	versionString := func(v *semver.Version) string {
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	}
+	return versionString(minVersion), versionString(maxVersion), nil
+}
+
+// excludeIncompatibleBundles removes from dc the bundles that cannot be installed on any
+// OpenShift version of the PlatformCompatibility range, before the catalog is filtered:
+//   - bundles with an olm.maxOpenShiftVersion lower than the minimum version
+//   - bundles with a minKubeVersion higher than the Kubernetes version of the maximum version
+func excludeIncompatibleBundles(dc declcfg.DeclarativeConfig, op v2alpha1.Operator) (declcfg.DeclarativeConfig, error) {
+	compatibility := op.PlatformCompatibility
+	if compatibility == nil {
+		return dc, nil
+	}
+	var maxKubeVersion *semver.Version
+	if compatibility.MaxOpenShiftVersion != "" {
+		maxOpenShiftVersion, err := semver.ParseTolerant(compatibility.MaxOpenShiftVersion)
+		if err != nil {
+			return dc, fmt.Errorf("platformCompatibility: %w", err)
+		}
+		maxKubeVersion = &semver.Version{Major: 1, Minor: maxOpenShiftVersion.Minor + openShiftToKubeMinorOffset}
+	}
+
+	removed := map[string]map[string]bool{}
+	for _, b := range dc.Bundles {
+		compatible, err := isBundleCompatible(b, compatibility.MinOpenShiftVersion, maxKubeVersion)
+		if err != nil {
+			return dc, fmt.Errorf("bundle %s: %w", b.Name, err)
+		}
+		if compatible {
+			continue
+		}
+		if internalLog != nil {
+			internalLog.Debug("bundle %s of package %s cannot be installed on OpenShift %s-%s: SKIPPING", b.Name, b.Package, compatibility.MinOpenShiftVersion, compatibility.MaxOpenShiftVersion)
+		}
+		if removed[b.Package] == nil {
+			removed[b.Package] = map[string]bool{}
+		}
+		removed[b.Package][b.Name] = true
+	}
+	return removeBundles(dc, removed, "the platformCompatibility"), nil
+}
+
+func isBundleCompatible(b declcfg.Bundle, minOpenShiftVersion string, maxKubeVersion *semver.Version) (bool, error) {
+	metadata, err := getBundleMetadata(b)
+	if err != nil {
+		return false, err
+	}
+	if minOpenShiftVersion != "" && metadata.maxOpenShiftVersion != "" {
+		compatible, err := isOpenShiftVersionCompatible(minOpenShiftVersion, metadata.maxOpenShiftVersion)
+		if err != nil || !compatible {
+			return false, err
+		}
+	}
+	if maxKubeVersion != nil && metadata.minKubeVersion != "" {
+		minKubeVersion, err := semver.ParseTolerant(metadata.minKubeVersion)
+		if err != nil {
+			return false, fmt.Errorf("invalid minKubeVersion %q: %w", metadata.minKubeVersion, err)
+		}
+		minKubeVersion.Patch, minKubeVersion.Pre, minKubeVersion.Build = 0, nil, nil
+		if minKubeVersion.GT(*maxKubeVersion) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
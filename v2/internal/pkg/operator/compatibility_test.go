@@ -0,0 +1,79 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+func TestExcludeIncompatibleBundles(t *testing.T) {
+	dc := testSelectorCatalog()
+	for i := range dc.Bundles {
+		if dc.Bundles[i].Name == "foo.v2.0.0" {
+			dc.Bundles[i].Properties[1] = property.MustBuild(&property.CSVMetadata{MinKubeVersion: "1.30.0"})
+		}
+	}
+
+	t.Run("Testing excludeIncompatibleBundles - range 4.15 to 4.16 : should remove the bundles that cannot be installed", func(t *testing.T) {
+		compatible, err := excludeIncompatibleBundles(dc, v2alpha1.Operator{
+			PlatformCompatibility: &v2alpha1.PlatformCompatibility{MinOpenShiftVersion: "4.15", MaxOpenShiftVersion: "4.16"},
+		})
+		assert.NoError(t, err)
+		bundles := []string{}
+		for _, b := range compatible.Bundles {
+			bundles = append(bundles, b.Name)
+		}
+		// foo.v1.0.0 has olm.maxOpenShiftVersion 4.14, foo.v2.0.0 requires Kubernetes 1.30 (OpenShift 4.17)
+		assert.Equal(t, []string{"foo.v1.1.0", "foo.v1.2.0", "foo.v1.3.0"}, bundles)
+		assert.Len(t, compatible.Channels, 1)
+		assert.Equal(t, []declcfg.ChannelEntry{
+			{Name: "foo.v1.1.0", Skips: []string{"foo.v1.0.0"}},
+			{Name: "foo.v1.2.0", Replaces: "foo.v1.1.0"},
+			{Name: "foo.v1.3.0", Replaces: "foo.v1.2.0"},
+		}, compatible.Channels[0].Entries)
+	})
+
+	t.Run("Testing excludeIncompatibleBundles - no platformCompatibility : should keep all bundles", func(t *testing.T) {
+		compatible, err := excludeIncompatibleBundles(dc, v2alpha1.Operator{})
+		assert.NoError(t, err)
+		assert.Len(t, compatible.Bundles, 5)
+	})
+}
+
+func TestResolvePlatformCompatibility(t *testing.T) {
+	cfg := v2alpha1.ImageSetConfiguration{
+		ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{
+			Mirror: v2alpha1.Mirror{
+				Platform: v2alpha1.Platform{
+					Channels: []v2alpha1.ReleaseChannel{
+						{Name: "stable-4.15", MinVersion: "4.15.3"},
+						{Name: "stable-4.16"},
+						{Name: "okd", Type: v2alpha1.TypeOKD},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("Testing resolvePlatformCompatibility - no version set : should use the platform channels", func(t *testing.T) {
+		op, err := resolvePlatformCompatibility(v2alpha1.Operator{PlatformCompatibility: &v2alpha1.PlatformCompatibility{}}, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, &v2alpha1.PlatformCompatibility{MinOpenShiftVersion: "4.15.3", MaxOpenShiftVersion: "4.16.0"}, op.PlatformCompatibility)
+	})
+
+	t.Run("Testing resolvePlatformCompatibility - version set : should keep the explicit range", func(t *testing.T) {
+		explicit := &v2alpha1.PlatformCompatibility{MaxOpenShiftVersion: "4.18"}
+		op, err := resolvePlatformCompatibility(v2alpha1.Operator{PlatformCompatibility: explicit}, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, explicit, op.PlatformCompatibility)
+	})
+
+	t.Run("Testing resolvePlatformCompatibility - no platform channel : should fail", func(t *testing.T) {
+		_, err := resolvePlatformCompatibility(v2alpha1.Operator{Catalog: "redhat-operator-index:v4.16", PlatformCompatibility: &v2alpha1.PlatformCompatibility{}}, v2alpha1.ImageSetConfiguration{})
+		assert.ErrorContains(t, err, "catalog redhat-operator-index:v4.16: platformCompatibility: no OpenShift version found in the platform channels")
+	})
+}
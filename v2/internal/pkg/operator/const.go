@@ -10,6 +10,9 @@ const (
 	operatorCatalogConfigDir   string = "catalog-config"
 	operatorCatalogImageDir    string = "catalog-image"
 	operatorCatalogFilteredDir string = "filtered-catalogs"
+	operatorBundlesDir         string = "operator-bundles"
+	operatorBundleImageDir     string = "bundle-image"
+	operatorBundleContentDir   string = "bundle"
 	blobsDir                          = "blobs/sha256"
 	collectorPrefix                   = "[OperatorImageCollector] "
 	errMsg                            = collectorPrefix + "%s"
@@ -0,0 +1,278 @@
+package operator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// dependency is a requirement of a bundle: either a package in a version range
+// (olm.package.required) or a provided API (olm.gvk.required)
+type dependency struct {
+	requiredBy string
+	pkg        *property.PackageRequired
+	gvk        *property.GVKRequired
+}
+
+func (d dependency) String() string {
+	if d.pkg != nil {
+		return fmt.Sprintf("%s %s %s", property.TypePackageRequired, d.pkg.PackageName, d.pkg.VersionRange)
+	}
+	return fmt.Sprintf("%s %s/%s/%s", property.TypeGVKRequired, d.gvk.Group, d.gvk.Version, d.gvk.Kind)
+}
+
+// bundleDependencies returns the olm.package.required and olm.gvk.required dependencies of b
+func bundleDependencies(b declcfg.Bundle) ([]dependency, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", b.Name, err)
+	}
+	deps := []dependency{}
+	for i := range props.PackagesRequired {
+		deps = append(deps, dependency{requiredBy: b.Name, pkg: &props.PackagesRequired[i]})
+	}
+	for i := range props.GVKsRequired {
+		deps = append(deps, dependency{requiredBy: b.Name, gvk: &props.GVKsRequired[i]})
+	}
+	return deps, nil
+}
+
+// bundleVersion returns the version of b from its olm.package property
+func bundleVersion(b declcfg.Bundle) (semver.Version, error) {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	if len(props.Packages) == 0 {
+		return semver.Version{}, fmt.Errorf("bundle %s: no %s property", b.Name, property.TypePackage)
+	}
+	return semver.Parse(props.Packages[0].Version)
+}
+
+// satisfies returns true when b provides the package version or the API required by dep
+func satisfies(b declcfg.Bundle, dep dependency) bool {
+	props, err := property.Parse(b.Properties)
+	if err != nil {
+		return false
+	}
+	if dep.pkg != nil {
+		if b.Package != dep.pkg.PackageName || len(props.Packages) == 0 {
+			return false
+		}
+		versionRange, err := semver.ParseRange(dep.pkg.VersionRange)
+		if err != nil {
+			return false
+		}
+		version, err := semver.Parse(props.Packages[0].Version)
+		return err == nil && versionRange(version)
+	}
+	return slices.ContainsFunc(props.GVKs, func(gvk property.GVK) bool {
+		return gvk.Group == dep.gvk.Group && gvk.Version == dep.gvk.Version && gvk.Kind == dep.gvk.Kind
+	})
+}
+
+// findDependency returns the bundle of dc to add to satisfy dep, or nil if there is none.
+// The bundles of the default channels are preferred, then the highest versions.
+func findDependency(dc declcfg.DeclarativeConfig, dep dependency) *declcfg.Bundle {
+	defaultChannels := map[string]string{}
+	for _, pkg := range dc.Packages {
+		defaultChannels[pkg.Name] = pkg.DefaultChannel
+	}
+	inDefaultChannel := map[string]bool{}
+	for _, ch := range dc.Channels {
+		if defaultChannels[ch.Package] != ch.Name {
+			continue
+		}
+		for _, e := range ch.Entries {
+			inDefaultChannel[ch.Package+"/"+e.Name] = true
+		}
+	}
+
+	candidates := []declcfg.Bundle{}
+	for _, b := range dc.Bundles {
+		if satisfies(b, dep) {
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iDefault, jDefault := inDefaultChannel[candidates[i].Package+"/"+candidates[i].Name], inDefaultChannel[candidates[j].Package+"/"+candidates[j].Name]
+		if iDefault != jDefault {
+			return iDefault
+		}
+		iVersion, iErr := bundleVersion(candidates[i])
+		jVersion, jErr := bundleVersion(candidates[j])
+		if iErr == nil && jErr == nil && !iVersion.EQ(jVersion) {
+			return iVersion.GT(jVersion)
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return &candidates[0]
+}
+
+// unresolvedDependencies returns the dependencies of the bundles of dc that no bundle of dc satisfies
+func unresolvedDependencies(dc declcfg.DeclarativeConfig) ([]dependency, error) {
+	unresolved := []dependency{}
+	for _, b := range dc.Bundles {
+		deps, err := bundleDependencies(b)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			if !slices.ContainsFunc(dc.Bundles, func(candidate declcfg.Bundle) bool { return satisfies(candidate, dep) }) {
+				unresolved = append(unresolved, dep)
+			}
+		}
+	}
+	return unresolved, nil
+}
+
+// resolveDependencies adds to filtered the bundles of source needed to satisfy the dependencies of
+// its bundles, including the dependencies of the added bundles.
+// Each added bundle is logged with the dependency it satisfies.
+// The dependencies that source cannot satisfy are returned.
+func resolveDependencies(source, filtered declcfg.DeclarativeConfig) (declcfg.DeclarativeConfig, []dependency, error) {
+	resolved := filtered
+	resolved.Packages = slices.Clone(filtered.Packages)
+	resolved.Channels = slices.Clone(filtered.Channels)
+	resolved.Bundles = slices.Clone(filtered.Bundles)
+
+	missing := []dependency{}
+	for {
+		unresolved, err := unresolvedDependencies(resolved)
+		if err != nil {
+			return filtered, nil, err
+		}
+		added := false
+		missing = missing[:0]
+		for _, dep := range unresolved {
+			// a previous addition may already satisfy this dependency
+			if slices.ContainsFunc(resolved.Bundles, func(candidate declcfg.Bundle) bool { return satisfies(candidate, dep) }) {
+				continue
+			}
+			b := findDependency(source, dep)
+			if b == nil {
+				missing = append(missing, dep)
+				continue
+			}
+			if internalLog != nil {
+				internalLog.Info("adding bundle %s of package %s: required by %s (%s)", b.Name, b.Package, dep.requiredBy, dep)
+			}
+			addBundle(&resolved, source, *b)
+			added = true
+		}
+		if !added {
+			return resolved, missing, nil
+		}
+	}
+}
+
+// addBundle adds b to dst, with its package, its deprecations and its channels from src when missing.
+// The entries of b get their replaces edge only when the replaced bundle is in dst,
+// and channels keep a single head: the newest head skips the other ones.
+func addBundle(dst *declcfg.DeclarativeConfig, src declcfg.DeclarativeConfig, b declcfg.Bundle) {
+	if !slices.ContainsFunc(dst.Packages, func(pkg declcfg.Package) bool { return pkg.Name == b.Package }) {
+		for _, pkg := range src.Packages {
+			if pkg.Name == b.Package {
+				dst.Packages = append(dst.Packages, pkg)
+			}
+		}
+		// the deprecations of the package are pruned once all the bundles are added
+		for _, d := range src.Deprecations {
+			if d.Package == b.Package {
+				dst.Deprecations = append(slices.Clone(dst.Deprecations), d)
+			}
+		}
+	}
+
+	for _, srcCh := range src.Channels {
+		if srcCh.Package != b.Package {
+			continue
+		}
+		entryIdx := slices.IndexFunc(srcCh.Entries, func(e declcfg.ChannelEntry) bool { return e.Name == b.Name })
+		if entryIdx < 0 {
+			continue
+		}
+		chIdx := slices.IndexFunc(dst.Channels, func(ch declcfg.Channel) bool {
+			return ch.Package == srcCh.Package && ch.Name == srcCh.Name
+		})
+		if chIdx < 0 {
+			ch := srcCh
+			ch.Entries = []declcfg.ChannelEntry{}
+			dst.Channels = append(dst.Channels, ch)
+			chIdx = len(dst.Channels) - 1
+		}
+		ch := &dst.Channels[chIdx]
+		ch.Entries = slices.Clone(ch.Entries)
+		entry := srcCh.Entries[entryIdx]
+		entry.Skips = slices.Clone(entry.Skips)
+		if !slices.ContainsFunc(ch.Entries, func(e declcfg.ChannelEntry) bool { return e.Name == entry.Replaces }) {
+			entry.Replaces = ""
+		}
+		ch.Entries = append(ch.Entries, entry)
+		keepSingleHead(ch, append(dst.Bundles, b))
+	}
+	dst.Bundles = append(dst.Bundles, b)
+
+	for i, pkg := range dst.Packages {
+		if pkg.Name != b.Package {
+			continue
+		}
+		channels := []string{}
+		for _, ch := range dst.Channels {
+			if ch.Package == pkg.Name {
+				channels = append(channels, ch.Name)
+			}
+		}
+		if !slices.Contains(channels, pkg.DefaultChannel) && len(channels) > 0 {
+			sort.Strings(channels)
+			dst.Packages[i].DefaultChannel = channels[0]
+		}
+	}
+}
+
+// keepSingleHead makes the newest head of ch skip the other heads
+func keepSingleHead(ch *declcfg.Channel, bundles []declcfg.Bundle) {
+	referenced := map[string]bool{}
+	for _, e := range ch.Entries {
+		referenced[e.Replaces] = true
+		for _, skip := range e.Skips {
+			referenced[skip] = true
+		}
+	}
+	heads := []int{}
+	for i, e := range ch.Entries {
+		if !referenced[e.Name] {
+			heads = append(heads, i)
+		}
+	}
+	if len(heads) < 2 {
+		return
+	}
+	versions := map[string]semver.Version{}
+	for _, b := range bundles {
+		if b.Package != ch.Package {
+			continue
+		}
+		if version, err := bundleVersion(b); err == nil {
+			versions[b.Name] = version
+		}
+	}
+	newest := heads[0]
+	for _, i := range heads[1:] {
+		if versions[ch.Entries[i].Name].GT(versions[ch.Entries[newest].Name]) {
+			newest = i
+		}
+	}
+	for _, i := range heads {
+		if i != newest {
+			ch.Entries[newest].Skips = append(slices.Clone(ch.Entries[newest].Skips), ch.Entries[i].Name)
+		}
+	}
+}
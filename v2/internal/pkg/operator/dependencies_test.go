@@ -0,0 +1,186 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"maps"
+	"os"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+func testDependencyBundle(pkg, version string, props ...property.Property) declcfg.Bundle {
+	return declcfg.Bundle{
+		Schema:     "olm.bundle",
+		Name:       pkg + ".v" + version,
+		Package:    pkg,
+		Properties: append([]property.Property{property.MustBuildPackage(pkg, version)}, props...),
+	}
+}
+
+// testDependencyCatalog returns a catalog where foo requires bar in [1.0.0, 2.0.0), the API
+// provided by baz, and a package qux that is not in the catalog
+func testDependencyCatalog() declcfg.DeclarativeConfig {
+	return declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{
+			{Schema: "olm.package", Name: "foo", DefaultChannel: "stable"},
+			{Schema: "olm.package", Name: "bar", DefaultChannel: "stable"},
+			{Schema: "olm.package", Name: "baz", DefaultChannel: "stable"},
+		},
+		Channels: []declcfg.Channel{
+			{Schema: "olm.channel", Name: "stable", Package: "foo", Entries: []declcfg.ChannelEntry{{Name: "foo.v1.0.0"}}},
+			{Schema: "olm.channel", Name: "stable", Package: "bar", Entries: []declcfg.ChannelEntry{
+				{Name: "bar.v1.0.0"},
+				{Name: "bar.v1.1.0", Replaces: "bar.v1.0.0"},
+				{Name: "bar.v2.0.0", Replaces: "bar.v1.1.0"},
+			}},
+			{Schema: "olm.channel", Name: "candidate", Package: "bar", Entries: []declcfg.ChannelEntry{
+				{Name: "bar.v1.2.0"},
+			}},
+			{Schema: "olm.channel", Name: "stable", Package: "baz", Entries: []declcfg.ChannelEntry{{Name: "baz.v0.1.0"}}},
+		},
+		Bundles: []declcfg.Bundle{
+			testDependencyBundle("foo", "1.0.0",
+				property.MustBuildPackageRequired("bar", ">=1.0.0 <2.0.0"),
+				property.MustBuildGVKRequired("baz.example.com", "v1", "Baz"),
+				property.MustBuildPackageRequired("qux", ">=0.0.1"),
+			),
+			testDependencyBundle("bar", "1.0.0"),
+			testDependencyBundle("bar", "1.1.0"),
+			testDependencyBundle("bar", "1.2.0"),
+			testDependencyBundle("bar", "2.0.0"),
+			testDependencyBundle("baz", "0.1.0", property.MustBuildGVK("baz.example.com", "v1", "Baz")),
+		},
+	}
+}
+
+func TestResolveDependencies(t *testing.T) {
+	source := testDependencyCatalog()
+
+	t.Run("Testing resolveDependencies - missing packages : should add the minimal satisfying bundles", func(t *testing.T) {
+		filtered := declcfg.DeclarativeConfig{
+			Packages: source.Packages[:1],
+			Channels: source.Channels[:1],
+			Bundles:  source.Bundles[:1],
+		}
+		resolved, missing, err := resolveDependencies(source, filtered)
+		assert.NoError(t, err)
+
+		bundles := []string{}
+		for _, b := range resolved.Bundles {
+			bundles = append(bundles, b.Name)
+		}
+		// bar.v1.2.0 is a higher version in range, but bar.v1.1.0 is in the default channel
+		assert.Equal(t, []string{"foo.v1.0.0", "bar.v1.1.0", "baz.v0.1.0"}, bundles)
+		assert.Len(t, resolved.Packages, 3)
+		assert.Contains(t, resolved.Channels, declcfg.Channel{Schema: "olm.channel", Name: "stable", Package: "bar", Entries: []declcfg.ChannelEntry{
+			{Name: "bar.v1.1.0"},
+		}})
+		assert.Len(t, missing, 1)
+		assert.Equal(t, "olm.package.required qux >=0.0.1", missing[0].String())
+		assert.Equal(t, "foo.v1.0.0", missing[0].requiredBy)
+
+		// the filtered catalog is left untouched
+		assert.Len(t, filtered.Bundles, 1)
+	})
+
+	t.Run("Testing resolveDependencies - package mirrored out of range : should keep a single channel head", func(t *testing.T) {
+		filtered := declcfg.DeclarativeConfig{
+			Packages: []declcfg.Package{source.Packages[0], source.Packages[1]},
+			Channels: []declcfg.Channel{
+				source.Channels[0],
+				{Schema: "olm.channel", Name: "stable", Package: "bar", Entries: []declcfg.ChannelEntry{{Name: "bar.v2.0.0"}}},
+			},
+			Bundles: []declcfg.Bundle{source.Bundles[0], source.Bundles[4]},
+		}
+		resolved, _, err := resolveDependencies(source, filtered)
+		assert.NoError(t, err)
+		assert.Equal(t, []declcfg.ChannelEntry{
+			{Name: "bar.v2.0.0", Skips: []string{"bar.v1.1.0"}},
+			{Name: "bar.v1.1.0"},
+		}, resolved.Channels[1].Entries)
+		assert.Equal(t, []declcfg.ChannelEntry{{Name: "bar.v2.0.0"}}, filtered.Channels[1].Entries)
+	})
+
+	t.Run("Testing resolveDependencies - dependencies satisfied : should not add any bundle", func(t *testing.T) {
+		resolved, missing, err := resolveDependencies(source, source)
+		assert.NoError(t, err)
+		assert.Equal(t, source.Bundles, resolved.Bundles)
+		assert.Len(t, missing, 1)
+	})
+}
+
+func TestIncludePackageOf(t *testing.T) {
+	source := testDependencyCatalog()
+
+	pkg, err := includePackageOf(source, source.Bundles[3])
+	assert.NoError(t, err)
+	assert.Equal(t, v2alpha1.IncludePackage{
+		Name: "bar",
+		Channels: []v2alpha1.IncludeChannel{{
+			Name:          "candidate",
+			IncludeBundle: v2alpha1.IncludeBundle{MinVersion: "1.2.0", MaxVersion: "1.2.0"},
+		}},
+	}, pkg)
+
+	pkg, err = includePackageOf(source, source.Bundles[2])
+	assert.NoError(t, err)
+	assert.Equal(t, "stable", pkg.Channels[0].Name)
+}
+
+func TestResolveDependenciesAcrossCatalogs(t *testing.T) {
+	requiring := v2alpha1.Operator{Catalog: "registry.example.com/community-index:v1", ResolveDependenciesAcrossCatalogs: true}
+	providing := v2alpha1.Operator{Catalog: "registry.example.com/redhat-index:v1"}
+	filtered := testDependencyCatalog()
+	filtered.Bundles = filtered.Bundles[:1]
+
+	t.Run("Testing resolveDependenciesAcrossCatalogs - other catalog not resolving across catalogs : should leave it untouched", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer func() {
+			log.SetOutput(os.Stderr)
+		}()
+		o := &FilterCollector{OperatorCollector{Log: clog.New("debug"), Opts: mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: t.TempDir()}}}}
+		results := map[string]v2alpha1.CatalogFilterResult{
+			"docker://" + requiring.Catalog: {OperatorFilter: requiring, DeclConfig: &filtered},
+			"docker://" + providing.Catalog: {OperatorFilter: providing, DeclConfig: &declcfg.DeclarativeConfig{}},
+		}
+		expected := maps.Clone(results)
+
+		assert.NoError(t, o.resolveDependenciesAcrossCatalogs(context.TODO(), results, map[string][]v2alpha1.RelatedImage{}, &v2alpha1.CopyImageSchemaMap{}))
+		assert.Equal(t, expected, results)
+		assert.Contains(t, buf.String(), "dependency olm.package.required bar >=1.0.0 <2.0.0 of bundle foo.v1.0.0 not found in the catalogs resolving dependencies across catalogs")
+	})
+}
+
+func TestPackagesWithDependencies(t *testing.T) {
+	o := &FilterCollector{OperatorCollector{Log: clog.New("debug")}}
+	bar := v2alpha1.IncludePackage{Name: "bar", Channels: []v2alpha1.IncludeChannel{{Name: "stable", IncludeBundle: v2alpha1.IncludeBundle{MinVersion: "1.1.0", MaxVersion: "1.1.0"}}}}
+
+	t.Run("Testing packagesWithDependencies - package filter : should add the dependencies", func(t *testing.T) {
+		op := v2alpha1.Operator{Catalog: "registry.example.com/redhat-index:v1", IncludeConfig: v2alpha1.IncludeConfig{Packages: []v2alpha1.IncludePackage{{Name: "baz"}}}}
+		packages, err := o.packagesWithDependencies(op, []v2alpha1.IncludePackage{bar})
+		assert.NoError(t, err)
+		assert.Equal(t, []v2alpha1.IncludePackage{{Name: "baz"}, bar}, packages)
+		assert.Len(t, op.Packages, 1)
+	})
+	t.Run("Testing packagesWithDependencies - package already filtered : should leave it as is", func(t *testing.T) {
+		op := v2alpha1.Operator{Catalog: "registry.example.com/redhat-index:v1", IncludeConfig: v2alpha1.IncludeConfig{Packages: []v2alpha1.IncludePackage{{Name: "bar"}}}}
+		packages, err := o.packagesWithDependencies(op, []v2alpha1.IncludePackage{bar})
+		assert.NoError(t, err)
+		assert.Equal(t, op.Packages, packages)
+	})
+	t.Run("Testing packagesWithDependencies - no package filter : should fail", func(t *testing.T) {
+		op := v2alpha1.Operator{Catalog: "registry.example.com/redhat-index:v1"}
+		_, err := o.packagesWithDependencies(op, []v2alpha1.IncludePackage{bar})
+		assert.EqualError(t, err, "catalog \"registry.example.com/redhat-index:v1\": no package filter to add the dependencies [bar] to: add them, and the other packages to mirror, to its packages")
+	})
+}
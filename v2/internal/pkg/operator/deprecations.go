@@ -0,0 +1,147 @@
+package operator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+// schemas referenced by the entries of olm.deprecations
+const (
+	deprecationSchemaBundle  = "olm.bundle"
+	deprecationSchemaChannel = "olm.channel"
+	deprecationSchemaPackage = "olm.package"
+)
+
+const deprecatedContentReportFilename = "deprecated-operators.txt"
+
+// deprecatedItems holds the packages, the channels and the bundles (per package) marked as deprecated in olm.deprecations
+type deprecatedItems struct {
+	packages map[string]bool
+	channels map[string][]string
+	bundles  map[string][]string
+}
+
+// deprecatedContent returns the content of dc marked as deprecated in olm.deprecations
+func deprecatedContent(dc declcfg.DeclarativeConfig) deprecatedItems {
+	deprecated := deprecatedItems{
+		packages: map[string]bool{},
+		channels: map[string][]string{},
+		bundles:  map[string][]string{},
+	}
+	for _, d := range dc.Deprecations {
+		for _, entry := range d.Entries {
+			switch entry.Reference.Schema {
+			case deprecationSchemaPackage:
+				deprecated.packages[d.Package] = true
+			case deprecationSchemaChannel:
+				deprecated.channels[d.Package] = append(deprecated.channels[d.Package], entry.Reference.Name)
+			case deprecationSchemaBundle:
+				deprecated.bundles[d.Package] = append(deprecated.bundles[d.Package], entry.Reference.Name)
+			}
+		}
+	}
+	return deprecated
+}
+
+// skipDeprecatedContent removes from dc the deprecated packages, channels and bundles
+// when the catalog skips deprecated content, or from the packages whose bundleSelector
+// excludes deprecated content, before the catalog is filtered.
+func skipDeprecatedContent(dc declcfg.DeclarativeConfig, op v2alpha1.Operator) declcfg.DeclarativeConfig {
+	if op.DeprecatedContent != v2alpha1.DeprecatedContentSkip && !hasBundleSelector(op) {
+		return dc
+	}
+	skipped := func(pkg string) bool {
+		return op.DeprecatedContent == v2alpha1.DeprecatedContentSkip || excludesDeprecated(op, pkg)
+	}
+	deprecated := deprecatedContent(dc)
+	packages := []string{}
+	for pkg := range deprecated.packages {
+		if skipped(pkg) {
+			packages = append(packages, pkg)
+		}
+	}
+	channels := map[string][]string{}
+	for pkg, names := range deprecated.channels {
+		if skipped(pkg) {
+			channels[pkg] = names
+		}
+	}
+	bundles := map[string][]string{}
+	for pkg, names := range deprecated.bundles {
+		if skipped(pkg) {
+			bundles[pkg] = names
+		}
+	}
+	return removeContent(dc, packages, channels, bundles, "the deprecated content skipping")
+}
+
+// pruneDeprecations keeps the olm.deprecations entries of dc referencing content still in dc,
+// so that the rebuilt catalog only carries the deprecations of the mirrored content.
+func pruneDeprecations(dc declcfg.DeclarativeConfig) declcfg.DeclarativeConfig {
+	packages := map[string]bool{}
+	for _, pkg := range dc.Packages {
+		packages[pkg.Name] = true
+	}
+	channels := map[string]map[string]bool{}
+	for _, ch := range dc.Channels {
+		if channels[ch.Package] == nil {
+			channels[ch.Package] = map[string]bool{}
+		}
+		channels[ch.Package][ch.Name] = true
+	}
+	bundles := map[string]map[string]bool{}
+	for _, b := range dc.Bundles {
+		if bundles[b.Package] == nil {
+			bundles[b.Package] = map[string]bool{}
+		}
+		bundles[b.Package][b.Name] = true
+	}
+
+	pruned := dc
+	pruned.Deprecations = []declcfg.Deprecation{}
+	for _, d := range dc.Deprecations {
+		if !packages[d.Package] {
+			continue
+		}
+		d.Entries = slices.DeleteFunc(slices.Clone(d.Entries), func(entry declcfg.DeprecationEntry) bool {
+			switch entry.Reference.Schema {
+			case deprecationSchemaChannel:
+				return !channels[d.Package][entry.Reference.Name]
+			case deprecationSchemaBundle:
+				return !bundles[d.Package][entry.Reference.Name]
+			}
+			return false
+		})
+		if len(d.Entries) > 0 {
+			pruned.Deprecations = append(pruned.Deprecations, d)
+		}
+	}
+	return pruned
+}
+
+// deprecationReport returns a line for each deprecated package, channel and bundle of the catalog
+func deprecationReport(catalog string, dc declcfg.DeclarativeConfig) []string {
+	report := []string{}
+	for _, d := range dc.Deprecations {
+		for _, entry := range d.Entries {
+			var item string
+			switch entry.Reference.Schema {
+			case deprecationSchemaPackage:
+				item = "package " + d.Package
+			case deprecationSchemaChannel:
+				item = fmt.Sprintf("channel %s of package %s", entry.Reference.Name, d.Package)
+			case deprecationSchemaBundle:
+				item = fmt.Sprintf("bundle %s of package %s", entry.Reference.Name, d.Package)
+			default:
+				continue
+			}
+			report = append(report, fmt.Sprintf("%s: %s is deprecated: %s", catalog, item, strings.Join(strings.Fields(entry.Message), " ")))
+		}
+	}
+	return report
+}
@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+// testDeprecationCatalog returns the selector catalog with the fast channel and foo.v1.3.0 deprecated
+func testDeprecationCatalog() declcfg.DeclarativeConfig {
+	dc := testSelectorCatalog()
+	dc.Deprecations = []declcfg.Deprecation{
+		{Schema: "olm.deprecations", Package: "foo", Entries: []declcfg.DeprecationEntry{
+			{Reference: declcfg.PackageScopedReference{Schema: deprecationSchemaChannel, Name: "fast"}, Message: "use stable"},
+			{Reference: declcfg.PackageScopedReference{Schema: deprecationSchemaBundle, Name: "foo.v1.3.0"}, Message: "security issue,\nupgrade"},
+		}},
+	}
+	return dc
+}
+
+func TestSkipDeprecatedContent(t *testing.T) {
+	dc := testDeprecationCatalog()
+
+	t.Run("Testing skipDeprecatedContent - skip : should remove the deprecated channels and bundles", func(t *testing.T) {
+		skipped := skipDeprecatedContent(dc, v2alpha1.Operator{DeprecatedContent: v2alpha1.DeprecatedContentSkip})
+		bundles := []string{}
+		for _, b := range skipped.Bundles {
+			bundles = append(bundles, b.Name)
+		}
+		assert.Equal(t, []string{"foo.v1.0.0", "foo.v1.1.0", "foo.v1.2.0"}, bundles)
+		assert.Len(t, skipped.Channels, 1)
+		assert.Equal(t, "stable", skipped.Channels[0].Name)
+		assert.Len(t, dc.Bundles, 5)
+	})
+
+	t.Run("Testing skipDeprecatedContent - deprecated package : should remove the package", func(t *testing.T) {
+		deprecatedPackage := testDeprecationCatalog()
+		deprecatedPackage.Deprecations[0].Entries = []declcfg.DeprecationEntry{
+			{Reference: declcfg.PackageScopedReference{Schema: deprecationSchemaPackage}, Message: "end of life"},
+		}
+		skipped := skipDeprecatedContent(deprecatedPackage, v2alpha1.Operator{DeprecatedContent: v2alpha1.DeprecatedContentSkip})
+		assert.Empty(t, skipped.Packages)
+		assert.Empty(t, skipped.Bundles)
+	})
+
+	t.Run("Testing skipDeprecatedContent - bundleSelector excluding deprecated content : should remove the same content as skip", func(t *testing.T) {
+		skipped := skipDeprecatedContent(dc, v2alpha1.Operator{DeprecatedContent: v2alpha1.DeprecatedContentSkip})
+		excluded := skipDeprecatedContent(dc, v2alpha1.Operator{BundleSelector: &v2alpha1.BundleSelector{ExcludeDeprecated: true}})
+		assert.Equal(t, skipped, excluded)
+
+		overridden := skipDeprecatedContent(dc, v2alpha1.Operator{
+			BundleSelector: &v2alpha1.BundleSelector{ExcludeDeprecated: true},
+			IncludeConfig: v2alpha1.IncludeConfig{
+				Packages: []v2alpha1.IncludePackage{{Name: "foo", BundleSelector: &v2alpha1.BundleSelector{}}},
+			},
+		})
+		assert.Equal(t, dc, overridden)
+	})
+
+	t.Run("Testing skipDeprecatedContent - warn : should keep all content", func(t *testing.T) {
+		kept := skipDeprecatedContent(dc, v2alpha1.Operator{DeprecatedContent: v2alpha1.DeprecatedContentWarn})
+		assert.Equal(t, dc, kept)
+	})
+}
+
+func TestPruneDeprecations(t *testing.T) {
+	dc := testDeprecationCatalog()
+	dc.Channels = dc.Channels[:1]
+	dc.Bundles = dc.Bundles[:4]
+
+	pruned := pruneDeprecations(dc)
+	assert.Equal(t, []declcfg.Deprecation{
+		{Schema: "olm.deprecations", Package: "foo", Entries: []declcfg.DeprecationEntry{
+			{Reference: declcfg.PackageScopedReference{Schema: deprecationSchemaBundle, Name: "foo.v1.3.0"}, Message: "security issue,\nupgrade"},
+		}},
+	}, pruned.Deprecations)
+	assert.Len(t, dc.Deprecations[0].Entries, 2)
+
+	dc.Packages = nil
+	assert.Empty(t, pruneDeprecations(dc).Deprecations)
+}
+
+func TestDeprecationReport(t *testing.T) {
+	assert.Equal(t, []string{
+		"oci:///catalog: channel fast of package foo is deprecated: use stable",
+		"oci:///catalog: bundle foo.v1.3.0 of package foo is deprecated: security issue, upgrade",
+	}, deprecationReport("oci:///catalog", testDeprecationCatalog()))
+}
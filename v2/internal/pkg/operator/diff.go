@@ -0,0 +1,188 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+// CatalogDiff is the content added to, and removed from, a catalog between two versions.
+// Channels are listed as package/channel, bundles as package/bundle, and the upgrade edges
+// as package/channel: bundle replaces|skips bundle.
+type CatalogDiff struct {
+	AddedPackages        []string `json:"addedPackages,omitempty"`
+	RemovedPackages      []string `json:"removedPackages,omitempty"`
+	AddedChannels        []string `json:"addedChannels,omitempty"`
+	RemovedChannels      []string `json:"removedChannels,omitempty"`
+	AddedBundles         []string `json:"addedBundles,omitempty"`
+	RemovedBundles       []string `json:"removedBundles,omitempty"`
+	AddedEdges           []string `json:"addedEdges,omitempty"`
+	RemovedEdges         []string `json:"removedEdges,omitempty"`
+	AddedRelatedImages   []string `json:"addedRelatedImages,omitempty"`
+	RemovedRelatedImages []string `json:"removedRelatedImages,omitempty"`
+}
+
+// IsEmpty returns true when both versions of the catalog have the same content
+func (d CatalogDiff) IsEmpty() bool {
+	return len(d.AddedPackages) == 0 && len(d.RemovedPackages) == 0 &&
+		len(d.AddedChannels) == 0 && len(d.RemovedChannels) == 0 &&
+		len(d.AddedBundles) == 0 && len(d.RemovedBundles) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 &&
+		len(d.AddedRelatedImages) == 0 && len(d.RemovedRelatedImages) == 0
+}
+
+// DiffCatalogs loads two versions of a catalog and returns the content added and removed between them,
+// restricted to packages when set. A version already in the working directory is not pulled again.
+func DiffCatalogs(ctx context.Context,
+	log clog.PluggableLoggerInterface,
+	opts mirror.CopyOptions,
+	mirror mirror.MirrorInterface,
+	manifest manifest.ManifestInterface,
+	oldCatalog, newCatalog string,
+	packages []string,
+) (CatalogDiff, error) {
+	o := FilterCollector{OperatorCollector{Log: log, Opts: opts, Mirror: mirror, Manifest: manifest, LocalStorageFQDN: opts.LocalStorageFQDN, ctlgHandler: catalogHandler{Log: log}}}
+	oldDC, err := o.catalogVersionDeclConfig(ctx, oldCatalog)
+	if err != nil {
+		return CatalogDiff{}, fmt.Errorf("catalog %s: %w", oldCatalog, err)
+	}
+	newDC, err := o.catalogVersionDeclConfig(ctx, newCatalog)
+	if err != nil {
+		return CatalogDiff{}, fmt.Errorf("catalog %s: %w", newCatalog, err)
+	}
+	if len(packages) > 0 {
+		*oldDC = restrictToPackages(*oldDC, packages)
+		*newDC = restrictToPackages(*newDC, packages)
+	}
+	return diffCatalogs(*oldDC, *newDC), nil
+}
+
+// catalogVersionDeclConfig returns the declarative config of catalog. When catalog is referenced by
+// digest and was already pulled to the working directory, the catalog is read from there without
+// accessing the registry.
+func (o FilterCollector) catalogVersionDeclConfig(ctx context.Context, catalog string) (*declcfg.DeclarativeConfig, error) {
+	op := v2alpha1.Operator{Catalog: catalog}
+	imgSpec, err := image.ParseRef(catalog)
+	if err != nil {
+		return nil, err
+	}
+	if imgSpec.Transport == ociProtocol || !imgSpec.IsImageByDigest() {
+		return o.originalDeclConfigOf(ctx, op)
+	}
+
+	imageIndexDir := filepath.Join(o.Opts.Global.WorkingDir, operatorCatalogsDir, imgSpec.ComponentName(), imgSpec.Digest)
+	if _, err := os.Stat(filepath.Join(imageIndexDir, operatorCatalogImageDir, "index.json")); err != nil {
+		return o.originalDeclConfig(ctx, op, imgSpec, imageIndexDir)
+	}
+	o.Log.Debug("catalog %s found in the working directory", catalog)
+	dcPath, err := o.extractOCIConfigLayers(catalog, imgSpec, imageIndexDir)
+	if err != nil {
+		return nil, err
+	}
+	return o.ctlgHandler.getDeclarativeConfig(dcPath)
+}
+
+// diffCatalogs returns the content of newDC that is not in oldDC, and the content of oldDC that is not in newDC
+func diffCatalogs(oldDC, newDC declcfg.DeclarativeConfig) CatalogDiff {
+	diff := CatalogDiff{}
+	diff.AddedPackages, diff.RemovedPackages = diffSets(packageKeys(oldDC), packageKeys(newDC))
+	diff.AddedChannels, diff.RemovedChannels = diffSets(channelKeys(oldDC), channelKeys(newDC))
+	diff.AddedBundles, diff.RemovedBundles = diffSets(bundleKeys(oldDC), bundleKeys(newDC))
+	diff.AddedEdges, diff.RemovedEdges = diffSets(edgeKeys(oldDC), edgeKeys(newDC))
+	diff.AddedRelatedImages, diff.RemovedRelatedImages = diffSets(relatedImageKeys(oldDC), relatedImageKeys(newDC))
+	return diff
+}
+
+// restrictToPackages returns the content of dc belonging to packages
+func restrictToPackages(dc declcfg.DeclarativeConfig, packages []string) declcfg.DeclarativeConfig {
+	restricted := dc
+	restricted.Packages = slices.DeleteFunc(slices.Clone(dc.Packages), func(pkg declcfg.Package) bool { return !slices.Contains(packages, pkg.Name) })
+	restricted.Channels = slices.DeleteFunc(slices.Clone(dc.Channels), func(ch declcfg.Channel) bool { return !slices.Contains(packages, ch.Package) })
+	restricted.Bundles = slices.DeleteFunc(slices.Clone(dc.Bundles), func(b declcfg.Bundle) bool { return !slices.Contains(packages, b.Package) })
+	restricted.Deprecations = slices.DeleteFunc(slices.Clone(dc.Deprecations), func(d declcfg.Deprecation) bool { return !slices.Contains(packages, d.Package) })
+	return restricted
+}
+
+func packageKeys(dc declcfg.DeclarativeConfig) map[string]bool {
+	keys := map[string]bool{}
+	for _, pkg := range dc.Packages {
+		keys[pkg.Name] = true
+	}
+	return keys
+}
+
+func channelKeys(dc declcfg.DeclarativeConfig) map[string]bool {
+	keys := map[string]bool{}
+	for _, ch := range dc.Channels {
+		keys[ch.Package+"/"+ch.Name] = true
+	}
+	return keys
+}
+
+func bundleKeys(dc declcfg.DeclarativeConfig) map[string]bool {
+	keys := map[string]bool{}
+	for _, b := range dc.Bundles {
+		keys[b.Package+"/"+b.Name] = true
+	}
+	return keys
+}
+
+func edgeKeys(dc declcfg.DeclarativeConfig) map[string]bool {
+	keys := map[string]bool{}
+	for _, ch := range dc.Channels {
+		for _, e := range ch.Entries {
+			if e.Replaces != "" {
+				keys[fmt.Sprintf("%s/%s: %s replaces %s", ch.Package, ch.Name, e.Name, e.Replaces)] = true
+			}
+			for _, skip := range e.Skips {
+				keys[fmt.Sprintf("%s/%s: %s skips %s", ch.Package, ch.Name, e.Name, skip)] = true
+			}
+		}
+	}
+	return keys
+}
+
+// relatedImageKeys returns the bundle images and the related images of dc
+func relatedImageKeys(dc declcfg.DeclarativeConfig) map[string]bool {
+	keys := map[string]bool{}
+	for _, b := range dc.Bundles {
+		if b.Image != "" {
+			keys[b.Image] = true
+		}
+		for _, ri := range b.RelatedImages {
+			if ri.Image != "" {
+				keys[ri.Image] = true
+			}
+		}
+	}
+	return keys
+}
+
+// diffSets returns the sorted keys only in newKeys (added) and only in oldKeys (removed)
+func diffSets(oldKeys, newKeys map[string]bool) ([]string, []string) {
+	var added, removed []string
+	for key := range newKeys {
+		if !oldKeys[key] {
+			added = append(added, key)
+		}
+	}
+	for key := range oldKeys {
+		if !newKeys[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,50 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCatalogs(t *testing.T) {
+	oldDC := testDependencyCatalog()
+	newDC := testDependencyCatalog()
+	newDC.Packages = newDC.Packages[:2]
+	newDC.Channels = newDC.Channels[:2]
+	newDC.Bundles = append(newDC.Bundles[:4], testDependencyBundle("bar", "2.1.0"))
+
+	diff := diffCatalogs(oldDC, newDC)
+	assert.Equal(t, CatalogDiff{
+		RemovedPackages: []string{"baz"},
+		RemovedChannels: []string{"bar/candidate", "baz/stable"},
+		AddedBundles:    []string{"bar/bar.v2.1.0"},
+		RemovedBundles:  []string{"bar/bar.v2.0.0", "baz/baz.v0.1.0"},
+	}, diff)
+	assert.False(t, diff.IsEmpty())
+	assert.True(t, diffCatalogs(oldDC, oldDC).IsEmpty())
+
+	t.Run("Testing diffCatalogs - edges and related images : should report the changes", func(t *testing.T) {
+		newDC := testDependencyCatalog()
+		newDC.Channels[1].Entries = []declcfg.ChannelEntry{
+			{Name: "bar.v1.0.0"},
+			{Name: "bar.v1.1.0", Replaces: "bar.v1.0.0"},
+			{Name: "bar.v2.0.0", Replaces: "bar.v1.1.0", Skips: []string{"bar.v1.0.0"}},
+		}
+		newDC.Bundles[0].Image = "quay.io/foo/bundle:v1.0.0"
+		diff := diffCatalogs(oldDC, newDC)
+		assert.Equal(t, CatalogDiff{
+			AddedEdges:         []string{"bar/stable: bar.v2.0.0 skips bar.v1.0.0"},
+			AddedRelatedImages: []string{"quay.io/foo/bundle:v1.0.0"},
+		}, diff)
+	})
+
+	t.Run("Testing diffCatalogs - restricted to packages : should ignore the other packages", func(t *testing.T) {
+		diff := diffCatalogs(restrictToPackages(oldDC, []string{"foo", "bar"}), restrictToPackages(newDC, []string{"foo", "bar"}))
+		assert.Equal(t, CatalogDiff{
+			RemovedChannels: []string{"bar/candidate"},
+			AddedBundles:    []string{"bar/bar.v2.1.0"},
+			RemovedBundles:  []string{"bar/bar.v2.0.0"},
+		}, diff)
+	})
+}
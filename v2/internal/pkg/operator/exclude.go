@@ -0,0 +1,105 @@
+package operator
+
+import (
+	"slices"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+)
+
+// hasExclusions returns true when the catalog excludes packages, or one of its packages excludes channels or bundles
+func hasExclusions(op v2alpha1.Operator) bool {
+	if len(op.ExcludePackages) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(op.Packages, func(pkg v2alpha1.IncludePackage) bool {
+		return len(pkg.ExcludeChannels) > 0 || len(pkg.ExcludeBundles) > 0
+	})
+}
+
+// excludeFromCatalog removes from dc the excluded packages, channels and bundles,
+// before the catalog is filtered.
+// Bundles that are no longer part of any channel once the excluded channels are
+// removed are excluded too.
+// The dependencies on the excluded content are reported as missing when the dependencies are resolved,
+// and by warnExcludedDependencies when they are not.
+func excludeFromCatalog(dc declcfg.DeclarativeConfig, op v2alpha1.Operator) declcfg.DeclarativeConfig {
+	if !hasExclusions(op) {
+		return dc
+	}
+	excludedChannels := map[string][]string{}
+	excludedBundles := map[string][]string{}
+	for _, pkg := range op.Packages {
+		excludedChannels[pkg.Name] = pkg.ExcludeChannels
+		excludedBundles[pkg.Name] = pkg.ExcludeBundles
+	}
+
+	return removeContent(dc, op.ExcludePackages, excludedChannels, excludedBundles, "the exclusions")
+}
+
+// removeContent removes from dc the packages, the channels and the bundles (per package) given.
+// Bundles that are no longer part of any channel once the channels are removed are removed too.
+// reason is used for logging.
+func removeContent(dc declcfg.DeclarativeConfig, packages []string, channels, bundles map[string][]string, reason string) declcfg.DeclarativeConfig {
+	pruned := dc
+	// the packages given are removed on purpose: unlike the packages left without bundle, they are not reported
+	pruned.Packages = slices.DeleteFunc(slices.Clone(dc.Packages), func(pkg declcfg.Package) bool {
+		if !slices.Contains(packages, pkg.Name) {
+			return false
+		}
+		if internalLog != nil {
+			internalLog.Debug("package %s removed by %s", pkg.Name, reason)
+		}
+		return true
+	})
+	pruned.Channels = slices.DeleteFunc(slices.Clone(dc.Channels), func(ch declcfg.Channel) bool {
+		return slices.Contains(packages, ch.Package) || slices.Contains(channels[ch.Package], ch.Name)
+	})
+	pruned.Bundles = slices.DeleteFunc(slices.Clone(dc.Bundles), func(b declcfg.Bundle) bool {
+		return slices.Contains(packages, b.Package)
+	})
+	pruned.Deprecations = slices.DeleteFunc(slices.Clone(dc.Deprecations), func(d declcfg.Deprecation) bool {
+		return slices.Contains(packages, d.Package)
+	})
+	inChannels := map[string]map[string]bool{}
+	for _, ch := range pruned.Channels {
+		if inChannels[ch.Package] == nil {
+			inChannels[ch.Package] = map[string]bool{}
+		}
+		for _, e := range ch.Entries {
+			inChannels[ch.Package][e.Name] = true
+		}
+	}
+
+	removed := map[string]map[string]bool{}
+	for _, b := range pruned.Bundles {
+		if !slices.Contains(bundles[b.Package], b.Name) && inChannels[b.Package][b.Name] {
+			continue
+		}
+		if removed[b.Package] == nil {
+			removed[b.Package] = map[string]bool{}
+		}
+		removed[b.Package][b.Name] = true
+	}
+	return removeBundles(pruned, removed, reason)
+}
+
+// warnExcludedDependencies logs a warning for each dependency of the bundles of filtered that only the
+// content excluded from source satisfies: used when the dependencies are not resolved, the missing
+// dependencies are not reported otherwise.
+func warnExcludedDependencies(source, excluded, filtered declcfg.DeclarativeConfig, op v2alpha1.Operator) error {
+	if !hasExclusions(op) || internalLog == nil {
+		return nil
+	}
+	unresolved, err := unresolvedDependencies(filtered)
+	if err != nil {
+		return err
+	}
+	for _, dep := range unresolved {
+		if findDependency(excluded, dep) == nil && findDependency(source, dep) != nil {
+			internalLog.Warn("catalog %s: dependency %s of bundle %s is only satisfied by excluded content: it will not be mirrored", op.Catalog, dep, dep.requiredBy)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+)
+
+// testExcludeCatalog returns testSelectorCatalog with a package bar, required by foo.v2.0.0
+func testExcludeCatalog() declcfg.DeclarativeConfig {
+	dc := testSelectorCatalog()
+	dc.Packages = append(dc.Packages, declcfg.Package{Schema: "olm.package", Name: "bar", DefaultChannel: "stable"})
+	dc.Channels = append(dc.Channels, declcfg.Channel{Schema: "olm.channel", Name: "stable", Package: "bar", Entries: []declcfg.ChannelEntry{
+		{Name: "bar.v1.0.0"},
+	}})
+	dc.Bundles = append(dc.Bundles, declcfg.Bundle{Schema: "olm.bundle", Name: "bar.v1.0.0", Package: "bar", Properties: []property.Property{
+		property.MustBuildPackage("bar", "1.0.0"),
+	}})
+	for i := range dc.Bundles {
+		if dc.Bundles[i].Name == "foo.v2.0.0" {
+			dc.Bundles[i].Properties = append(dc.Bundles[i].Properties, property.MustBuildPackageRequired("bar", ">=1.0.0"))
+		}
+	}
+	return dc
+}
+
+func TestExcludeFromCatalog(t *testing.T) {
+	type testCase struct {
+		caseName               string
+		op                     v2alpha1.Operator
+		expectedBundles        []string
+		expectedEntries        map[string][]declcfg.ChannelEntry
+		expectedDefaultChannel string
+	}
+
+	testCases := []testCase{
+		{
+			caseName:               "no exclusion - should keep the catalog untouched",
+			op:                     v2alpha1.Operator{Full: true},
+			expectedBundles:        []string{"foo.v1.0.0", "foo.v1.1.0", "foo.v1.2.0", "foo.v1.3.0", "foo.v2.0.0", "bar.v1.0.0"},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName:               "excluded package required by an included one - should remove it",
+			op:                     v2alpha1.Operator{Full: true, ExcludePackages: []string{"bar"}},
+			expectedBundles:        []string{"foo.v1.0.0", "foo.v1.1.0", "foo.v1.2.0", "foo.v1.3.0", "foo.v2.0.0"},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName: "excluded bundles - should remove them and rewire the channels",
+			op: v2alpha1.Operator{
+				IncludeConfig: v2alpha1.IncludeConfig{
+					Packages: []v2alpha1.IncludePackage{{
+						Name:           "foo",
+						ExcludeBundles: []string{"foo.v1.1.0", "foo.v1.2.0"},
+					}},
+				},
+			},
+			expectedBundles: []string{"foo.v1.0.0", "foo.v1.3.0", "foo.v2.0.0", "bar.v1.0.0"},
+			expectedEntries: map[string][]declcfg.ChannelEntry{
+				"stable": {
+					{Name: "foo.v1.0.0"},
+					{Name: "foo.v1.3.0", Replaces: "foo.v1.0.0", Skips: []string{"foo.v1.2.0", "foo.v1.1.0"}},
+				},
+			},
+			expectedDefaultChannel: "stable",
+		},
+		{
+			caseName: "excluded default channel - should remove its bundles and use another channel as default",
+			op: v2alpha1.Operator{
+				IncludeConfig: v2alpha1.IncludeConfig{
+					Packages: []v2alpha1.IncludePackage{{
+						Name:            "foo",
+						ExcludeChannels: []string{"stable"},
+					}},
+				},
+			},
+			expectedBundles:        []string{"foo.v2.0.0", "bar.v1.0.0"},
+			expectedDefaultChannel: "fast",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.caseName, func(t *testing.T) {
+			excluded := excludeFromCatalog(testExcludeCatalog(), testCase.op)
+
+			bundles := []string{}
+			for _, b := range excluded.Bundles {
+				bundles = append(bundles, b.Name)
+			}
+			assert.Equal(t, testCase.expectedBundles, bundles)
+			for _, ch := range excluded.Channels {
+				if expected, ok := testCase.expectedEntries[ch.Name]; ok && ch.Package == "foo" {
+					assert.Equal(t, expected, ch.Entries)
+				}
+			}
+			assert.Equal(t, testCase.expectedDefaultChannel, excluded.Packages[0].DefaultChannel)
+		})
+	}
+
+	t.Run("excluded package required by a filtered bundle - should report the missing dependency", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer func() {
+			log.SetOutput(os.Stderr)
+		}()
+		setInternalLog(clog.New("debug"))
+
+		op := v2alpha1.Operator{Catalog: "example.com/catalog:v1", Full: true, ExcludePackages: []string{"bar"}}
+		filtered, err := filterCatalog(context.TODO(), testExcludeCatalog(), op)
+		assert.NoError(t, err)
+		for _, b := range filtered.Bundles {
+			assert.NotEqual(t, "bar", b.Package)
+		}
+		assert.Contains(t, buf.String(), "catalog example.com/catalog:v1: dependency olm.package.required bar >=1.0.0 of bundle foo.v2.0.0 not found")
+		assert.Equal(t, 1, strings.Count(buf.String(), "foo.v2.0.0 not found"))
+	})
+
+	t.Run("excluded package required by a filtered bundle, dependencies skipped - should warn", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer func() {
+			log.SetOutput(os.Stderr)
+		}()
+		setInternalLog(clog.New("debug"))
+
+		op := v2alpha1.Operator{Catalog: "example.com/catalog:v1", Full: true, SkipDependencies: true, ExcludePackages: []string{"bar"}}
+		_, err := filterCatalog(context.TODO(), testExcludeCatalog(), op)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "catalog example.com/catalog:v1: dependency olm.package.required bar >=1.0.0 of bundle foo.v2.0.0 is only satisfied by excluded content")
+		assert.NotContains(t, buf.String(), "no bundle of package bar left")
+	})
+
+	t.Run("package not excluded, dependencies skipped - should not warn", func(t *testing.T) {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		defer func() {
+			log.SetOutput(os.Stderr)
+		}()
+		setInternalLog(clog.New("debug"))
+
+		op := v2alpha1.Operator{Catalog: "example.com/catalog:v1", SkipDependencies: true, IncludeConfig: v2alpha1.IncludeConfig{Packages: []v2alpha1.IncludePackage{{Name: "foo", ExcludeBundles: []string{"foo.v1.0.0"}}}}}
+		_, err := filterCatalog(context.TODO(), testExcludeCatalog(), op)
+		assert.NoError(t, err)
+		assert.NotContains(t, buf.String(), "excluded content")
+	})
+}
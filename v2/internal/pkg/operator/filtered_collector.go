@@ -11,9 +11,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/containers/image/v5/types"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
 	"github.com/otiai10/copy"
 	"github.com/vbauerster/mpb/v8"
 
@@ -84,6 +86,16 @@ func (o *FilterCollector) OperatorImageCollector(ctx context.Context) (v2alpha1.
 	}
 	p.Wait()
 
+	if err := o.resolveDependenciesAcrossCatalogs(ctx, collectorSchema.CatalogToFBCMap, relatedImages, copyImageSchemaMap); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := o.reportDeprecatedContent(collectorSchema.CatalogToFBCMap); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := o.mergeTargetCatalogs(ctx, collectorSchema.CatalogToFBCMap, relatedImages); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	o.Log.Debug(collectorPrefix+"related images length %d ", len(relatedImages))
 	count := 0
 	for _, v := range relatedImages {
@@ -112,8 +124,225 @@ func (o *FilterCollector) OperatorImageCollector(ctx context.Context) (v2alpha1.
 	return collectorSchema, errors.Join(allErrs...)
 }
 
+// reportDeprecatedContent logs a warning for each deprecated package, channel and bundle mirrored from
+// the catalogs with the warn DeprecatedContent mode, and writes them to a report in the logs directory
+func (o *FilterCollector) reportDeprecatedContent(results map[string]v2alpha1.CatalogFilterResult) error {
+	report := []string{}
+	for _, key := range slices.Sorted(maps.Keys(results)) {
+		result := results[key]
+		if result.OperatorFilter.DeprecatedContent != v2alpha1.DeprecatedContentWarn || result.DeclConfig == nil {
+			continue
+		}
+		for _, line := range deprecationReport(result.OperatorFilter.Catalog, *result.DeclConfig) {
+			o.Log.Warn("%s", line)
+			report = append(report, line)
+		}
+	}
+	if len(report) == 0 {
+		return nil
+	}
+
+	reportPath := filepath.Join(o.LogsDir, deprecatedContentReportFilename)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return fmt.Errorf("create logs directory: %w", err)
+	}
+	if err := os.WriteFile(reportPath, []byte(strings.Join(report, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("write deprecated content report: %w", err)
+	}
+	o.Log.Info("%s file created", reportPath)
+	return nil
+}
+
+// resolveDependenciesAcrossCatalogs looks for the dependencies missing from the catalogs with
+// ResolveDependenciesAcrossCatalogs in the other catalogs of the ImageSetConfiguration.
+// A dependency already mirrored from another catalog is only reported. Otherwise, the bundle
+// satisfying it is added to the packages of the catalog providing it, which is collected again:
+// only the catalogs with ResolveDependenciesAcrossCatalogs are searched and changed.
+// It fails when the catalog providing a dependency has no package filter to add it to.
+func (o *FilterCollector) resolveDependenciesAcrossCatalogs(
+	ctx context.Context,
+	results map[string]v2alpha1.CatalogFilterResult,
+	relatedImages map[string][]v2alpha1.RelatedImage,
+	copyImageSchemaMap *v2alpha1.CopyImageSchemaMap,
+) error {
+	keys := slices.Sorted(maps.Keys(results))
+	originals := map[string]*declcfg.DeclarativeConfig{}
+	additions := map[string][]v2alpha1.IncludePackage{}
+	var errs []error
+
+	for _, key := range keys {
+		op := results[key].OperatorFilter
+		if !op.ResolveDependenciesAcrossCatalogs || op.SkipDependencies || results[key].DeclConfig == nil {
+			continue
+		}
+		missing, err := unresolvedDependencies(*results[key].DeclConfig)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("catalog %q: %w", op.Catalog, err))
+			continue
+		}
+		for _, dep := range missing {
+			if provider := o.mirroredDependencyProvider(results, keys, key, dep); provider != "" {
+				o.Log.Info("catalog %s: dependency %s of bundle %s mirrored from catalog %s", op.Catalog, dep, dep.requiredBy, provider)
+				continue
+			}
+			found := false
+			for _, otherKey := range keys {
+				if otherKey == key {
+					continue
+				}
+				otherOp := results[otherKey].OperatorFilter
+				if !otherOp.ResolveDependenciesAcrossCatalogs {
+					continue
+				}
+				if originals[otherKey] == nil {
+					original, err := o.originalDeclConfigOf(ctx, otherOp)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("catalog %q: %w", otherOp.Catalog, err))
+						continue
+					}
+					originals[otherKey] = original
+				}
+				b := findDependency(*originals[otherKey], dep)
+				if b == nil {
+					continue
+				}
+				pkg, err := includePackageOf(*originals[otherKey], *b)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("catalog %q: %w", otherOp.Catalog, err))
+					break
+				}
+				o.Log.Info("catalog %s: adding bundle %s of package %s from catalog %s: required by %s (%s)", op.Catalog, b.Name, b.Package, otherOp.Catalog, dep.requiredBy, dep)
+				if !slices.ContainsFunc(additions[otherKey], func(p v2alpha1.IncludePackage) bool { return p.Name == pkg.Name }) {
+					additions[otherKey] = append(additions[otherKey], pkg)
+				}
+				found = true
+				break
+			}
+			if !found {
+				o.Log.Warn("catalog %s: dependency %s of bundle %s not found in the catalogs resolving dependencies across catalogs", op.Catalog, dep, dep.requiredBy)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if len(additions[key]) == 0 {
+			continue
+		}
+		op := results[key].OperatorFilter
+		packages, err := o.packagesWithDependencies(op, additions[key])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(packages) == len(op.Packages) {
+			continue
+		}
+		op.Packages = packages
+		result, err := o.collectOperator(ctx, op, relatedImages, copyImageSchemaMap)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("collect catalog %q: %w", op.Catalog, err))
+			continue
+		}
+		results[key] = result
+	}
+	return errors.Join(errs...)
+}
+
+// packagesWithDependencies returns the packages of op extended with the packages of the dependencies.
+// A catalog without package filter mirrors the heads of all its packages: a package filter cannot be
+// added to it without dropping the other packages, so it fails.
+func (o *FilterCollector) packagesWithDependencies(op v2alpha1.Operator, dependencies []v2alpha1.IncludePackage) ([]v2alpha1.IncludePackage, error) {
+	if len(op.Packages) == 0 {
+		return nil, fmt.Errorf("catalog %q: no package filter to add the dependencies %v to: add them, and the other packages to mirror, to its packages", op.Catalog, packageNames(dependencies))
+	}
+	packages := slices.Clone(op.Packages)
+	for _, pkg := range dependencies {
+		if slices.ContainsFunc(packages, func(p v2alpha1.IncludePackage) bool { return p.Name == pkg.Name }) {
+			o.Log.Warn("catalog %s: package %s is already filtered, add the version %s to its filter to mirror the dependency", op.Catalog, pkg.Name, pkg.Channels[0].MinVersion)
+			continue
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// mirroredDependencyProvider returns the first catalog, other than the one at key, whose filtered declarative config satisfies dep
+func (o *FilterCollector) mirroredDependencyProvider(results map[string]v2alpha1.CatalogFilterResult, keys []string, key string, dep dependency) string {
+	for _, otherKey := range keys {
+		if otherKey == key || results[otherKey].DeclConfig == nil {
+			continue
+		}
+		if findDependency(*results[otherKey].DeclConfig, dep) != nil {
+			return results[otherKey].OperatorFilter.Catalog
+		}
+	}
+	return ""
+}
+
+// originalDeclConfigOf returns the declarative config of the catalog of op, before any filtering
+func (o *FilterCollector) originalDeclConfigOf(ctx context.Context, op v2alpha1.Operator) (*declcfg.DeclarativeConfig, error) {
+	imgSpec, err := image.ParseRef(op.Catalog)
+	if err != nil {
+		return nil, err
+	}
+	catalogDigest, err := o.getCatalogDigest(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	imageIndexDir := filepath.Join(o.Opts.Global.WorkingDir, operatorCatalogsDir, imgSpec.ComponentName(), catalogDigest)
+	return o.originalDeclConfig(ctx, op, imgSpec, imageIndexDir)
+}
+
+// includePackageOf returns the filter selecting only b, in the default channel of its package when possible
+func includePackageOf(dc declcfg.DeclarativeConfig, b declcfg.Bundle) (v2alpha1.IncludePackage, error) {
+	version, err := bundleVersion(b)
+	if err != nil {
+		return v2alpha1.IncludePackage{}, err
+	}
+	channels := []string{}
+	defaultChannel := ""
+	for _, pkg := range dc.Packages {
+		if pkg.Name == b.Package {
+			defaultChannel = pkg.DefaultChannel
+		}
+	}
+	for _, ch := range dc.Channels {
+		if ch.Package == b.Package && slices.ContainsFunc(ch.Entries, func(e declcfg.ChannelEntry) bool { return e.Name == b.Name }) {
+			channels = append(channels, ch.Name)
+		}
+	}
+	if len(channels) == 0 {
+		return v2alpha1.IncludePackage{}, fmt.Errorf("bundle %s is not in any channel", b.Name)
+	}
+	channel := channels[0]
+	if slices.Contains(channels, defaultChannel) {
+		channel = defaultChannel
+	}
+	return v2alpha1.IncludePackage{
+		Name: b.Package,
+		Channels: []v2alpha1.IncludeChannel{{
+			Name: channel,
+			IncludeBundle: v2alpha1.IncludeBundle{
+				MinVersion: version.String(),
+				MaxVersion: version.String(),
+			},
+		}},
+	}, nil
+}
+
+func packageNames(packages []v2alpha1.IncludePackage) []string {
+	names := []string{}
+	for _, pkg := range packages {
+		names = append(names, pkg.Name)
+	}
+	return names
+}
+
 func isFullCatalog(catalog v2alpha1.Operator) bool {
-	return len(catalog.IncludeConfig.Packages) == 0 && catalog.Full
+	return len(catalog.IncludeConfig.Packages) == 0 && catalog.Full &&
+		catalog.BundleSelector == nil && len(catalog.ExcludePackages) == 0 && catalog.PlatformCompatibility == nil &&
+		catalog.DeprecatedContent != v2alpha1.DeprecatedContentSkip && !catalog.MergeTargetCatalog &&
+		len(catalog.AdditionalBundles) == 0
 }
 
 func createFolders(paths []string) error {
@@ -134,6 +363,7 @@ func digestOfFilter(catalog v2alpha1.Operator) (string, error) {
 	c.TargetCatalog = ""
 	c.TargetTag = ""
 	c.TargetCatalogSourceTemplate = ""
+	c.MergeTargetCatalog = false
 	pkgs, err := json.Marshal(c)
 	if err != nil {
 		return "", err
@@ -199,6 +429,12 @@ func (o FilterCollector) collectOperator( //nolint:cyclop // TODO: this needs fu
 		return v2alpha1.CatalogFilterResult{}, err
 	}
 
+	// the OpenShift version range is part of the filter, and of its digest
+	op, err = resolvePlatformCompatibility(op, o.Config)
+	if err != nil {
+		return v2alpha1.CatalogFilterResult{}, err
+	}
+
 	catalogDigest, err := o.getCatalogDigest(ctx, op)
 	if err != nil {
 		// OCPBUGS-36548 (manifest unknown)
@@ -251,11 +487,8 @@ func (o FilterCollector) collectOperator( //nolint:cyclop // TODO: this needs fu
 
 	rebuiltTag := ""
 	if result.ToRebuild {
-		tag, err := digestOfFilter(op)
-		if err != nil {
-			return v2alpha1.CatalogFilterResult{}, err
-		}
-		rebuiltTag = tag
+		// the catalog is rebuilt with the tag of its filtered catalog directory
+		rebuiltTag = filepath.Base(filepath.Dir(result.FilteredConfigPath))
 	}
 
 	componentName := imgSpec.ComponentName() + "." + result.Digest
@@ -302,9 +535,15 @@ func (o FilterCollector) filterOperator(ctx context.Context, op v2alpha1.Operato
 		return v2alpha1.CatalogFilterResult{}, err
 	}
 
+	// the additional bundles are rendered during mirrorToDisk and mirrorToMirror: the catalog is rebuilt on each run,
+	// so that the changes of their directories are picked up.
+	// During diskToMirror and delete, the catalog filtered by mirrorToDisk, with its additional bundles, is reused.
+	fromCache := o.Opts.IsDiskToMirror() || o.Opts.IsDeleteMode()
+	withAdditionalBundles := len(op.AdditionalBundles) > 0
+
 	var isAlreadyFiltered bool
 	filteredImageDigest, err := os.ReadFile(filepath.Join(filteredCatalogsDir, filterDigest, "digest"))
-	if err != nil {
+	if err != nil || (withAdditionalBundles && !fromCache) {
 		// If there was an error reading the digest file, we assume the catalog has not been filtered
 		isAlreadyFiltered = false
 	} else { // digest read
@@ -332,17 +571,26 @@ func (o FilterCollector) filterOperator(ctx context.Context, op v2alpha1.Operato
 	}
 	o.Log.Debug("Catalog has not been filtered previously")
 
-	if err := o.ensureCatalogInOCIFormat(ctx, imgSpec, op.Catalog, imageIndexDir); err != nil {
-		return v2alpha1.CatalogFilterResult{}, err
-	}
-
-	// It's now in oci format so we can go directly to the index.json file
-	dcPath, err := o.extractOCIConfigLayers(op.Catalog, imgSpec, imageIndexDir)
-	if err != nil {
-		return v2alpha1.CatalogFilterResult{}, err
+	filteredDigestPath := filepath.Join(filteredCatalogsDir, filterDigest, operatorCatalogConfigDir)
+	if withAdditionalBundles && fromCache {
+		// the original catalog in the cache does not have the additional bundles
+		if _, err := os.Stat(filteredDigestPath); err != nil {
+			return v2alpha1.CatalogFilterResult{}, fmt.Errorf("catalog %s with its additional bundles was not filtered by mirrorToDisk: %w", op.Catalog, err)
+		}
+		filteredDC, err := o.ctlgHandler.getDeclarativeConfig(filteredDigestPath)
+		if err != nil {
+			return v2alpha1.CatalogFilterResult{}, fmt.Errorf("retrieve filtered catalog config from %s: %w", filteredDigestPath, err)
+		}
+		return v2alpha1.CatalogFilterResult{
+			OperatorFilter:     op,
+			FilteredConfigPath: filteredDigestPath,
+			ToRebuild:          true,
+			DeclConfig:         filteredDC,
+			Digest:             catalogDigest,
+		}, nil
 	}
 
-	originalDC, err := o.ctlgHandler.getDeclarativeConfig(dcPath)
+	originalDC, err := o.originalDeclConfig(ctx, op, imgSpec, imageIndexDir)
 	if err != nil {
 		return v2alpha1.CatalogFilterResult{}, err
 	}
@@ -358,12 +606,11 @@ func (o FilterCollector) filterOperator(ctx context.Context, op v2alpha1.Operato
 		}, nil
 	}
 
-	filteredDC, err := filterCatalog(ctx, *originalDC, op)
+	filteredDC, err := o.filteredDeclConfig(ctx, op, *originalDC)
 	if err != nil {
 		return v2alpha1.CatalogFilterResult{}, err
 	}
 
-	filteredDigestPath := filepath.Join(filteredCatalogsDir, filterDigest, operatorCatalogConfigDir)
 	if err := createFolders([]string{filteredDigestPath}); err != nil {
 		return v2alpha1.CatalogFilterResult{}, err
 	}
@@ -381,6 +628,41 @@ func (o FilterCollector) filterOperator(ctx context.Context, op v2alpha1.Operato
 	}, nil
 }
 
+// filteredDeclConfig filters the declarative config of the catalog of op, and adds the additional bundles of op
+func (o FilterCollector) filteredDeclConfig(ctx context.Context, op v2alpha1.Operator, originalDC declcfg.DeclarativeConfig) (*declcfg.DeclarativeConfig, error) {
+	filteredDC, err := filterCatalog(ctx, originalDC, op)
+	if err != nil {
+		return nil, err
+	}
+	if len(op.AdditionalBundles) == 0 {
+		return filteredDC, nil
+	}
+	additionalBundles, err := o.renderAdditionalBundles(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	dc, err := addBundles(*filteredDC, additionalBundles)
+	if err != nil {
+		return nil, err
+	}
+	return &dc, nil
+}
+
+// originalDeclConfig returns the declarative config of the catalog, before any filtering
+func (o FilterCollector) originalDeclConfig(ctx context.Context, op v2alpha1.Operator, imgSpec image.ImageSpec, imageIndexDir string) (*declcfg.DeclarativeConfig, error) {
+	if err := o.ensureCatalogInOCIFormat(ctx, imgSpec, op.Catalog, imageIndexDir); err != nil {
+		return nil, err
+	}
+
+	// It's now in oci format so we can go directly to the index.json file
+	dcPath, err := o.extractOCIConfigLayers(op.Catalog, imgSpec, imageIndexDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.ctlgHandler.getDeclarativeConfig(dcPath)
+}
+
 func (o FilterCollector) ensureCatalogInOCIFormat(ctx context.Context, imgSpec image.ImageSpec, catalog, imageIndexDir string) error {
 	o.Log.Debug("Ensuring catalog is in OCI format")
 	catalogImageDir := filepath.Join(imageIndexDir, operatorCatalogImageDir)
@@ -0,0 +1,137 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+// CatalogListing is the content of a catalog, as listed by the list operators command
+type CatalogListing struct {
+	Catalog  string           `json:"catalog"`
+	Packages []PackageListing `json:"packages"`
+}
+
+// PackageListing is a package of a catalog, with its channels
+type PackageListing struct {
+	Name           string           `json:"name"`
+	DefaultChannel string           `json:"defaultChannel"`
+	Channels       []ChannelListing `json:"channels"`
+}
+
+// ChannelListing is a channel of a package, with its head and its bundles sorted by version
+type ChannelListing struct {
+	Name    string          `json:"name"`
+	Head    string          `json:"head"`
+	Bundles []BundleListing `json:"bundles"`
+}
+
+// BundleListing is a bundle of a channel, with the number of its related images
+type BundleListing struct {
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	RelatedImages int    `json:"relatedImages"`
+}
+
+// ListCatalog pulls the catalog to the working directory, when not already there,
+// and lists its content, restricted to pkg and channel when set
+func ListCatalog(ctx context.Context,
+	log clog.PluggableLoggerInterface,
+	opts mirror.CopyOptions,
+	mirror mirror.MirrorInterface,
+	manifest manifest.ManifestInterface,
+	catalog, pkg, channel string,
+) (CatalogListing, error) {
+	o := &FilterCollector{OperatorCollector{Log: log, Opts: opts, Mirror: mirror, Manifest: manifest, ctlgHandler: catalogHandler{Log: log}}}
+	dc, err := o.originalDeclConfigOf(ctx, v2alpha1.Operator{Catalog: catalog})
+	if err != nil {
+		return CatalogListing{}, fmt.Errorf("catalog %s: %w", catalog, err)
+	}
+	return listCatalog(catalog, *dc, pkg, channel)
+}
+
+// listCatalog lists the content of dc, restricted to pkg and channel when set
+func listCatalog(catalog string, dc declcfg.DeclarativeConfig, pkg, channel string) (CatalogListing, error) {
+	bundles := map[string]map[string]declcfg.Bundle{}
+	for _, b := range dc.Bundles {
+		if bundles[b.Package] == nil {
+			bundles[b.Package] = map[string]declcfg.Bundle{}
+		}
+		bundles[b.Package][b.Name] = b
+	}
+
+	listing := CatalogListing{Catalog: catalog, Packages: []PackageListing{}}
+	for _, p := range dc.Packages {
+		if pkg != "" && p.Name != pkg {
+			continue
+		}
+		pkgListing := PackageListing{Name: p.Name, DefaultChannel: p.DefaultChannel, Channels: []ChannelListing{}}
+		for _, ch := range dc.Channels {
+			if ch.Package != p.Name || (channel != "" && ch.Name != channel) {
+				continue
+			}
+			chListing, err := listChannel(ch, bundles[p.Name])
+			if err != nil {
+				return CatalogListing{}, fmt.Errorf("package %s: %w", p.Name, err)
+			}
+			pkgListing.Channels = append(pkgListing.Channels, chListing)
+		}
+		if channel != "" && len(pkgListing.Channels) == 0 {
+			return CatalogListing{}, fmt.Errorf("channel %s not found in package %s", channel, p.Name)
+		}
+		sort.Slice(pkgListing.Channels, func(i, j int) bool { return pkgListing.Channels[i].Name < pkgListing.Channels[j].Name })
+		listing.Packages = append(listing.Packages, pkgListing)
+	}
+	if pkg != "" && len(listing.Packages) == 0 {
+		return CatalogListing{}, fmt.Errorf("package %s not found in catalog %s", pkg, catalog)
+	}
+	sort.Slice(listing.Packages, func(i, j int) bool { return listing.Packages[i].Name < listing.Packages[j].Name })
+	return listing, nil
+}
+
+// listChannel lists the bundles of ch sorted by version. The head is the entry that no other entry
+// replaces or skips, the highest version being chosen when there are several ones.
+func listChannel(ch declcfg.Channel, bundles map[string]declcfg.Bundle) (ChannelListing, error) {
+	referenced := map[string]bool{}
+	for _, e := range ch.Entries {
+		referenced[e.Replaces] = true
+		for _, skip := range e.Skips {
+			referenced[skip] = true
+		}
+	}
+
+	versions := map[string]semver.Version{}
+	listing := ChannelListing{Name: ch.Name, Bundles: []BundleListing{}}
+	for _, e := range ch.Entries {
+		b, ok := bundles[e.Name]
+		if !ok {
+			return ChannelListing{}, fmt.Errorf("channel %s: bundle %s not found", ch.Name, e.Name)
+		}
+		version, err := bundleVersion(b)
+		if err != nil {
+			return ChannelListing{}, fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+		versions[b.Name] = version
+		listing.Bundles = append(listing.Bundles, BundleListing{Name: b.Name, Version: version.String(), RelatedImages: len(b.RelatedImages)})
+	}
+	sort.SliceStable(listing.Bundles, func(i, j int) bool {
+		return versions[listing.Bundles[i].Name].LT(versions[listing.Bundles[j].Name])
+	})
+
+	for _, b := range slices.Backward(listing.Bundles) {
+		if !referenced[b.Name] {
+			listing.Head = b.Name
+			break
+		}
+	}
+	return listing, nil
+}
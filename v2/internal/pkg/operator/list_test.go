@@ -0,0 +1,51 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListCatalog(t *testing.T) {
+	dc := testDependencyCatalog()
+	dc.Bundles[2].RelatedImages = []declcfg.RelatedImage{{Name: "operator", Image: "quay.io/bar/operator:v1.1.0"}, {Name: "bundle", Image: "quay.io/bar/bundle:v1.1.0"}}
+
+	t.Run("Testing listCatalog - all packages : should list the packages sorted by name", func(t *testing.T) {
+		listing, err := listCatalog("oci:///catalog", dc, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "oci:///catalog", listing.Catalog)
+		names := []string{}
+		for _, pkg := range listing.Packages {
+			names = append(names, pkg.Name)
+		}
+		assert.Equal(t, []string{"bar", "baz", "foo"}, names)
+		assert.Equal(t, "stable", listing.Packages[0].DefaultChannel)
+		assert.Equal(t, "candidate", listing.Packages[0].Channels[0].Name)
+	})
+
+	t.Run("Testing listCatalog - channel : should list the bundles sorted by version with the head", func(t *testing.T) {
+		listing, err := listCatalog("oci:///catalog", dc, "bar", "stable")
+		assert.NoError(t, err)
+		assert.Equal(t, []PackageListing{{
+			Name:           "bar",
+			DefaultChannel: "stable",
+			Channels: []ChannelListing{{
+				Name: "stable",
+				Head: "bar.v2.0.0",
+				Bundles: []BundleListing{
+					{Name: "bar.v1.0.0", Version: "1.0.0"},
+					{Name: "bar.v1.1.0", Version: "1.1.0", RelatedImages: 2},
+					{Name: "bar.v2.0.0", Version: "2.0.0"},
+				},
+			}},
+		}}, listing.Packages)
+	})
+
+	t.Run("Testing listCatalog - unknown package or channel : should fail", func(t *testing.T) {
+		_, err := listCatalog("oci:///catalog", dc, "qux", "")
+		assert.EqualError(t, err, "package qux not found in catalog oci:///catalog")
+		_, err = listCatalog("oci:///catalog", dc, "bar", "fast")
+		assert.EqualError(t, err, "channel fast not found in package bar")
+	})
+}
@@ -0,0 +1,219 @@
+package operator
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+)
+
+// mergeGroup is a set of catalogs with MergeTargetCatalog, merged into the same target catalog.
+// The keys of their results are in the order of the ImageSetConfiguration: the first catalog
+// is the one the merged catalog is rebuilt from.
+type mergeGroup struct {
+	target string
+	keys   []string
+}
+
+// mergeGroupsOf returns the groups of catalogs of the ImageSetConfiguration merged into the same
+// target catalog, ignoring the catalogs that could not be collected.
+func mergeGroupsOf(operators []v2alpha1.Operator, results map[string]v2alpha1.CatalogFilterResult) ([]mergeGroup, error) {
+	groups := []mergeGroup{}
+	indexes := map[string]int{}
+	for _, op := range operators {
+		if !op.MergeTargetCatalog {
+			continue
+		}
+		imgSpec, err := image.ParseRef(op.Catalog)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := results[imgSpec.ReferenceWithTransport]; !ok {
+			continue
+		}
+		target := op.TargetCatalog + ":" + op.TargetTag
+		i, ok := indexes[target]
+		if !ok {
+			i = len(groups)
+			indexes[target] = i
+			groups = append(groups, mergeGroup{target: target})
+		}
+		groups[i].keys = append(groups[i].keys, imgSpec.ReferenceWithTransport)
+	}
+	return groups, nil
+}
+
+// mergeTargetCatalogs merges the filtered content of the catalogs with MergeTargetCatalog into the
+// result of the first catalog of each group, which is the only one rebuilt and mirrored to the target catalog.
+// During diskToMirror and delete, the merge is computed again, to find the catalog merged by mirrorToDisk
+// in the cache, under the same digest.
+func (o *FilterCollector) mergeTargetCatalogs(ctx context.Context, results map[string]v2alpha1.CatalogFilterResult, relatedImages map[string][]v2alpha1.RelatedImage) error {
+	groups, err := mergeGroupsOf(o.Config.Mirror.Operators, results)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, group := range groups {
+		if len(group.keys) < 2 {
+			continue
+		}
+		primaryKey := group.keys[0]
+		result, err := o.mergeCatalogs(ctx, group, results)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("merge into target catalog %s: %w", group.target, err))
+			continue
+		}
+		results[primaryKey] = result
+		if err := setRebuiltTag(relatedImages, result); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, key := range group.keys[1:] {
+			o.Log.Info("catalog %s merged into catalog %s, as %s", results[key].OperatorFilter.Catalog, results[primaryKey].OperatorFilter.Catalog, group.target)
+			catalogKey, err := catalogImageKey(results[key])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			delete(relatedImages, catalogKey)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mergeCatalogs saves the merged declarative config of the catalogs of group in the working directory of
+// the first one, under a digest of all their filters, and returns its result updated accordingly.
+// During diskToMirror and delete, the merged catalog is always rebuilt from the one mirrorToDisk pushed to the cache.
+func (o *FilterCollector) mergeCatalogs(ctx context.Context, group mergeGroup, results map[string]v2alpha1.CatalogFilterResult) (v2alpha1.CatalogFilterResult, error) {
+	primary := results[group.keys[0]]
+	configs := make([]catalogConfig, 0, len(group.keys))
+	for _, key := range group.keys {
+		result := results[key]
+		if result.DeclConfig == nil || result.FilteredConfigPath == "" {
+			return v2alpha1.CatalogFilterResult{}, fmt.Errorf("catalog %s has no filtered content to merge", result.OperatorFilter.Catalog)
+		}
+		configs = append(configs, catalogConfig{catalog: result.OperatorFilter.Catalog, dc: *result.DeclConfig})
+	}
+	mergedDC, err := mergeDeclConfigs(configs)
+	if err != nil {
+		return v2alpha1.CatalogFilterResult{}, err
+	}
+
+	mergeDigest, err := o.digestOfMerge(group, results)
+	if err != nil {
+		return v2alpha1.CatalogFilterResult{}, err
+	}
+	// working-dir/operator-catalogs/<catalog>/<catalog digest>/filtered-catalogs/<filter digest>/catalog-config
+	imageIndexDir := filepath.Dir(filepath.Dir(filepath.Dir(primary.FilteredConfigPath)))
+	mergedDir := filepath.Join(imageIndexDir, operatorCatalogFilteredDir, mergeDigest)
+	mergedConfigPath := filepath.Join(mergedDir, operatorCatalogConfigDir)
+
+	isAlreadyMerged := false
+	fromCache := o.Opts.IsDiskToMirror() || o.Opts.IsDeleteMode()
+	if mergedImageDigest, err := os.ReadFile(filepath.Join(mergedDir, "digest")); err == nil && !fromCache {
+		srcMergedCatalog, err := o.cachedCatalog(primary.OperatorFilter, mergeDigest)
+		if err != nil {
+			return v2alpha1.CatalogFilterResult{}, err
+		}
+		isAlreadyMerged = o.isAlreadyFiltered(ctx, srcMergedCatalog, string(mergedImageDigest))
+	}
+	if !isAlreadyMerged {
+		if err := os.RemoveAll(mergedConfigPath); err != nil {
+			return v2alpha1.CatalogFilterResult{}, err
+		}
+		if err := createFolders([]string{mergedConfigPath}); err != nil {
+			return v2alpha1.CatalogFilterResult{}, err
+		}
+		if err := saveDeclarativeConfig(mergedDC, mergedConfigPath); err != nil {
+			return v2alpha1.CatalogFilterResult{}, err
+		}
+	}
+
+	primary.FilteredConfigPath = mergedConfigPath
+	primary.DeclConfig = &mergedDC
+	primary.ToRebuild = !isAlreadyMerged
+	return primary, nil
+}
+
+type catalogConfig struct {
+	catalog string
+	dc      declcfg.DeclarativeConfig
+}
+
+// mergeDeclConfigs returns the content of all configs, failing when a package is found in several of them
+func mergeDeclConfigs(configs []catalogConfig) (declcfg.DeclarativeConfig, error) {
+	merged := declcfg.DeclarativeConfig{}
+	packageCatalogs := map[string]string{}
+	var errs []error
+	for _, c := range configs {
+		for _, pkg := range c.dc.Packages {
+			if other, ok := packageCatalogs[pkg.Name]; ok {
+				errs = append(errs, fmt.Errorf("package %s is in both catalogs %s and %s", pkg.Name, other, c.catalog))
+				continue
+			}
+			packageCatalogs[pkg.Name] = c.catalog
+		}
+		merged.Packages = append(merged.Packages, c.dc.Packages...)
+		merged.Channels = append(merged.Channels, c.dc.Channels...)
+		merged.Bundles = append(merged.Bundles, c.dc.Bundles...)
+		merged.Deprecations = append(merged.Deprecations, c.dc.Deprecations...)
+		merged.Others = append(merged.Others, c.dc.Others...)
+	}
+	if len(errs) > 0 {
+		return declcfg.DeclarativeConfig{}, errors.Join(errs...)
+	}
+	return merged, nil
+}
+
+// digestOfMerge returns a digest of the catalogs and of the filters of group. It changes whenever
+// the digest or the filter of one of the merged catalogs changes.
+func (o *FilterCollector) digestOfMerge(group mergeGroup, results map[string]v2alpha1.CatalogFilterResult) (string, error) {
+	filtered := make([]string, 0, len(group.keys))
+	for _, key := range group.keys {
+		rel, err := filepath.Rel(o.Opts.Global.WorkingDir, results[key].FilteredConfigPath)
+		if err != nil {
+			return "", err
+		}
+		filtered = append(filtered, rel)
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", md5.Sum(data))[0:32], nil
+}
+
+// catalogImageKey returns the key of the catalog image of result in the related images
+func catalogImageKey(result v2alpha1.CatalogFilterResult) (string, error) {
+	imgSpec, err := image.ParseRef(result.OperatorFilter.Catalog)
+	if err != nil {
+		return "", err
+	}
+	return imgSpec.ComponentName() + "." + result.Digest, nil
+}
+
+// setRebuiltTag sets the tag of the catalog image of result to the directory of its filtered config when rebuilt
+func setRebuiltTag(relatedImages map[string][]v2alpha1.RelatedImage, result v2alpha1.CatalogFilterResult) error {
+	key, err := catalogImageKey(result)
+	if err != nil {
+		return err
+	}
+	for i := range relatedImages[key] {
+		if relatedImages[key][i].Type != v2alpha1.TypeOperatorCatalog {
+			continue
+		}
+		relatedImages[key][i].RebuiltTag = ""
+		if result.ToRebuild {
+			relatedImages[key][i].RebuiltTag = filepath.Base(filepath.Dir(result.FilteredConfigPath))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package operator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/operator-framework/operator-registry/alpha/declcfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+func TestMergeDeclConfigs(t *testing.T) {
+	redhat := declcfg.DeclarativeConfig{
+		Packages: []declcfg.Package{{Name: "foo"}},
+		Channels: []declcfg.Channel{{Name: "stable", Package: "foo"}},
+		Bundles:  []declcfg.Bundle{{Name: "foo.v1.0.0", Package: "foo"}},
+	}
+	certified := declcfg.DeclarativeConfig{
+		Packages:     []declcfg.Package{{Name: "bar"}},
+		Channels:     []declcfg.Channel{{Name: "stable", Package: "bar"}},
+		Bundles:      []declcfg.Bundle{{Name: "bar.v1.0.0", Package: "bar"}},
+		Deprecations: []declcfg.Deprecation{{Package: "bar"}},
+	}
+
+	t.Run("Testing mergeDeclConfigs - distinct packages : should return the content of all catalogs", func(t *testing.T) {
+		merged, err := mergeDeclConfigs([]catalogConfig{{catalog: "redhat", dc: redhat}, {catalog: "certified", dc: certified}})
+		assert.NoError(t, err)
+		assert.Equal(t, []declcfg.Package{{Name: "foo"}, {Name: "bar"}}, merged.Packages)
+		assert.Len(t, merged.Channels, 2)
+		assert.Len(t, merged.Bundles, 2)
+		assert.Len(t, merged.Deprecations, 1)
+	})
+
+	t.Run("Testing mergeDeclConfigs - duplicate package : should fail", func(t *testing.T) {
+		_, err := mergeDeclConfigs([]catalogConfig{{catalog: "redhat", dc: redhat}, {catalog: "certified", dc: certified}, {catalog: "community", dc: redhat}})
+		assert.EqualError(t, err, "package foo is in both catalogs redhat and community")
+	})
+}
+
+func TestMergeTargetCatalogs(t *testing.T) {
+	redhatCatalog := "registry.redhat.io/redhat/redhat-operator-index:v4.16"
+	certifiedCatalog := "registry.redhat.io/redhat/certified-operator-index:v4.16"
+	operators := []v2alpha1.Operator{
+		{Catalog: redhatCatalog, TargetCatalog: "mirror/operator-index", TargetTag: "v4.16", MergeTargetCatalog: true},
+		{Catalog: certifiedCatalog, TargetCatalog: "mirror/operator-index", TargetTag: "v4.16", MergeTargetCatalog: true},
+	}
+
+	setup := func(workingDir string, mode string, certifiedPackage string) (FilterCollector, map[string]v2alpha1.CatalogFilterResult, map[string][]v2alpha1.RelatedImage) {
+		o := FilterCollector{OperatorCollector{
+			Log:    clog.New("debug"),
+			Config: v2alpha1.ImageSetConfiguration{ImageSetConfigurationSpec: v2alpha1.ImageSetConfigurationSpec{Mirror: v2alpha1.Mirror{Operators: operators}}},
+			Opts:   mirror.CopyOptions{Mode: mode, Global: &mirror.GlobalOptions{WorkingDir: workingDir}},
+		}}
+		results := map[string]v2alpha1.CatalogFilterResult{
+			"docker://" + redhatCatalog: {
+				OperatorFilter:     operators[0],
+				FilteredConfigPath: filepath.Join(workingDir, operatorCatalogsDir, "redhat-operator-index", "digest1", operatorCatalogFilteredDir, "filter1", operatorCatalogConfigDir),
+				ToRebuild:          true,
+				DeclConfig:         &declcfg.DeclarativeConfig{Packages: []declcfg.Package{{Name: "foo"}}},
+				Digest:             "digest1",
+			},
+			"docker://" + certifiedCatalog: {
+				OperatorFilter:     operators[1],
+				FilteredConfigPath: filepath.Join(workingDir, operatorCatalogsDir, "certified-operator-index", "digest2", operatorCatalogFilteredDir, "filter2", operatorCatalogConfigDir),
+				ToRebuild:          true,
+				DeclConfig:         &declcfg.DeclarativeConfig{Packages: []declcfg.Package{{Name: certifiedPackage}}},
+				Digest:             "digest2",
+			},
+		}
+		relatedImages := map[string][]v2alpha1.RelatedImage{
+			"redhat-operator-index.digest1":    {{Name: "mirror/operator-index", Image: redhatCatalog, Type: v2alpha1.TypeOperatorCatalog, TargetCatalog: "mirror/operator-index", TargetTag: "v4.16", RebuiltTag: "filter1"}},
+			"certified-operator-index.digest2": {{Name: "mirror/operator-index", Image: certifiedCatalog, Type: v2alpha1.TypeOperatorCatalog, TargetCatalog: "mirror/operator-index", TargetTag: "v4.16", RebuiltTag: "filter2"}},
+			"bar":                              {{Name: "bar", Image: "quay.io/certified/bar:v1", Type: v2alpha1.TypeOperatorBundle}},
+		}
+		return o, results, relatedImages
+	}
+
+	t.Run("Testing mergeTargetCatalogs - mirrorToDisk : should rebuild a single catalog with the content of both", func(t *testing.T) {
+		o, results, relatedImages := setup(t.TempDir(), mirror.MirrorToDisk, "bar")
+		err := o.mergeTargetCatalogs(context.Background(), results, relatedImages)
+		assert.NoError(t, err)
+
+		merged := results["docker://"+redhatCatalog]
+		assert.True(t, merged.ToRebuild)
+		assert.Equal(t, []declcfg.Package{{Name: "foo"}, {Name: "bar"}}, merged.DeclConfig.Packages)
+		assert.DirExists(t, merged.FilteredConfigPath)
+		mergeDigest := filepath.Base(filepath.Dir(merged.FilteredConfigPath))
+		assert.NotEqual(t, "filter1", mergeDigest)
+		assert.Equal(t, filepath.Join(o.Opts.Global.WorkingDir, operatorCatalogsDir, "redhat-operator-index", "digest1", operatorCatalogFilteredDir), filepath.Dir(filepath.Dir(merged.FilteredConfigPath)))
+
+		assert.Equal(t, mergeDigest, relatedImages["redhat-operator-index.digest1"][0].RebuiltTag)
+		assert.NotContains(t, relatedImages, "certified-operator-index.digest2")
+		assert.Contains(t, relatedImages, "bar")
+	})
+
+	t.Run("Testing mergeTargetCatalogs - duplicate package : should fail", func(t *testing.T) {
+		o, results, relatedImages := setup(t.TempDir(), mirror.MirrorToDisk, "foo")
+		err := o.mergeTargetCatalogs(context.Background(), results, relatedImages)
+		assert.ErrorContains(t, err, "merge into target catalog mirror/operator-index:v4.16: package foo is in both catalogs "+redhatCatalog+" and "+certifiedCatalog)
+	})
+
+	t.Run("Testing mergeTargetCatalogs - mirrorToDisk then diskToMirror : should copy the catalog merged by mirrorToDisk", func(t *testing.T) {
+		workingDir := t.TempDir()
+		o, results, relatedImages := setup(workingDir, mirror.MirrorToDisk, "bar")
+		err := o.mergeTargetCatalogs(context.Background(), results, relatedImages)
+		assert.NoError(t, err)
+		mergeDigest := relatedImages["redhat-operator-index.digest1"][0].RebuiltTag
+
+		for _, mode := range []string{mirror.DiskToMirror, string(mirror.DeleteMode)} {
+			d2m, results, relatedImages := setup(workingDir, mode, "bar")
+			err := d2m.mergeTargetCatalogs(context.Background(), results, relatedImages)
+			assert.NoError(t, err)
+			assert.Equal(t, mergeDigest, relatedImages["redhat-operator-index.digest1"][0].RebuiltTag)
+			assert.Equal(t, []declcfg.Package{{Name: "foo"}, {Name: "bar"}}, results["docker://"+redhatCatalog].DeclConfig.Packages)
+			assert.NotContains(t, relatedImages, "certified-operator-index.digest2")
+		}
+	})
+}
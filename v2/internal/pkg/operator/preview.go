@@ -0,0 +1,205 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/api/v2alpha1"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	clog "github.com/openshift/oc-mirror/v2/internal/pkg/log"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/manifest"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+// CatalogPreview is the content selected from a catalog by an Operator entry of the ImageSetConfiguration
+type CatalogPreview struct {
+	Catalog        string           `json:"catalog"`
+	Packages       []PackagePreview `json:"packages"`
+	RelatedImages  int              `json:"relatedImages"`
+	CompressedSize int64            `json:"compressedSize,omitempty"`
+	// Diff is the change compared to the catalog filtered with the same filter by the last mirroring run, when there is one
+	Diff *CatalogDiff `json:"diff,omitempty"`
+}
+
+// PackagePreview is a package selected from a catalog, with the number and the compressed size of its related images
+type PackagePreview struct {
+	PackageListing
+	RelatedImages  int   `json:"relatedImages"`
+	CompressedSize int64 `json:"compressedSize,omitempty"`
+}
+
+// PreviewFilters filters the catalogs of the ImageSetConfiguration, without mirroring any image, and
+// returns the content selected from each one. When withSizes is set, the compressed size of the related
+// images is computed from their manifests.
+// The catalogs are filtered in memory: the filtered catalogs of the working directory are left untouched.
+func PreviewFilters(ctx context.Context,
+	log clog.PluggableLoggerInterface,
+	config v2alpha1.ImageSetConfiguration,
+	opts mirror.CopyOptions,
+	mirror mirror.MirrorInterface,
+	manifest manifest.ManifestInterface,
+	withSizes bool,
+) ([]CatalogPreview, error) {
+	o := FilterCollector{OperatorCollector{Log: log, Config: config, Opts: opts, Mirror: mirror, Manifest: manifest, LocalStorageFQDN: opts.LocalStorageFQDN, ctlgHandler: catalogHandler{Log: log}}}
+	previews := []CatalogPreview{}
+	for _, op := range config.Mirror.Operators {
+		preview, err := o.previewFilter(ctx, op, withSizes)
+		if err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", op.Catalog, err)
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+func (o FilterCollector) previewFilter(ctx context.Context, op v2alpha1.Operator, withSizes bool) (CatalogPreview, error) {
+	op, err := resolvePlatformCompatibility(op, o.Config)
+	if err != nil {
+		return CatalogPreview{}, err
+	}
+	imgSpec, err := image.ParseRef(op.Catalog)
+	if err != nil {
+		return CatalogPreview{}, err
+	}
+	catalogDigest, err := o.getCatalogDigest(ctx, op)
+	if err != nil {
+		return CatalogPreview{}, err
+	}
+
+	filterDigest, err := digestOfFilter(op)
+	if err != nil {
+		return CatalogPreview{}, err
+	}
+	imageIndexDir := filepath.Join(o.Opts.Global.WorkingDir, operatorCatalogsDir, imgSpec.ComponentName(), catalogDigest)
+	filteredDC, err := o.originalDeclConfig(ctx, op, imgSpec, imageIndexDir)
+	if err != nil {
+		return CatalogPreview{}, err
+	}
+	if !isFullCatalog(op) {
+		if filteredDC, err = o.filteredDeclConfig(ctx, op, *filteredDC); err != nil {
+			return CatalogPreview{}, err
+		}
+	}
+	listing, err := listCatalog(op.Catalog, *filteredDC, "", "")
+	if err != nil {
+		return CatalogPreview{}, err
+	}
+
+	imagesByPackage := map[string][]string{}
+	for _, b := range filteredDC.Bundles {
+		imagesByPackage[b.Package] = appendImage(imagesByPackage[b.Package], b.Image)
+		for _, ri := range b.RelatedImages {
+			imagesByPackage[b.Package] = appendImage(imagesByPackage[b.Package], ri.Image)
+		}
+	}
+	var blobSizes map[string]map[string]int64
+	if withSizes {
+		blobSizes = o.imagesBlobSizes(ctx, imagesByPackage)
+	}
+
+	preview := CatalogPreview{Catalog: op.Catalog, Packages: []PackagePreview{}}
+	catalogImages := map[string]bool{}
+	catalogBlobs := map[string]int64{}
+	for _, pkg := range listing.Packages {
+		pkgBlobs := map[string]int64{}
+		for _, img := range imagesByPackage[pkg.Name] {
+			catalogImages[img] = true
+			for blob, size := range blobSizes[img] {
+				pkgBlobs[blob] = size
+				catalogBlobs[blob] = size
+			}
+		}
+		preview.Packages = append(preview.Packages, PackagePreview{
+			PackageListing: pkg,
+			RelatedImages:  len(imagesByPackage[pkg.Name]),
+			CompressedSize: sumSizes(pkgBlobs),
+		})
+	}
+	preview.RelatedImages = len(catalogImages)
+	preview.CompressedSize = sumSizes(catalogBlobs)
+
+	if previousConfigPath := o.lastMirroredConfig(imgSpec, filterDigest); previousConfigPath != "" {
+		previous, err := o.ctlgHandler.getDeclarativeConfig(previousConfigPath)
+		if err != nil {
+			o.Log.Warn("unable to load the previously filtered catalog %s: %v", previousConfigPath, err)
+		} else {
+			diff := diffCatalogs(*previous, *filteredDC)
+			preview.Diff = &diff
+		}
+	}
+	return preview, nil
+}
+
+// lastMirroredConfig returns the path of the declarative config of the catalog filtered with filterDigest
+// by the last mirroring run, whatever the digest of the catalog, or "" if there is none.
+// The catalogs rebuilt by a mirroring run have their digest recorded next to their declarative config.
+func (o FilterCollector) lastMirroredConfig(imgSpec image.ImageSpec, filterDigest string) string {
+	paths, err := filepath.Glob(filepath.Join(o.Opts.Global.WorkingDir, operatorCatalogsDir, imgSpec.ComponentName(), "*", operatorCatalogFilteredDir, filterDigest, "digest"))
+	if err != nil {
+		return ""
+	}
+	last := ""
+	var lastModTime int64
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		configDir := filepath.Join(filepath.Dir(p), operatorCatalogConfigDir)
+		if _, err := os.Stat(configDir); err != nil {
+			continue
+		}
+		if modTime := info.ModTime().UnixNano(); last == "" || modTime > lastModTime {
+			last, lastModTime = configDir, modTime
+		}
+	}
+	return last
+}
+
+// imagesBlobSizes returns the compressed size of the blobs of each image.
+// The images whose manifest cannot be read are logged and left out.
+func (o FilterCollector) imagesBlobSizes(ctx context.Context, imagesByPackage map[string][]string) map[string]map[string]int64 {
+	sizes := map[string]map[string]int64{}
+	srcCtx, err := o.Opts.SrcImage.NewSystemContext()
+	if err != nil {
+		o.Log.Warn("unable to compute the size of the related images: %v", err)
+		return sizes
+	}
+	for _, images := range imagesByPackage {
+		for _, img := range images {
+			if _, ok := sizes[img]; ok {
+				continue
+			}
+			imgSpec, err := image.ParseRef(img)
+			if err != nil {
+				o.Log.Warn("unable to compute the size of %s: %v", img, err)
+				continue
+			}
+			blobSizes, err := manifest.ImageBlobSizes(ctx, srcCtx, imgSpec.ReferenceWithTransport)
+			if err != nil {
+				o.Log.Warn("unable to compute the size of %s: %v", img, err)
+				continue
+			}
+			sizes[img] = blobSizes
+		}
+	}
+	return sizes
+}
+
+func appendImage(images []string, img string) []string {
+	if img == "" || slices.Contains(images, img) {
+		return images
+	}
+	return append(images, img)
+}
+
+func sumSizes(blobs map[string]int64) int64 {
+	var total int64
+	for _, size := range blobs {
+		total += size
+	}
+	return total
+}
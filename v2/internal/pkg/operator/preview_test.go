@@ -0,0 +1,40 @@
+package operator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/oc-mirror/v2/internal/pkg/image"
+	"github.com/openshift/oc-mirror/v2/internal/pkg/mirror"
+)
+
+func TestLastMirroredConfig(t *testing.T) {
+	workingDir := t.TempDir()
+	o := FilterCollector{OperatorCollector{Opts: mirror.CopyOptions{Global: &mirror.GlobalOptions{WorkingDir: workingDir}}}}
+	imgSpec, err := image.ParseRef("registry.redhat.io/redhat/redhat-operator-index:v4.16")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "", o.lastMirroredConfig(imgSpec, "filter1"))
+
+	catalogDir := filepath.Join(workingDir, operatorCatalogsDir, imgSpec.ComponentName())
+	mirrored := filepath.Join(catalogDir, "digest1", operatorCatalogFilteredDir, "filter1")
+	olderMirrored := filepath.Join(catalogDir, "digest0", operatorCatalogFilteredDir, "filter1")
+	// filtered without being mirrored
+	notMirrored := filepath.Join(catalogDir, "digest2", operatorCatalogFilteredDir, "filter1")
+	otherFilter := filepath.Join(catalogDir, "digest2", operatorCatalogFilteredDir, "filter2")
+	for _, dir := range []string{mirrored, olderMirrored, notMirrored, otherFilter} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, operatorCatalogConfigDir), 0755))
+	}
+	now := time.Now()
+	for dir, modTime := range map[string]time.Time{mirrored: now.Add(-time.Hour), olderMirrored: now.Add(-2 * time.Hour), otherFilter: now} {
+		digestFile := filepath.Join(dir, "digest")
+		assert.NoError(t, os.WriteFile(digestFile, []byte("sha256:1234"), 0600))
+		assert.NoError(t, os.Chtimes(digestFile, modTime, modTime))
+	}
+	assert.Equal(t, filepath.Join(mirrored, operatorCatalogConfigDir), o.lastMirroredConfig(imgSpec, "filter1"))
+	assert.Equal(t, "", o.lastMirroredConfig(imgSpec, "filter3"))
+}